@@ -72,7 +72,14 @@ func (s *serverSecure) DogstatsdCaptureTrigger(ctx context.Context, req *pb.Capt
 		return &pb.CaptureTriggerResponse{}, err
 	}
 
-	err = common.DSD.Capture(req.GetPath(), d, req.GetCompressed())
+	filter := &dsdReplay.CaptureFilter{
+		MetricPrefixes: req.GetMetricPrefixes(),
+		Pids:           req.GetPids(),
+		ContainerIDs:   req.GetContainerIds(),
+		Tags:           req.GetTags(),
+	}
+
+	err = common.DSD.Capture(req.GetPath(), d, req.GetCompressed(), filter)
 	if err != nil {
 		return &pb.CaptureTriggerResponse{}, err
 	}
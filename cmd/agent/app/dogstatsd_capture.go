@@ -27,9 +27,13 @@ import (
 )
 
 var (
-	dsdCaptureDuration   time.Duration
-	dsdCaptureFilePath   string
-	dsdCaptureCompressed bool
+	dsdCaptureDuration       time.Duration
+	dsdCaptureFilePath       string
+	dsdCaptureCompressed     bool
+	dsdCaptureMetricPrefixes []string
+	dsdCapturePids           []int32
+	dsdCaptureContainerIDs   []string
+	dsdCaptureTags           []string
 )
 
 const (
@@ -41,6 +45,10 @@ func init() {
 	dogstatsdCaptureCmd.Flags().DurationVarP(&dsdCaptureDuration, "duration", "d", defaultCaptureDuration, "Duration traffic capture should span.")
 	dogstatsdCaptureCmd.Flags().StringVarP(&dsdCaptureFilePath, "path", "p", "", "Directory path to write the capture to.")
 	dogstatsdCaptureCmd.Flags().BoolVarP(&dsdCaptureCompressed, "compressed", "z", true, "Should capture be zstd compressed.")
+	dogstatsdCaptureCmd.Flags().StringSliceVar(&dsdCaptureMetricPrefixes, "metric-prefix", nil, "Only capture the metrics whose name starts with one of these prefixes.")
+	dogstatsdCaptureCmd.Flags().Int32SliceVar(&dsdCapturePids, "pid", nil, "Only capture the traffic sent by these PIDs, requires origin detection.")
+	dogstatsdCaptureCmd.Flags().StringSliceVar(&dsdCaptureContainerIDs, "container-id", nil, "Only capture the traffic sent by these containers, requires origin detection.")
+	dogstatsdCaptureCmd.Flags().StringSliceVar(&dsdCaptureTags, "tag", nil, "Only capture the messages with one of these tags, given as key:value or as a tag key.")
 
 	// shut up grpc client!
 	grpclog.SetLoggerV2(grpclog.NewLoggerV2(ioutil.Discard, ioutil.Discard, ioutil.Discard))
@@ -108,9 +116,13 @@ func dogstatsdCapture() error {
 	cli := pb.NewAgentSecureClient(conn)
 
 	resp, err := cli.DogstatsdCaptureTrigger(ctx, &pb.CaptureTriggerRequest{
-		Duration:   dsdCaptureDuration.String(),
-		Path:       dsdCaptureFilePath,
-		Compressed: dsdCaptureCompressed,
+		Duration:       dsdCaptureDuration.String(),
+		Path:           dsdCaptureFilePath,
+		Compressed:     dsdCaptureCompressed,
+		MetricPrefixes: dsdCaptureMetricPrefixes,
+		Pids:           dsdCapturePids,
+		ContainerIds:   dsdCaptureContainerIDs,
+		Tags:           dsdCaptureTags,
 	})
 	if err != nil {
 		return err
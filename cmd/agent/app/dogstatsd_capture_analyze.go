@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2018-present Datadog, Inc.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var (
+	dsdAnalyzeFilePath string
+	dsdAnalyzeMmap     bool
+	dsdAnalyzeTop      int
+	dsdAnalyzeJSON     bool
+)
+
+const (
+	defaultAnalyzeTop = 20
+)
+
+func init() {
+	dogstatsdCaptureCmd.AddCommand(dogstatsdCaptureAnalyzeCmd)
+	dogstatsdCaptureAnalyzeCmd.Flags().StringVarP(&dsdAnalyzeFilePath, "file", "f", "", "Capture file to analyze.")
+	dogstatsdCaptureAnalyzeCmd.Flags().BoolVarP(&dsdAnalyzeMmap, "mmap", "m", true, "Mmap file for analysis. Set to false to load the entire file into memory instead")
+	dogstatsdCaptureAnalyzeCmd.Flags().IntVarP(&dsdAnalyzeTop, "top", "n", defaultAnalyzeTop, "Number of metrics, tag keys and origins to report, 0 to report all of them.")
+	dogstatsdCaptureAnalyzeCmd.Flags().BoolVarP(&dsdAnalyzeJSON, "json", "j", false, "print out raw json")
+}
+
+var dogstatsdCaptureAnalyzeCmd = &cobra.Command{
+	Use:   "analyze",
+	Short: "Report the top metrics, tag cardinality, origins and malformed packets of a dogstatsd capture file",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if flagNoColor {
+			color.NoColor = true
+		}
+
+		if dsdAnalyzeFilePath == "" {
+			return fmt.Errorf("a capture file must be specified with --file")
+		}
+
+		err := common.SetupConfigWithoutSecrets(confFilePath, "")
+		if err != nil {
+			return fmt.Errorf("unable to set up global agent configuration: %v", err)
+		}
+
+		err = config.SetupLogger(loggerName, config.GetEnvDefault("DD_LOG_LEVEL", "off"), "", "", false, true, false)
+		if err != nil {
+			fmt.Printf("Cannot setup logger, exiting: %v\n", err)
+			return err
+		}
+
+		return dogstatsdCaptureAnalyze()
+	},
+}
+
+func dogstatsdCaptureAnalyze() error {
+	reader, err := replay.NewTrafficCaptureReader(dsdAnalyzeFilePath, 0, dsdAnalyzeMmap)
+	if reader != nil {
+		defer reader.Close()
+	}
+
+	if err != nil {
+		fmt.Printf("could not open: %s\n", dsdAnalyzeFilePath)
+		return err
+	}
+
+	analysis, err := dogstatsd.AnalyzeCapture(reader, dsdAnalyzeTop)
+	if err != nil {
+		return fmt.Errorf("unable to analyze the capture, the file may be corrupt: %v", err)
+	}
+
+	if dsdAnalyzeJSON {
+		r, err := json.Marshal(analysis)
+		if err != nil {
+			return err
+		}
+		var prettyJSON bytes.Buffer
+		json.Indent(&prettyJSON, r, "", "  ") //nolint:errcheck
+		fmt.Println(prettyJSON.String())
+		return nil
+	}
+
+	fmt.Println(analysis.Format())
+	return nil
+}
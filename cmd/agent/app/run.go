@@ -358,6 +358,7 @@ func StartAgent() error {
 	forwarderOpts.EnabledFeatures = forwarder.SetFeature(forwarderOpts.EnabledFeatures, forwarder.CoreFeatures)
 	opts := aggregator.DefaultDemultiplexerOptions(forwarderOpts)
 	opts.UseContainerLifecycleForwarder = config.Datadog.GetBool("container_lifecycle.enabled")
+	opts.UseRemoteWriteForwarder = config.Datadog.GetBool("remote_write.enabled")
 	demux = aggregator.InitAndStartAgentDemultiplexer(opts, hostname)
 	demux.AddAgentStartupTelemetry(version.AgentVersion)
 
@@ -202,6 +202,7 @@ func runAgent(ctx context.Context) (err error) {
 	opts.UseOrchestratorForwarder = false
 	opts.UseEventPlatformForwarder = false
 	opts.UseContainerLifecycleForwarder = false
+	opts.UseRemoteWriteForwarder = config.Datadog.GetBool("remote_write.enabled")
 	hname, err := util.GetHostname(context.TODO())
 	if err != nil {
 		log.Warnf("Error getting hostname: %s", err)
@@ -208,6 +208,26 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 	if coreconfig.Datadog.IsSet("apm_config.max_remote_traces_per_second") {
 		c.MaxRemoteTPS = coreconfig.Datadog.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
+	if coreconfig.Datadog.IsSet("apm_config.tail_sampling.enabled") {
+		c.TailSampling.Enabled = coreconfig.Datadog.GetBool("apm_config.tail_sampling.enabled")
+	}
+	if k := "apm_config.tail_sampling.decision_wait_seconds"; coreconfig.Datadog.IsSet(k) {
+		c.TailSampling.DecisionWait = getDuration(coreconfig.Datadog.GetInt(k))
+	}
+	if k := "apm_config.tail_sampling.max_traces"; coreconfig.Datadog.IsSet(k) {
+		c.TailSampling.MaxTraces = coreconfig.Datadog.GetInt(k)
+	}
+	if k := "apm_config.tail_sampling.max_spans"; coreconfig.Datadog.IsSet(k) {
+		c.TailSampling.MaxSpans = coreconfig.Datadog.GetInt(k)
+	}
+	if k := "apm_config.tail_sampling.policies"; coreconfig.Datadog.IsSet(k) {
+		var policies []*config.TailSamplingPolicy
+		if err := coreconfig.Datadog.UnmarshalKey(k, &policies); err != nil {
+			log.Errorf("Bad format for %q it should be a list of policies such as '[{\"name\": \"slow\", \"latency_threshold_ms\": 500}]', error: %v", k, err)
+		} else {
+			c.TailSampling.Policies = policies
+		}
+	}
 
 	if k := "apm_config.ignore_resources"; coreconfig.Datadog.IsSet(k) {
 		c.Ignore["resource"] = coreconfig.Datadog.GetStringSlice(k)
@@ -227,6 +247,22 @@ func applyDatadogConfig(c *config.AgentConfig) error {
 			c.ReplaceTags = rt
 		}
 	}
+	if k := "apm_config.span_rules"; coreconfig.Datadog.IsSet(k) {
+		var rules []*config.SpanRule
+		if err := coreconfig.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be a list of rules such as '[{\"name\": \"health_checks\", \"resource\": \"^GET /health\", \"drop\": \"trace\"}]', error: %v", k, err)
+		} else {
+			c.SpanRules = rules
+		}
+	}
+	if k := "apm_config.stats_dimensions"; coreconfig.Datadog.IsSet(k) {
+		var dims []*config.StatsDimension
+		if err := coreconfig.Datadog.UnmarshalKey(k, &dims); err != nil {
+			log.Errorf("Bad format for %q it should be a list of dimensions such as '[{\"tag\": \"region\", \"max_cardinality\": 10}]', error: %v", k, err)
+		} else {
+			c.StatsDimensions = dims
+		}
+	}
 
 	if coreconfig.Datadog.IsSet("bind_host") || coreconfig.Datadog.IsSet("apm_config.apm_non_local_traffic") {
 		if coreconfig.Datadog.IsSet("bind_host") {
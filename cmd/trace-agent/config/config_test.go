@@ -467,6 +467,42 @@ func TestFullYamlConfig(t *testing.T) {
 
 	assert.EqualValues([]string{"/health", "/500"}, c.Ignore["resource"])
 
+	assert.Equal([]*config.SpanRule{
+		{Name: "health_checks", Resource: "^GET /health", Drop: "trace"},
+		{
+			Name:          "pii",
+			Service:       "^web$",
+			Meta:          map[string]string{"user.email": ""},
+			Metrics:       map[string]string{"http.status_code": ">= 500"},
+			DeleteTags:    []string{"user.email"},
+			HashTags:      []string{"user.id"},
+			RenameService: "web-store",
+			AddTags:       map[string]string{"pii": "redacted"},
+		},
+	}, c.SpanRules)
+
+	assert.Equal([]*config.StatsDimension{
+		{Tag: "region", MaxCardinality: 20},
+		{Tag: "peer.service"},
+	}, c.StatsDimensions)
+
+	assert.Equal(&config.TailSamplingConfig{
+		Enabled:      true,
+		DecisionWait: 30 * time.Second,
+		MaxTraces:    1000,
+		MaxSpans:     1_000_000,
+		Policies: []*config.TailSamplingPolicy{
+			{Name: "slow", LatencyThresholdMs: 500},
+			{
+				Name:       "checkout_errors",
+				Error:      true,
+				Service:    "web",
+				Resource:   "^POST /checkout",
+				Attributes: map[string]string{"http.status_code": "500"},
+			},
+		},
+	}, c.TailSampling)
+
 	o := c.Obfuscation
 	assert.NotNil(o)
 	assert.True(o.ES.Enabled)
@@ -830,6 +866,50 @@ func TestLoadEnv(t *testing.T) {
 		assert.Contains(cfg.ReplaceTags, rule2)
 	})
 
+	env = "DD_APM_TAIL_SAMPLING_POLICIES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `[{"name":"errors","error":true,"attributes":{"team":"payments"}}]`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.TailSamplingPolicy{
+			{Name: "errors", Error: true, Attributes: map[string]string{"team": "payments"}},
+		}, cfg.TailSampling.Policies)
+	})
+
+	env = "DD_APM_SPAN_RULES"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `[{"name":"health_checks","resource":"^GET /health","drop":"trace"},{"meta":{"user.id":""},"hash_tags":["user.id"]}]`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.SpanRule{
+			{Name: "health_checks", Resource: "^GET /health", Drop: "trace"},
+			{Meta: map[string]string{"user.id": ""}, HashTags: []string{"user.id"}},
+		}, cfg.SpanRules)
+	})
+
+	env = "DD_APM_STATS_DIMENSIONS"
+	t.Run(env, func(t *testing.T) {
+		defer cleanConfig()()
+		assert := assert.New(t)
+		err := os.Setenv(env, `[{"tag":"tenant_tier","max_cardinality":5},{"tag":"region"}]`)
+		assert.NoError(err)
+		defer os.Unsetenv(env)
+		cfg, err := LoadConfigFile("./testdata/full.yaml")
+		assert.NoError(err)
+		assert.Equal([]*config.StatsDimension{
+			{Tag: "tenant_tier", MaxCardinality: 5},
+			{Tag: "region"},
+		}, cfg.StatsDimensions)
+	})
+
 	env = "DD_APM_FILTER_TAGS_REQUIRE"
 	t.Run(env, func(t *testing.T) {
 		defer cleanConfig()()
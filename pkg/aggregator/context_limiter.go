@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// overflowDrop drops the samples of the contexts over the limits
+	overflowDrop = "drop"
+	// overflowCollapse aggregates the samples of the contexts over the limits
+	// into a single context per metric name, tagged with overflowTag
+	overflowCollapse = "collapse"
+
+	overflowTag = "overflow:true"
+
+	// number of offenders reported in the status and the telemetry
+	contextLimiterTopOffenders = 10
+)
+
+var (
+	tlmContextsOverLimit = telemetry.NewCounter("aggregator", "dogstatsd_contexts_over_limit",
+		[]string{"limit"}, "Count the number of new dogstatsd contexts over the per-metric or per-origin limits")
+	tlmContextsOverLimitTop = telemetry.NewGauge("aggregator", "dogstatsd_contexts_over_limit_top",
+		[]string{"limit", "name"}, "Number of new dogstatsd contexts over the limits for the top offending metrics and origins")
+)
+
+// contextLimiter limits the number of contexts tracked per metric name and per
+// origin. It is shared by the time samplers, so it is safe for concurrent use.
+type contextLimiter struct {
+	mu sync.Mutex
+
+	metricLimit int
+	originLimit int
+	collapse    bool
+
+	contextsByMetric map[string]int
+	contextsByOrigin map[string]int
+
+	// number of contexts rejected since the start, they are never reset
+	overLimitByMetric map[string]uint64
+	overLimitByOrigin map[string]uint64
+	overLimit         uint64
+
+	// offenders currently reported by the telemetry
+	tlmOffenders map[[2]string]struct{}
+}
+
+// newContextLimiter returns a limiter allowing metricLimit contexts per metric
+// name and originLimit contexts per origin, a limit of 0 disables it. The
+// contexts over the limits are either dropped or collapsed.
+func newContextLimiter(metricLimit, originLimit int, overflow string) *contextLimiter {
+	return &contextLimiter{
+		metricLimit:       metricLimit,
+		originLimit:       originLimit,
+		collapse:          overflow == overflowCollapse,
+		contextsByMetric:  make(map[string]int),
+		contextsByOrigin:  make(map[string]int),
+		overLimitByMetric: make(map[string]uint64),
+		overLimitByOrigin: make(map[string]uint64),
+		tlmOffenders:      make(map[[2]string]struct{}),
+	}
+}
+
+// newContextLimiterFromConfig returns the limiter configured for the dogstatsd
+// contexts, or nil if no limit is set.
+func newContextLimiterFromConfig() *contextLimiter {
+	metricLimit := config.Datadog.GetInt("dogstatsd_context_limit_per_metric")
+	originLimit := config.Datadog.GetInt("dogstatsd_context_limit_per_origin")
+	if metricLimit <= 0 && originLimit <= 0 {
+		return nil
+	}
+	if metricLimit < 0 {
+		metricLimit = 0
+	}
+	if originLimit < 0 {
+		originLimit = 0
+	}
+
+	overflow := config.Datadog.GetString("dogstatsd_context_limit_overflow")
+	if overflow != overflowDrop && overflow != overflowCollapse {
+		log.Warnf("Invalid dogstatsd_context_limit_overflow %q, must be %q or %q, defaulting to %q", overflow, overflowDrop, overflowCollapse, overflowDrop)
+		overflow = overflowDrop
+	}
+
+	return newContextLimiter(metricLimit, originLimit, overflow)
+}
+
+// track returns true and counts a new context if it is within the limits. The
+// origin of the sample is empty when it is unknown, the per-origin limit is
+// then not applied.
+func (l *contextLimiter) track(name, origin string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.metricLimit > 0 && l.contextsByMetric[name] >= l.metricLimit {
+		l.overLimitByMetric[name]++
+		l.overLimit++
+		tlmContextsOverLimit.Inc("metric")
+		return false
+	}
+	if l.originLimit > 0 && origin != "" && l.contextsByOrigin[origin] >= l.originLimit {
+		l.overLimitByOrigin[origin]++
+		l.overLimit++
+		tlmContextsOverLimit.Inc("origin")
+		return false
+	}
+
+	l.contextsByMetric[name]++
+	if origin != "" {
+		l.contextsByOrigin[origin]++
+	}
+	return true
+}
+
+// remove stops counting a context previously accepted by track.
+func (l *contextLimiter) remove(name, origin string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.contextsByMetric[name] <= 1 {
+		delete(l.contextsByMetric, name)
+	} else {
+		l.contextsByMetric[name]--
+	}
+
+	if origin == "" {
+		return
+	}
+	if l.contextsByOrigin[origin] <= 1 {
+		delete(l.contextsByOrigin, origin)
+	} else {
+		l.contextsByOrigin[origin]--
+	}
+}
+
+// contextLimiterOffender is a metric or an origin with contexts over the limits.
+type contextLimiterOffender struct {
+	Name      string
+	Contexts  int
+	OverLimit uint64
+}
+
+func (l *contextLimiter) topOffenders(overLimit map[string]uint64, contexts map[string]int) []contextLimiterOffender {
+	offenders := make([]contextLimiterOffender, 0, len(overLimit))
+	for name, count := range overLimit {
+		offenders = append(offenders, contextLimiterOffender{Name: name, Contexts: contexts[name], OverLimit: count})
+	}
+	sort.Slice(offenders, func(i, j int) bool {
+		if offenders[i].OverLimit != offenders[j].OverLimit {
+			return offenders[i].OverLimit > offenders[j].OverLimit
+		}
+		return offenders[i].Name < offenders[j].Name
+	})
+	if len(offenders) > contextLimiterTopOffenders {
+		offenders = offenders[:contextLimiterTopOffenders]
+	}
+	return offenders
+}
+
+// updateTelemetry reports the top offenders in the telemetry.
+func (l *contextLimiter) updateTelemetry() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := make(map[[2]string]struct{})
+	for _, offender := range l.topOffenders(l.overLimitByMetric, l.contextsByMetric) {
+		tlmContextsOverLimitTop.Set(float64(offender.OverLimit), "metric", offender.Name)
+		current[[2]string{"metric", offender.Name}] = struct{}{}
+	}
+	for _, offender := range l.topOffenders(l.overLimitByOrigin, l.contextsByOrigin) {
+		tlmContextsOverLimitTop.Set(float64(offender.OverLimit), "origin", offender.Name)
+		current[[2]string{"origin", offender.Name}] = struct{}{}
+	}
+
+	for offender := range l.tlmOffenders {
+		if _, found := current[offender]; !found {
+			tlmContextsOverLimitTop.Delete(offender[0], offender[1])
+		}
+	}
+	l.tlmOffenders = current
+}
+
+// exp returns the state of the limiter for the expvars and the status page.
+func (l *contextLimiter) exp() interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	overflow := overflowDrop
+	if l.collapse {
+		overflow = overflowCollapse
+	}
+
+	return map[string]interface{}{
+		"MetricLimit": l.metricLimit,
+		"OriginLimit": l.originLimit,
+		"Overflow":    overflow,
+		"OverLimit":   l.overLimit,
+		"TopMetrics":  l.topOffenders(l.overLimitByMetric, l.contextsByMetric),
+		"TopOrigins":  l.topOffenders(l.overLimitByOrigin, l.contextsByOrigin),
+	}
+}
+
+// sampleOrigin returns the origin of a sample, or an empty string if it is unknown.
+func sampleOrigin(metricSampleContext metrics.MetricSampleContext) string {
+	sample, ok := metricSampleContext.(*metrics.MetricSample)
+	if !ok {
+		return ""
+	}
+	if sample.OriginFromUDS != "" {
+		return sample.OriginFromUDS
+	}
+	return sample.OriginFromClient
+}
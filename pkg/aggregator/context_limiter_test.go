@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package aggregator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func TestContextLimiterPerMetric(t *testing.T) {
+	limiter := newContextLimiter(2, 0, overflowDrop)
+
+	assert.True(t, limiter.track("foo", ""))
+	assert.True(t, limiter.track("foo", "origin"))
+	assert.False(t, limiter.track("foo", "origin"))
+	assert.True(t, limiter.track("bar", "origin"))
+
+	limiter.remove("foo", "origin")
+	assert.True(t, limiter.track("foo", ""))
+	assert.False(t, limiter.track("foo", ""))
+
+	assert.Equal(t, uint64(2), limiter.overLimitByMetric["foo"])
+	assert.Empty(t, limiter.overLimitByOrigin)
+}
+
+func TestContextLimiterPerOrigin(t *testing.T) {
+	limiter := newContextLimiter(0, 2, overflowDrop)
+
+	assert.True(t, limiter.track("foo", "origin1"))
+	assert.True(t, limiter.track("bar", "origin1"))
+	assert.False(t, limiter.track("baz", "origin1"))
+	assert.True(t, limiter.track("baz", "origin2"))
+
+	// the per-origin limit does not apply to the samples with no origin
+	for i := 0; i < 5; i++ {
+		assert.True(t, limiter.track("foo", ""))
+	}
+
+	limiter.remove("foo", "origin1")
+	assert.True(t, limiter.track("baz", "origin1"))
+	assert.Equal(t, uint64(1), limiter.overLimitByOrigin["origin1"])
+}
+
+func TestContextLimiterTopOffenders(t *testing.T) {
+	limiter := newContextLimiter(1, 0, overflowDrop)
+	for i := 0; i < contextLimiterTopOffenders+5; i++ {
+		name := fmt.Sprintf("metric%02d", i)
+		for j := 0; j <= i+1; j++ {
+			limiter.track(name, "")
+		}
+	}
+
+	exp := limiter.exp().(map[string]interface{})
+	assert.Equal(t, 1, exp["MetricLimit"])
+	assert.Equal(t, overflowDrop, exp["Overflow"])
+
+	top := exp["TopMetrics"].([]contextLimiterOffender)
+	require.Len(t, top, contextLimiterTopOffenders)
+	assert.Equal(t, contextLimiterOffender{Name: "metric14", Contexts: 1, OverLimit: 15}, top[0])
+	assert.Equal(t, "metric05", top[contextLimiterTopOffenders-1].Name)
+
+	limiter.updateTelemetry()
+	assert.Len(t, limiter.tlmOffenders, contextLimiterTopOffenders)
+}
+
+func TestNewContextLimiterFromConfig(t *testing.T) {
+	assert.Nil(t, newContextLimiterFromConfig())
+
+	config.Datadog.Set("dogstatsd_context_limit_per_origin", 10)
+	config.Datadog.Set("dogstatsd_context_limit_overflow", "invalid")
+	defer config.Datadog.Set("dogstatsd_context_limit_per_origin", 0)
+	defer config.Datadog.Set("dogstatsd_context_limit_overflow", overflowDrop)
+
+	limiter := newContextLimiterFromConfig()
+	require.NotNil(t, limiter)
+	assert.Equal(t, 0, limiter.metricLimit)
+	assert.Equal(t, 10, limiter.originLimit)
+	assert.False(t, limiter.collapse)
+}
+
+func testContextResolverLimitDrop(t *testing.T, store *tags.Store) {
+	limiter := newContextLimiter(2, 0, overflowDrop)
+	resolver := newTimestampContextResolver(store, limiter, nil)
+
+	for i := 0; i < 4; i++ {
+		_, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{fmt.Sprintf("id:%d", i)}}, 1)
+		assert.Equal(t, i < 2, ok)
+	}
+	// the existing contexts are still tracked
+	_, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"id:1"}}, 2)
+	assert.True(t, ok)
+	assert.Equal(t, 2, resolver.length())
+
+	// the expired contexts are released from the limiter
+	assert.Len(t, resolver.expireContexts(2), 1)
+	_, ok = resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"id:3"}}, 3)
+	assert.True(t, ok)
+	assert.Equal(t, 2, limiter.contextsByMetric["foo"])
+}
+func TestContextResolverLimitDrop(t *testing.T) {
+	testWithTagsStore(t, testContextResolverLimitDrop)
+}
+
+func testContextResolverLimitCollapse(t *testing.T, store *tags.Store) {
+	limiter := newContextLimiter(0, 1, overflowCollapse)
+	resolver := newTimestampContextResolver(store, limiter, nil)
+
+	key1, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"id:1"}, OriginFromUDS: "origin"}, 1)
+	assert.True(t, ok)
+	key2, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"id:2"}, Host: "host", OriginFromUDS: "origin"}, 1)
+	assert.True(t, ok)
+	key3, ok := resolver.trackContext(&metrics.MetricSample{Name: "foo", Tags: []string{"id:3"}, Host: "host", OriginFromUDS: "origin"}, 1)
+	assert.True(t, ok)
+
+	assert.NotEqual(t, key1, key2)
+	assert.Equal(t, key2, key3)
+	assert.Equal(t, 2, resolver.length())
+
+	context, found := resolver.get(key2)
+	require.True(t, found)
+	assert.Equal(t, "foo", context.Name)
+	assert.Equal(t, "host", context.Host)
+	metrics.AssertCompositeTagsEqual(t, context.Tags(), tagset.CompositeTagsFromSlice([]string{overflowTag}))
+
+	// the overflow context is not counted by the limiter
+	assert.Len(t, resolver.expireContexts(2), 2)
+	assert.Empty(t, limiter.contextsByOrigin)
+	assert.Empty(t, limiter.contextsByMetric)
+}
+func TestContextResolverLimitCollapse(t *testing.T) {
+	testWithTagsStore(t, testContextResolverLimitCollapse)
+}
+
+func TestTimeSamplerContextLimit(t *testing.T) {
+	limiter := newContextLimiter(1, 0, overflowDrop)
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), limiter, nil)
+
+	sampler.sample(&metrics.MetricSample{Name: "foo", Value: 1, Mtype: metrics.GaugeType, Tags: []string{"id:1"}, SampleRate: 1}, 12345.0)
+	sampler.sample(&metrics.MetricSample{Name: "foo", Value: 2, Mtype: metrics.GaugeType, Tags: []string{"id:2"}, SampleRate: 1}, 12345.0)
+	sampler.sample(&metrics.MetricSample{Name: "foo", Value: 3, Mtype: metrics.DistributionType, Tags: []string{"id:3"}, SampleRate: 1}, 12345.0)
+
+	series, sketches := flushSerie(sampler, 12360.0)
+	require.Len(t, series, 1)
+	assert.Equal(t, float64(1), series[0].Points[0].Value)
+	assert.Len(t, sketches, 0)
+}
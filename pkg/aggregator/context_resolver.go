@@ -21,6 +21,9 @@ type Context struct {
 	mtype      metrics.MetricType
 	taggerTags *tags.Entry
 	metricTags *tags.Entry
+	// origin and overflow are used to release the context from the limiter
+	origin   string
+	overflow bool
 }
 
 // Tags returns tags for the context.
@@ -41,6 +44,12 @@ type contextResolver struct {
 	keyGenerator  *ckey.KeyGenerator
 	taggerBuffer  *tagset.HashingTagsAccumulator
 	metricBuffer  *tagset.HashingTagsAccumulator
+	// limiter is nil when the number of contexts is not limited
+	limiter *contextLimiter
+	// tagRules is nil when the tags are not filtered, the rule matching each
+	// metric name (nil if none) is cached in tagRuleByName
+	tagRules      *tagRules
+	tagRuleByName map[string]*tagRule
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -48,7 +57,7 @@ func (cr *contextResolver) generateContextKey(metricSampleContext metrics.Metric
 	return cr.keyGenerator.GenerateWithTags2(metricSampleContext.GetName(), metricSampleContext.GetHost(), cr.taggerBuffer, cr.metricBuffer)
 }
 
-func newContextResolver(cache *tags.Store) *contextResolver {
+func newContextResolver(cache *tags.Store, limiter *contextLimiter, rules *tagRules) *contextResolver {
 	return &contextResolver{
 		contextsByKey: make(map[ckey.ContextKey]*Context),
 		countsByMtype: make([]uint64, metrics.NumMetricTypes),
@@ -56,30 +65,82 @@ func newContextResolver(cache *tags.Store) *contextResolver {
 		keyGenerator:  ckey.NewKeyGenerator(),
 		taggerBuffer:  tagset.NewHashingTagsAccumulator(),
 		metricBuffer:  tagset.NewHashingTagsAccumulator(),
+		limiter:       limiter,
+		tagRules:      rules,
+		tagRuleByName: make(map[string]*tagRule),
 	}
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer)                  // tags here are not sorted and can contain duplicates
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// When a new context is over the limits of the limiter, it returns false if the sample must be dropped, or the
+// key of the overflow context of the metric if the contexts over the limits are collapsed.
+func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) (ckey.ContextKey, bool) {
+	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer) // tags here are not sorted and can contain duplicates
+	if cr.tagRules != nil {
+		cr.applyTagRules(metricSampleContext.GetName())
+	}
 	contextKey, taggerKey, metricKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates (and doesn't mind the order)
 
+	tracked := true
 	if _, ok := cr.contextsByKey[contextKey]; !ok {
-		mtype := metricSampleContext.GetMetricType()
-		cr.contextsByKey[contextKey] = &Context{
-			Name:       metricSampleContext.GetName(),
-			taggerTags: cr.tagsCache.Insert(taggerKey, cr.taggerBuffer),
-			metricTags: cr.tagsCache.Insert(metricKey, cr.metricBuffer),
-			Host:       metricSampleContext.GetHost(),
-			mtype:      mtype,
+		origin := ""
+		if cr.limiter != nil {
+			origin = sampleOrigin(metricSampleContext)
+		}
+
+		if cr.limiter == nil || cr.limiter.track(metricSampleContext.GetName(), origin) {
+			cr.insertContext(metricSampleContext, contextKey, taggerKey, metricKey, origin, false)
+		} else if cr.limiter.collapse {
+			// the context is replaced by the overflow context of the metric
+			cr.taggerBuffer.Reset()
+			cr.metricBuffer.Reset()
+			cr.metricBuffer.Append(overflowTag)
+			contextKey, taggerKey, metricKey = cr.generateContextKey(metricSampleContext)
+			if _, ok := cr.contextsByKey[contextKey]; !ok {
+				cr.insertContext(metricSampleContext, contextKey, taggerKey, metricKey, "", true)
+			}
+		} else {
+			tracked = false
 		}
-		cr.countsByMtype[mtype]++
 	}
 
 	cr.taggerBuffer.Reset()
 	cr.metricBuffer.Reset()
 
-	return contextKey
+	return contextKey, tracked
+}
+
+// applyTagRules removes from the buffers the tags filtered out by the rule matching the metric name
+func (cr *contextResolver) applyTagRules(name string) {
+	rule, found := cr.tagRuleByName[name]
+	if !found {
+		rule = cr.tagRules.match(name)
+		if len(cr.tagRuleByName) >= tagRulesCacheSize {
+			cr.tagRuleByName = make(map[string]*tagRule)
+		}
+		cr.tagRuleByName[name] = rule
+	}
+	if rule == nil {
+		return
+	}
+
+	cr.taggerBuffer.Retain(rule.keepTag)
+	cr.metricBuffer.Retain(rule.keepTag)
+}
+
+// insertContext tracks a new context made of the tags in the buffers
+func (cr *contextResolver) insertContext(metricSampleContext metrics.MetricSampleContext, contextKey ckey.ContextKey, taggerKey, metricKey ckey.TagsKey, origin string, overflow bool) {
+	mtype := metricSampleContext.GetMetricType()
+	cr.contextsByKey[contextKey] = &Context{
+		Name:       metricSampleContext.GetName(),
+		taggerTags: cr.tagsCache.Insert(taggerKey, cr.taggerBuffer),
+		metricTags: cr.tagsCache.Insert(metricKey, cr.metricBuffer),
+		Host:       metricSampleContext.GetHost(),
+		mtype:      mtype,
+		origin:     origin,
+		overflow:   overflow,
+	}
+	cr.countsByMtype[mtype]++
 }
 
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -98,6 +159,9 @@ func (cr *contextResolver) removeKeys(expiredContextKeys []ckey.ContextKey) {
 
 		if context != nil {
 			cr.countsByMtype[context.mtype]--
+			if cr.limiter != nil && !context.overflow {
+				cr.limiter.remove(context.Name, context.origin)
+			}
 			context.release()
 		}
 	}
@@ -105,6 +169,9 @@ func (cr *contextResolver) removeKeys(expiredContextKeys []ckey.ContextKey) {
 
 func (cr *contextResolver) release() {
 	for _, c := range cr.contextsByKey {
+		if cr.limiter != nil && !c.overflow {
+			cr.limiter.remove(c.Name, c.origin)
+		}
 		c.release()
 	}
 }
@@ -115,9 +182,9 @@ type timestampContextResolver struct {
 	lastSeenByKey map[ckey.ContextKey]float64
 }
 
-func newTimestampContextResolver(cache *tags.Store) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, limiter *contextLimiter, rules *tagRules) *timestampContextResolver {
 	return &timestampContextResolver{
-		resolver:      newContextResolver(cache),
+		resolver:      newContextResolver(cache, limiter, rules),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 	}
 }
@@ -133,11 +200,14 @@ func (cr *timestampContextResolver) updateTrackedContext(contextKey ckey.Context
 	return nil
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
-	cr.lastSeenByKey[contextKey] = currentTimestamp
-	return contextKey
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context,
+// it returns false if the sample must be dropped because its context is over the limits.
+func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp float64) (ckey.ContextKey, bool) {
+	contextKey, ok := cr.resolver.trackContext(metricSampleContext)
+	if ok {
+		cr.lastSeenByKey[contextKey] = currentTimestamp
+	}
+	return contextKey, ok
 }
 
 func (cr *timestampContextResolver) length() int {
@@ -185,7 +255,7 @@ type countBasedContextResolver struct {
 
 func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *countBasedContextResolver {
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache),
+		resolver:            newContextResolver(cache, nil, nil),
 		expireCountByKey:    make(map[ckey.ContextKey]int64),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
@@ -194,7 +264,8 @@ func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *c
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *countBasedContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext)
+	// the contexts of the checks are not limited
+	contextKey, _ := cr.resolver.trackContext(metricSampleContext)
 	cr.expireCountByKey[contextKey] = cr.expireCount
 	return contextKey
 }
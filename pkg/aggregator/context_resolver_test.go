@@ -78,12 +78,12 @@ func testTrackContext(t *testing.T, store *tags.Store) {
 		SampleRate: 1,
 	}
 
-	contextResolver := newContextResolver(store)
+	contextResolver := newContextResolver(store, nil, nil)
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1)
-	contextKey2 := contextResolver.trackContext(&mSample2)
-	contextKey3 := contextResolver.trackContext(&mSample3)
+	contextKey1, _ := contextResolver.trackContext(&mSample1)
+	contextKey2, _ := contextResolver.trackContext(&mSample2)
+	contextKey3, _ := contextResolver.trackContext(&mSample3)
 
 	// When we look up the 2 keys, they return the correct contexts
 	context1 := contextResolver.contextsByKey[contextKey1]
@@ -122,11 +122,11 @@ func testExpireContexts(t *testing.T, store *tags.Store) {
 		Tags:       []string{"foo", "bar", "baz"},
 		SampleRate: 1,
 	}
-	contextResolver := newTimestampContextResolver(store)
+	contextResolver := newTimestampContextResolver(store, nil, nil)
 
 	// Track the 2 contexts
-	contextKey1 := contextResolver.trackContext(&mSample1, 4)
-	contextKey2 := contextResolver.trackContext(&mSample2, 6)
+	contextKey1, _ := contextResolver.trackContext(&mSample1, 4)
+	contextKey2, _ := contextResolver.trackContext(&mSample2, 6)
 
 	// With an expireTimestap of 3, both contexts are still valid
 	assert.Len(t, contextResolver.expireContexts(3), 0)
@@ -179,9 +179,9 @@ func TestCountBasedExpireContexts(t *testing.T) {
 }
 
 func testTagDeduplication(t *testing.T, store *tags.Store) {
-	resolver := newContextResolver(store)
+	resolver := newContextResolver(store, nil, nil)
 
-	ckey := resolver.trackContext(&metrics.MetricSample{
+	ckey, _ := resolver.trackContext(&metrics.MetricSample{
 		Name: "foo",
 		Tags: []string{"bar", "bar"},
 	})
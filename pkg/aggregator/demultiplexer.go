@@ -85,6 +85,7 @@ type DemultiplexerOptions struct {
 	UseEventPlatformForwarder      bool
 	UseOrchestratorForwarder       bool
 	UseContainerLifecycleForwarder bool
+	UseRemoteWriteForwarder        bool
 	FlushInterval                  time.Duration
 
 	DontStartForwarders bool // unit tests don't need the forwarders to be instanciated
@@ -126,6 +127,7 @@ func DefaultDemultiplexerOptions(options *forwarder.Options) DemultiplexerOption
 		UseEventPlatformForwarder:      true,
 		UseOrchestratorForwarder:       true,
 		UseContainerLifecycleForwarder: false,
+		UseRemoteWriteForwarder:        false,
 	}
 }
 
@@ -6,6 +6,7 @@
 package aggregator
 
 import (
+	"expvar"
 	"fmt"
 	"sync"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/epforwarder"
 	"github.com/DataDog/datadog-agent/pkg/forwarder"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/remotewrite"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -64,6 +66,7 @@ type forwarders struct {
 	orchestrator       *forwarder.DefaultForwarder
 	eventPlatform      epforwarder.EventPlatformForwarder
 	containerLifecycle *forwarder.DefaultForwarder
+	remoteWrite        forwarder.Forwarder
 }
 
 type dataOutputs struct {
@@ -115,6 +118,12 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 		containerLifecycleForwarder = containerlifecycle.NewForwarder()
 	}
 
+	// Prometheus remote write forwarder
+	var remoteWriteForwarder forwarder.Forwarder
+	if options.UseRemoteWriteForwarder {
+		remoteWriteForwarder = remotewrite.NewForwarder()
+	}
+
 	var sharedForwarder forwarder.Forwarder
 	if options.UseNoopForwarder {
 		sharedForwarder = forwarder.NoopForwarder{}
@@ -125,7 +134,7 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 	// prepare the serializer
 	// ----------------------
 
-	sharedSerializer := serializer.NewSerializer(sharedForwarder, orchestratorForwarder, containerLifecycleForwarder)
+	sharedSerializer := serializer.NewSerializer(sharedForwarder, orchestratorForwarder, containerLifecycleForwarder, remoteWriteForwarder)
 
 	// prepare the embedded aggregator
 	// --
@@ -143,10 +152,17 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 
 	statsdWorkers := make([]*timeSamplerWorker, statsdPipelinesCount)
 
+	// the contexts limits apply to all the samplers
+	limiter := newContextLimiterFromConfig()
+	if limiter != nil {
+		aggregatorExpvars.Set("ContextLimiter", expvar.Func(limiter.exp))
+	}
+	tagRules := newTagRulesFromConfig()
+
 	for i := 0; i < statsdPipelinesCount; i++ {
 		// the sampler
 		tagsStore := tags.NewStore(config.Datadog.GetBool("aggregator_use_tags_store"), fmt.Sprintf("timesampler #%d", i))
-		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore)
+		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore, limiter, tagRules)
 
 		// its worker (process loop + flush/serialization mechanism)
 
@@ -172,6 +188,7 @@ func initAgentDemultiplexer(options DemultiplexerOptions, hostname string) *Agen
 				orchestrator:       orchestratorForwarder,
 				eventPlatform:      eventPlatformForwarder,
 				containerLifecycle: containerLifecycleForwarder,
+				remoteWrite:        remoteWriteForwarder,
 			},
 
 			sharedSerializer: sharedSerializer,
@@ -244,6 +261,15 @@ func (d *AgentDemultiplexer) Run() {
 			log.Debug("not starting the container lifecycle forwarder")
 		}
 
+		// remote write forwarder
+		if d.forwarders.remoteWrite != nil {
+			if err := d.forwarders.remoteWrite.Start(); err != nil {
+				log.Errorf("error starting remote write forwarder: %s", err)
+			}
+		} else {
+			log.Debug("not starting the remote write forwarder")
+		}
+
 		// shared forwarder
 		if d.forwarders.shared != nil {
 			d.forwarders.shared.Start() //nolint:errcheck
@@ -343,6 +369,10 @@ func (d *AgentDemultiplexer) Stop(flush bool) {
 			d.dataOutputs.forwarders.containerLifecycle.Stop()
 			d.dataOutputs.forwarders.containerLifecycle = nil
 		}
+		if d.dataOutputs.forwarders.remoteWrite != nil {
+			d.dataOutputs.forwarders.remoteWrite.Stop()
+			d.dataOutputs.forwarders.remoteWrite = nil
+		}
 		if d.dataOutputs.forwarders.shared != nil {
 			d.dataOutputs.forwarders.shared.Stop()
 			d.dataOutputs.forwarders.shared = nil
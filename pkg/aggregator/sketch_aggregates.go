@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// sketchAggregates computes percentiles and cumulative bucket counts from the
+// sketches of the distributions, and sends them as regular series along with
+// the sketches.
+type sketchAggregates struct {
+	percentiles []float64 // each in the 0-1 range
+	suffixes    []string  // name suffix of each percentile
+	buckets     []float64 // sorted upper bounds of the buckets, ending with +Inf
+	bucketTags  []string  // `le` tag of each bucket
+}
+
+// newSketchAggregates returns the aggregates computing the given percentiles
+// and the buckets with the given upper bounds, or nil if there is none. The
+// invalid values are skipped.
+func newSketchAggregates(percentiles []string, buckets []string) *sketchAggregates {
+	a := &sketchAggregates{}
+
+	for _, p := range percentiles {
+		q, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			log.Errorf("Could not parse '%s' from 'dogstatsd_distribution_percentiles' (skipping): %s", p, err)
+			continue
+		}
+		if q < 0 || q > 1 {
+			log.Errorf("dogstatsd_distribution_percentiles must be between 0 and 1: skipping %f", q)
+			continue
+		}
+		a.percentiles = append(a.percentiles, q)
+	}
+	sort.Float64s(a.percentiles)
+	for _, q := range a.percentiles {
+		// rounded to avoid the float artifacts of the multiplication (ex: 0.29 would become 28.999999999999996)
+		p := strconv.FormatFloat(math.Round(q*100*1e6)/1e6, 'f', -1, 64)
+		a.suffixes = append(a.suffixes, "."+strings.Replace(p, ".", "_", 1)+"percentile")
+	}
+
+	for _, b := range buckets {
+		bound, err := strconv.ParseFloat(b, 64)
+		if err != nil || math.IsNaN(bound) {
+			log.Errorf("Could not parse '%s' from 'dogstatsd_distribution_buckets' (skipping): %v", b, err)
+			continue
+		}
+		if math.IsInf(bound, 1) {
+			// the +Inf bucket is always sent
+			continue
+		}
+		a.buckets = append(a.buckets, bound)
+	}
+	if len(a.percentiles) == 0 && len(a.buckets) == 0 {
+		return nil
+	}
+
+	if len(a.buckets) > 0 {
+		sort.Float64s(a.buckets)
+		uniq := a.buckets[:0]
+		for i, bound := range a.buckets {
+			if i == 0 || bound != a.buckets[i-1] {
+				uniq = append(uniq, bound)
+			}
+		}
+		a.buckets = append(uniq, math.Inf(1))
+		for _, bound := range a.buckets {
+			a.bucketTags = append(a.bucketTags, "le:"+strconv.FormatFloat(bound, 'f', -1, 64))
+		}
+	}
+	return a
+}
+
+// newSketchAggregatesFromConfig returns the aggregates configured for the
+// dogstatsd distributions, or nil if there is none.
+func newSketchAggregatesFromConfig() *sketchAggregates {
+	return newSketchAggregates(
+		config.Datadog.GetStringSlice("dogstatsd_distribution_percentiles"),
+		config.Datadog.GetStringSlice("dogstatsd_distribution_buckets"),
+	)
+}
+
+// flush appends to the sink the series computed from the sketches of the context,
+// with a point per sketch point.
+//
+// The percentiles are sent as gauges named `<metric>.<percentile>percentile`, a
+// decimal percentile has its dot replaced by an underscore (ex: 0.999 becomes
+// `.99_9percentile`). The number of values lower or equal to the upper bound of
+// each bucket is sent as a count named `<metric>.bucket`, with a `le` tag set
+// to the upper bound like the buckets of the Prometheus histograms. The bucket
+// counts are only accurate up to the relative accuracy of the sketch.
+func (a *sketchAggregates) flush(ck ckey.ContextKey, ctx *Context, interval int64, points []metrics.SketchPoint, series metrics.SerieSink) {
+	c := quantile.Default()
+	tags := ctx.Tags()
+
+	for i, q := range a.percentiles {
+		values := make([]metrics.Point, 0, len(points))
+		for _, p := range points {
+			values = append(values, metrics.Point{Ts: float64(p.Ts), Value: p.Sketch.Quantile(c, q)})
+		}
+		series.Append(&metrics.Serie{
+			Name:       ctx.Name + a.suffixes[i],
+			Points:     values,
+			Tags:       tags,
+			Host:       ctx.Host,
+			MType:      metrics.APIGaugeType,
+			Interval:   interval,
+			ContextKey: ck,
+			NameSuffix: a.suffixes[i],
+		})
+	}
+
+	for i, bound := range a.buckets {
+		values := make([]metrics.Point, 0, len(points))
+		for _, p := range points {
+			values = append(values, metrics.Point{Ts: float64(p.Ts), Value: float64(p.Sketch.CountBelow(c, bound))})
+		}
+		series.Append(&metrics.Serie{
+			Name:       ctx.Name + ".bucket",
+			Points:     values,
+			Tags:       tagset.CombineCompositeTagsAndSlice(tags, []string{a.bucketTags[i]}),
+			Host:       ctx.Host,
+			MType:      metrics.APICountType,
+			Interval:   interval,
+			ContextKey: ck,
+			NameSuffix: ".bucket",
+		})
+	}
+}
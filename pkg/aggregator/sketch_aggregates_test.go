@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package aggregator
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func TestNewSketchAggregates(t *testing.T) {
+	assert.Nil(t, newSketchAggregates(nil, nil))
+	assert.Nil(t, newSketchAggregates([]string{"abc", "1.5"}, []string{"+Inf", "x"}))
+
+	a := newSketchAggregates([]string{"0.999", "0.5", "0.29", "2"}, nil)
+	require.NotNil(t, a)
+	assert.Equal(t, []float64{0.29, 0.5, 0.999}, a.percentiles)
+	assert.Equal(t, []string{".29percentile", ".50percentile", ".99_9percentile"}, a.suffixes)
+	assert.Empty(t, a.buckets)
+
+	a = newSketchAggregates(nil, []string{"10", "0.5", "+Inf", "10", "x", "-1"})
+	require.NotNil(t, a)
+	assert.Empty(t, a.percentiles)
+	assert.Equal(t, []float64{-1, 0.5, 10, math.Inf(1)}, a.buckets)
+	assert.Equal(t, []string{"le:-1", "le:0.5", "le:10", "le:+Inf"}, a.bucketTags)
+}
+
+func TestTimeSamplerSketchAggregates(t *testing.T) {
+	config.Datadog.Set("dogstatsd_distribution_percentiles", []string{"0.5", "0.99"})
+	config.Datadog.Set("dogstatsd_distribution_buckets", []string{"10", "50"})
+	defer config.Datadog.Set("dogstatsd_distribution_percentiles", []string{})
+	defer config.Datadog.Set("dogstatsd_distribution_buckets", []string{})
+
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), nil, nil)
+	for i := 1; i <= 100; i++ {
+		sampler.sample(&metrics.MetricSample{Name: "latency", Value: float64(i), Mtype: metrics.DistributionType, Tags: []string{"env:prod"}, Host: "host", SampleRate: 1}, 12345.0)
+	}
+
+	series, sketches := flushSerie(sampler, 12360.0)
+	require.Len(t, sketches, 1)
+	require.Len(t, series, 5)
+
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Name != series[j].Name {
+			return series[i].Name < series[j].Name
+		}
+		return series[i].Points[0].Value < series[j].Points[0].Value
+	})
+
+	for _, serie := range series {
+		assert.Equal(t, "host", serie.Host)
+		assert.Equal(t, int64(10), serie.Interval)
+		assert.Equal(t, float64(12340), serie.Points[0].Ts)
+	}
+
+	assert.Equal(t, "latency.50percentile", series[0].Name)
+	assert.Equal(t, metrics.APIGaugeType, series[0].MType)
+	assert.InEpsilon(t, 51, series[0].Points[0].Value, 0.02)
+	metrics.AssertCompositeTagsEqual(t, series[0].Tags, tagset.CompositeTagsFromSlice([]string{"env:prod"}))
+	assert.Equal(t, "latency.99percentile", series[1].Name)
+	assert.InEpsilon(t, 99, series[1].Points[0].Value, 0.02)
+
+	expected := []struct {
+		tag   string
+		count float64
+	}{{"le:10", 10}, {"le:50", 50}, {"le:+Inf", 100}}
+	for i, bucket := range expected {
+		serie := series[2+i]
+		assert.Equal(t, "latency.bucket", serie.Name)
+		assert.Equal(t, metrics.APICountType, serie.MType)
+		assert.Equal(t, bucket.count, serie.Points[0].Value)
+		metrics.AssertCompositeTagsEqual(t, serie.Tags, tagset.CompositeTagsFromSlice([]string{"env:prod", bucket.tag}))
+	}
+}
+
+func TestTimeSamplerSketchAggregatesSeveralPoints(t *testing.T) {
+	config.Datadog.Set("dogstatsd_distribution_percentiles", []string{"0.5"})
+	config.Datadog.Set("dogstatsd_distribution_buckets", []string{"10"})
+	defer config.Datadog.Set("dogstatsd_distribution_percentiles", []string{})
+	defer config.Datadog.Set("dogstatsd_distribution_buckets", []string{})
+
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), nil, nil)
+	// the context has a sketch point in two time buckets
+	for i := 1; i <= 20; i++ {
+		sampler.sample(&metrics.MetricSample{Name: "latency", Value: float64(i), Mtype: metrics.DistributionType, Host: "host", SampleRate: 1}, 12345.0)
+		sampler.sample(&metrics.MetricSample{Name: "latency", Value: float64(i + 100), Mtype: metrics.DistributionType, Host: "host", SampleRate: 1}, 12355.0)
+	}
+
+	series, sketches := flushSerie(sampler, 12370.0)
+	require.Len(t, sketches, 1)
+	require.Len(t, sketches[0].Points, 2)
+	// a single serie per percentile and bucket, with a point per time bucket
+	require.Len(t, series, 3)
+	for _, serie := range series {
+		require.Len(t, serie.Points, 2)
+		sort.Slice(serie.Points, func(i, j int) bool { return serie.Points[i].Ts < serie.Points[j].Ts })
+		assert.Equal(t, float64(12340), serie.Points[0].Ts)
+		assert.Equal(t, float64(12350), serie.Points[1].Ts)
+		switch {
+		case serie.Name == "latency.50percentile":
+			assert.InEpsilon(t, 10.5, serie.Points[0].Value, 0.1)
+			assert.InEpsilon(t, 110.5, serie.Points[1].Value, 0.1)
+		case serie.Tags.Join(",") == "le:10":
+			assert.Equal(t, 10.0, serie.Points[0].Value)
+			assert.Equal(t, 0.0, serie.Points[1].Value)
+		default:
+			assert.Equal(t, "le:+Inf", serie.Tags.Join(","))
+			assert.Equal(t, 20.0, serie.Points[0].Value)
+			assert.Equal(t, 20.0, serie.Points[1].Value)
+		}
+	}
+}
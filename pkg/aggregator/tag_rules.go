@@ -0,0 +1,108 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// tagRuleKeep keeps only the listed tags of the matching metrics
+	tagRuleKeep = "keep"
+	// tagRuleDrop removes the listed tags from the matching metrics
+	tagRuleDrop = "drop"
+
+	// maximum number of metric names for which the matching rule is cached
+	tagRulesCacheSize = 10000
+)
+
+// tagRules keep or drop the tags of the metrics matching a pattern before their
+// context key is generated, so that the contexts only differing by these tags
+// are aggregated together. They are not modified once created, so they can be
+// shared by the time samplers.
+type tagRules struct {
+	rules []*tagRule
+}
+
+type tagRule struct {
+	pattern *regexp.Regexp
+	keep    bool
+	keys    map[string]struct{}
+}
+
+// newTagRules compiles the tag rules. The `*` wildcard of the patterns matches
+// any sequence of characters, dots included.
+func newTagRules(configRules []config.MetricTagRule) (*tagRules, error) {
+	rules := make([]*tagRule, 0, len(configRules))
+	for i, configRule := range configRules {
+		if configRule.Match == "" {
+			return nil, fmt.Errorf("rule %d: match is required", i)
+		}
+
+		var keep bool
+		switch configRule.Action {
+		case tagRuleKeep:
+			keep = true
+		case tagRuleDrop, "":
+			keep = false
+		default:
+			return nil, fmt.Errorf("rule %d: invalid action %q, must be %q or %q", i, configRule.Action, tagRuleKeep, tagRuleDrop)
+		}
+
+		// the quoted pattern is always a valid regex
+		regex := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(configRule.Match), `\*`, ".*") + "$")
+
+		keys := make(map[string]struct{}, len(configRule.Tags))
+		for _, key := range configRule.Tags {
+			keys[key] = struct{}{}
+		}
+
+		rules = append(rules, &tagRule{pattern: regex, keep: keep, keys: keys})
+	}
+	return &tagRules{rules: rules}, nil
+}
+
+// newTagRulesFromConfig returns the tag rules of the dogstatsd metrics, or nil
+// if there is none or if they are invalid.
+func newTagRulesFromConfig() *tagRules {
+	configRules, err := config.GetDogstatsdTagRules()
+	if err != nil || len(configRules) == 0 {
+		return nil
+	}
+
+	rules, err := newTagRules(configRules)
+	if err != nil {
+		log.Errorf("Invalid dogstatsd_tag_rules, the tags of the metrics will not be filtered: %s", err)
+		return nil
+	}
+	return rules
+}
+
+// match returns the first rule matching the metric name, or nil if none does.
+func (r *tagRules) match(name string) *tagRule {
+	for _, rule := range r.rules {
+		if rule.pattern.MatchString(name) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// keepTag returns true if the tag is kept by the rule. The key of a tag without
+// value is the tag itself.
+func (rule *tagRule) keepTag(tag string) bool {
+	key := tag
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		key = tag[:i]
+	}
+	_, found := rule.keys[key]
+	return found == rule.keep
+}
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func TestNewTagRules(t *testing.T) {
+	_, err := newTagRules([]config.MetricTagRule{{Action: "drop", Tags: []string{"pod_name"}}})
+	assert.Error(t, err)
+
+	_, err = newTagRules([]config.MetricTagRule{{Match: "foo", Action: "invalid"}})
+	assert.Error(t, err)
+
+	rules, err := newTagRules([]config.MetricTagRule{
+		{Match: "app.requests", Action: "keep", Tags: []string{"env", "service"}},
+		{Match: "app.*", Tags: []string{"pod_name", "container_id"}},
+		{Match: "*.latency.*", Action: "drop", Tags: []string{"host"}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, rules.match("app.requests").keep)
+	assert.False(t, rules.match("app.errors").keep)
+	assert.False(t, rules.match("app.db.latency").keep)
+	assert.NotNil(t, rules.match("db.latency.p99"))
+	assert.Nil(t, rules.match("db.latency"))
+	assert.Nil(t, rules.match("xapp.requests"))
+
+	keep := rules.match("app.requests")
+	assert.True(t, keep.keepTag("env:prod"))
+	assert.True(t, keep.keepTag("service"))
+	assert.False(t, keep.keepTag("environment:prod"))
+	assert.False(t, keep.keepTag("pod_name:web-1"))
+
+	drop := rules.match("app.errors")
+	assert.False(t, drop.keepTag("pod_name:web-1"))
+	assert.False(t, drop.keepTag("container_id"))
+	assert.True(t, drop.keepTag("env:prod"))
+}
+
+func TestNewTagRulesFromConfig(t *testing.T) {
+	assert.Nil(t, newTagRulesFromConfig())
+
+	config.Datadog.Set("dogstatsd_tag_rules", []config.MetricTagRule{{Match: "foo", Action: "invalid"}})
+	defer config.Datadog.Set("dogstatsd_tag_rules", nil)
+	assert.Nil(t, newTagRulesFromConfig())
+
+	config.Datadog.Set("dogstatsd_tag_rules", []map[string]interface{}{{"match": "foo.*", "tags": []string{"pod_name"}}})
+	rules := newTagRulesFromConfig()
+	require.NotNil(t, rules)
+	require.Len(t, rules.rules, 1)
+	assert.False(t, rules.match("foo.bar").keepTag("pod_name:web-1"))
+}
+
+func testContextResolverTagRules(t *testing.T, store *tags.Store) {
+	rules, err := newTagRules([]config.MetricTagRule{
+		{Match: "app.*", Action: "drop", Tags: []string{"pod_name", "container_id"}},
+	})
+	require.NoError(t, err)
+	resolver := newContextResolver(store, nil, rules)
+
+	key1, _ := resolver.trackContext(&metrics.MetricSample{Name: "app.requests", Tags: []string{"env:prod", "pod_name:web-1"}})
+	key2, _ := resolver.trackContext(&metrics.MetricSample{Name: "app.requests", Tags: []string{"pod_name:web-2", "env:prod", "container_id:abc"}})
+	key3, _ := resolver.trackContext(&metrics.MetricSample{Name: "other", Tags: []string{"env:prod", "pod_name:web-1"}})
+	key4, _ := resolver.trackContext(&metrics.MetricSample{Name: "other", Tags: []string{"env:prod", "pod_name:web-2"}})
+
+	assert.Equal(t, key1, key2)
+	assert.NotEqual(t, key3, key4)
+	assert.Equal(t, 3, resolver.length())
+
+	context, found := resolver.get(key1)
+	require.True(t, found)
+	metrics.AssertCompositeTagsEqual(t, context.Tags(), tagset.CompositeTagsFromSlice([]string{"env:prod"}))
+	context, found = resolver.get(key3)
+	require.True(t, found)
+	metrics.AssertCompositeTagsEqual(t, context.Tags(), tagset.CompositeTagsFromSlice([]string{"env:prod", "pod_name:web-1"}))
+
+	assert.Len(t, resolver.tagRuleByName, 2)
+	assert.Nil(t, resolver.tagRuleByName["other"])
+}
+func TestContextResolverTagRules(t *testing.T) {
+	testWithTagsStore(t, testContextResolverTagRules)
+}
+
+func TestTimeSamplerTagRules(t *testing.T) {
+	rules, err := newTagRules([]config.MetricTagRule{
+		{Match: "app.requests", Action: "keep", Tags: []string{"env"}},
+	})
+	require.NoError(t, err)
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), nil, rules)
+
+	sampler.sample(&metrics.MetricSample{Name: "app.requests", Value: 1, Mtype: metrics.CountType, Tags: []string{"env:prod", "pod_name:web-1"}, SampleRate: 1}, 12345.0)
+	sampler.sample(&metrics.MetricSample{Name: "app.requests", Value: 2, Mtype: metrics.CountType, Tags: []string{"env:prod", "pod_name:web-2"}, SampleRate: 1}, 12345.0)
+
+	series, _ := flushSerie(sampler, 12360.0)
+	require.Len(t, series, 1)
+	assert.Equal(t, float64(3), series[0].Points[0].Value)
+	metrics.AssertCompositeTagsEqual(t, series[0].Tags, tagset.CompositeTagsFromSlice([]string{"env:prod"}))
+}
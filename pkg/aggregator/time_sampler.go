@@ -31,14 +31,22 @@ type TimeSampler struct {
 	counterLastSampledByContext map[ckey.ContextKey]float64
 	lastCutOffTime              int64
 	sketchMap                   sketchMap
+	limiter                     *contextLimiter
+	// sketchAggregates is nil when no series are computed from the sketches
+	sketchAggregates *sketchAggregates
 
 	// id is a number to differentiate multiple time samplers
 	// since we start running more than one with the demultiplexer introduction
 	id TimeSamplerID
 }
 
-// NewTimeSampler returns a newly initialized TimeSampler
-func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store) *TimeSampler {
+// NewTimeSampler returns a newly initialized TimeSampler, the number of contexts it
+// tracks is limited by the limiter shared by the time samplers, if it is not nil.
+// The tags of the samples are filtered by the tag rules before their contexts are
+// resolved, if they are not nil. The series computed from the sketches of the
+// distributions are configured by dogstatsd_distribution_percentiles and
+// dogstatsd_distribution_buckets.
+func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, limiter *contextLimiter, rules *tagRules) *TimeSampler {
 	if interval == 0 {
 		interval = bucketSize
 	}
@@ -47,10 +55,12 @@ func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store) *TimeSa
 
 	s := &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newTimestampContextResolver(cache),
+		contextResolver:             newTimestampContextResolver(cache, limiter, rules),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
+		limiter:                     limiter,
+		sketchAggregates:            newSketchAggregatesFromConfig(),
 		id:                          id,
 	}
 
@@ -72,7 +82,11 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 	}
 
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, timestamp)
+	contextKey, ok := s.contextResolver.trackContext(metricSample, timestamp)
+	if !ok {
+		// the context is over the limits
+		return
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
@@ -189,7 +203,7 @@ func (s *TimeSampler) dedupSerieBySerieSignature(
 	}
 }
 
-func (s *TimeSampler) flushSketches(cutoffTime int64) metrics.SketchSeriesList {
+func (s *TimeSampler) flushSketches(cutoffTime int64, series metrics.SerieSink) metrics.SketchSeriesList {
 	pointsByCtx := make(map[ckey.ContextKey][]metrics.SketchPoint)
 	sketches := make(metrics.SketchSeriesList, 0, len(pointsByCtx))
 
@@ -201,6 +215,12 @@ func (s *TimeSampler) flushSketches(cutoffTime int64) metrics.SketchSeriesList {
 	})
 	for ck, points := range pointsByCtx {
 		sketches = append(sketches, s.newSketchSeries(ck, points))
+
+		if s.sketchAggregates != nil {
+			if ctx, ok := s.contextResolver.get(ck); ok {
+				s.sketchAggregates.flush(ck, ctx, s.interval, points, series)
+			}
+		}
 	}
 
 	return sketches
@@ -211,7 +231,7 @@ func (s *TimeSampler) flush(timestamp float64, series metrics.SerieSink) metrics
 	cutoffTime := s.calculateBucketStart(timestamp)
 
 	s.flushSeries(cutoffTime, series)
-	sketches := s.flushSketches(cutoffTime)
+	sketches := s.flushSketches(cutoffTime, series)
 
 	// expiring contexts
 	s.contextResolver.expireContexts(timestamp - config.Datadog.GetFloat64("dogstatsd_context_expiry_seconds"))
@@ -228,6 +248,10 @@ func (s *TimeSampler) flush(timestamp float64, series metrics.SerieSink) metrics
 		tlmDogstatsdContextsByMtype.Set(float64(count), mtype)
 	}
 
+	if s.limiter != nil {
+		s.limiter.updateTelemetry()
+	}
+
 	return sketches
 }
 
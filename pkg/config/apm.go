@@ -72,6 +72,11 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.errors_per_second", "DD_APM_ERROR_TPS")
 	config.BindEnv("apm_config.disable_rare_sampler", "DD_APM_DISABLE_RARE_SAMPLER")
 	config.BindEnv("apm_config.max_remote_traces_per_second", "DD_APM_MAX_REMOTE_TPS")
+	config.BindEnv("apm_config.tail_sampling.enabled", "DD_APM_TAIL_SAMPLING_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.decision_wait_seconds", "DD_APM_TAIL_SAMPLING_DECISION_WAIT_SECONDS")
+	config.BindEnv("apm_config.tail_sampling.max_traces", "DD_APM_TAIL_SAMPLING_MAX_TRACES")
+	config.BindEnv("apm_config.tail_sampling.max_spans", "DD_APM_TAIL_SAMPLING_MAX_SPANS")
+	config.BindEnv("apm_config.tail_sampling.policies", "DD_APM_TAIL_SAMPLING_POLICIES")
 
 	config.BindEnv("apm_config.max_memory", "DD_APM_MAX_MEMORY")
 	config.BindEnv("apm_config.max_cpu_percent", "DD_APM_MAX_CPU_PERCENT")
@@ -84,6 +89,8 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.profiling_additional_endpoints", "DD_APM_PROFILING_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.additional_endpoints", "DD_APM_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.replace_tags", "DD_APM_REPLACE_TAGS")
+	config.BindEnv("apm_config.span_rules", "DD_APM_SPAN_RULES")
+	config.BindEnv("apm_config.stats_dimensions", "DD_APM_STATS_DIMENSIONS")
 	config.BindEnv("apm_config.analyzed_spans", "DD_APM_ANALYZED_SPANS")
 	config.BindEnv("apm_config.ignore_resources", "DD_APM_IGNORE_RESOURCES", "DD_IGNORE_RESOURCE")
 	config.BindEnv("apm_config.receiver_socket", "DD_APM_RECEIVER_SOCKET")
@@ -125,6 +132,30 @@ func setupAPM(config Config) {
 		return out
 	})
 
+	config.SetEnvKeyTransformer("apm_config.span_rules", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.span_rules" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.SetEnvKeyTransformer("apm_config.stats_dimensions", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.stats_dimensions" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.SetEnvKeyTransformer("apm_config.tail_sampling.policies", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.tail_sampling.policies" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.SetEnvKeyTransformer("apm_config.analyzed_spans", func(in string) interface{} {
 		out, err := parseAnalyzedSpans(in)
 		if err != nil {
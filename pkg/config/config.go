@@ -174,10 +174,35 @@ type MappingProfile struct {
 
 // MetricMapping represent one mapping rule
 type MetricMapping struct {
-	Match     string            `mapstructure:"match" json:"match"`
-	MatchType string            `mapstructure:"match_type" json:"match_type"`
-	Name      string            `mapstructure:"name" json:"name"`
-	Tags      map[string]string `mapstructure:"tags" json:"tags"`
+	Match      string                   `mapstructure:"match" json:"match"`
+	MatchType  string                   `mapstructure:"match_type" json:"match_type"`
+	Action     string                   `mapstructure:"action" json:"action"`
+	Name       string                   `mapstructure:"name" json:"name"`
+	Tags       map[string]string        `mapstructure:"tags" json:"tags"`
+	Transforms []MetricMappingTransform `mapstructure:"transforms" json:"transforms"`
+	DropTags   []string                 `mapstructure:"drop_tags" json:"drop_tags"`
+	RenameTags map[string]string        `mapstructure:"rename_tags" json:"rename_tags"`
+}
+
+// MetricMappingTransform represent a transformation of the values captured by a mapping rule
+type MetricMappingTransform struct {
+	Type        string `mapstructure:"type" json:"type"`
+	Pattern     string `mapstructure:"pattern" json:"pattern"`
+	Replacement string `mapstructure:"replacement" json:"replacement"`
+}
+
+// MetricTagRule represent a rule keeping or dropping tags of the metrics matching a pattern
+type MetricTagRule struct {
+	Match  string   `mapstructure:"match" json:"match"`
+	Action string   `mapstructure:"action" json:"action"`
+	Tags   []string `mapstructure:"tags" json:"tags"`
+}
+
+// ForwarderRoutingRule represent a rule restricting the payloads sent to a domain
+type ForwarderRoutingRule struct {
+	Domain         string   `mapstructure:"domain" json:"domain"`
+	PayloadTypes   []string `mapstructure:"payload_types" json:"payload_types"`
+	MetricPrefixes []string `mapstructure:"metric_prefixes" json:"metric_prefixes"`
 }
 
 // Endpoint represent a datadog endpoint
@@ -449,6 +474,13 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_payloads.sketches", true)
 	config.BindEnvAndSetDefault("enable_payloads.json_to_v1_intake", true)
 
+	// Serializer: Prometheus remote write output of the series and sketches
+	config.BindEnvAndSetDefault("remote_write.enabled", false)
+	config.BindEnvAndSetDefault("remote_write.endpoints", []string{})
+	config.BindEnvAndSetDefault("remote_write.headers", map[string]string{})
+	config.BindEnvAndSetDefault("remote_write.max_samples_per_send", 2000)
+	config.BindEnvAndSetDefault("remote_write.sketch_quantiles", []string{"0.5", "0.9", "0.95", "0.99"})
+
 	// Forwarder
 	config.BindEnvAndSetDefault("additional_endpoints", map[string][]string{})
 	config.BindEnvAndSetDefault("forwarder_timeout", 20)
@@ -458,6 +490,14 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_apikey_validation_interval", DefaultAPIKeyValidationInterval) // in minutes
 	config.BindEnvAndSetDefault("forwarder_num_workers", 1)
 	config.BindEnvAndSetDefault("forwarder_stop_timeout", 2)
+	config.BindEnv("forwarder_routing_rules")
+	config.SetEnvKeyTransformer("forwarder_routing_rules", func(in string) interface{} {
+		var rules []ForwarderRoutingRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"forwarder_routing_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
 	// Forwarder retry settings
 	config.BindEnvAndSetDefault("forwarder_backoff_factor", 2)
 	config.BindEnvAndSetDefault("forwarder_backoff_base", 2)
@@ -472,6 +512,9 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0)                // 0 means disabled. This is a BETA feature.
 	config.BindEnvAndSetDefault("forwarder_storage_max_disk_ratio", 0.80)                // Do not store transactions on disk when the disk usage exceeds 80% of the disk capacity. Use 80% as some applications do not behave well when the disk space is very small.
 	config.BindEnvAndSetDefault("forwarder_retry_queue_capacity_time_interval_sec", 900) // 15 mins
+	config.BindEnvAndSetDefault("forwarder_storage_encryption_key", "")
+	config.BindEnvAndSetDefault("forwarder_storage_encryption_key_file", "")
+	config.BindEnvAndSetDefault("forwarder_storage_compression", false)
 
 	// Forwarder channels buffer size
 	config.BindEnvAndSetDefault("forwarder_high_prio_buffer_size", 100)
@@ -482,6 +525,9 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("use_dogstatsd", true)
 	config.BindEnvAndSetDefault("dogstatsd_port", 8125)    // Notice: 0 means UDP port closed
 	config.BindEnvAndSetDefault("dogstatsd_pipe_name", "") // experimental and not officially supported for now.
+	config.BindEnvAndSetDefault("dogstatsd_tcp_port", 0)   // Notice: 0 means TCP port closed
+	// Options are: newline, length_prefixed
+	config.BindEnvAndSetDefault("dogstatsd_tcp_framing", "newline")
 	// Experimental and not officially supported for now.
 	// Options are: udp, uds, named_pipe
 	config.BindEnvAndSetDefault("dogstatsd_eol_required", []string{})
@@ -497,7 +543,8 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
-	config.BindEnvAndSetDefault("dogstatsd_socket", "") // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_socket", "")        // Notice: empty means feature disabled
+	config.BindEnvAndSetDefault("dogstatsd_stream_socket", "") // Notice: empty means feature disabled
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_autoadjust", false)
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_count", 1)
 	config.BindEnvAndSetDefault("dogstatsd_stats_port", 5000)
@@ -510,12 +557,22 @@ func InitConfig(config Config) {
 	// is 10s), otherwise we won't be able to sample unseen counter as
 	// contexts will be deleted (see 'dogstatsd_expiry_seconds').
 	config.BindEnvAndSetDefault("dogstatsd_context_expiry_seconds", 300)
+	// Maximum number of dogstatsd contexts per metric name and per origin, 0 means unlimited.
+	config.BindEnvAndSetDefault("dogstatsd_context_limit_per_metric", 0)
+	config.BindEnvAndSetDefault("dogstatsd_context_limit_per_origin", 0)
+	// What to do with the contexts over the limits. Options are: drop, collapse
+	config.BindEnvAndSetDefault("dogstatsd_context_limit_overflow", "drop")
+	// Percentiles and upper bounds of the `le` buckets computed from the distributions and sent as series
+	config.BindEnvAndSetDefault("dogstatsd_distribution_percentiles", []string{})
+	config.BindEnvAndSetDefault("dogstatsd_distribution_buckets", []string{})
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection_client", false)
 	config.BindEnvAndSetDefault("dogstatsd_so_rcvbuf", 0)
 	config.BindEnvAndSetDefault("dogstatsd_metrics_stats_enable", false)
 	config.BindEnvAndSetDefault("dogstatsd_tags", []string{})
 	config.BindEnvAndSetDefault("dogstatsd_mapper_cache_size", 1000)
+	// Options are: first, best
+	config.BindEnvAndSetDefault("dogstatsd_mapper_match_order", "first")
 	config.BindEnvAndSetDefault("dogstatsd_string_interner_size", 4096)
 	// Enable check for Entity-ID presence when enriching Dogstatsd metrics with tags
 	config.BindEnvAndSetDefault("dogstatsd_entity_id_precedence", false)
@@ -536,6 +593,15 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	config.BindEnv("dogstatsd_tag_rules")
+	config.SetEnvKeyTransformer("dogstatsd_tag_rules", func(in string) interface{} {
+		var rules []MetricTagRule
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"dogstatsd_tag_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -779,6 +845,10 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.open_files_limit", 100)
 	// add global processing rules that are applied on all logs
 	config.BindEnv("logs_config.processing_rules")
+	// collapse the identical log lines of a source received within this window (seconds), 0 disables it
+	config.BindEnvAndSetDefault("logs_config.dedup_window", 0)
+	// compare log lines once the mask_sequences processing rules have been applied when collapsing them
+	config.BindEnvAndSetDefault("logs_config.dedup_after_masking", true)
 	// enforce the agent to use files to collect container logs on kubernetes environment
 	config.BindEnvAndSetDefault("logs_config.k8s_container_use_file", false)
 	// Enable the agent to use files to collect container logs on standalone docker environment, containers
@@ -820,6 +890,12 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.dd_url_443", "agent-443-intake.logs.datadoghq.com")
 	config.BindEnvAndSetDefault("logs_config.stop_grace_period", 30)
 	config.BindEnvAndSetDefault("logs_config.close_timeout", 60)
+	// Number of bytes at the beginning of the files used to identify them, 0 to identify them by path only.
+	config.BindEnvAndSetDefault("logs_config.file_fingerprint_size", 0)
+	// Store the logs payloads on disk until they are sent, 0 means disabled.
+	config.BindEnvAndSetDefault("logs_config.storage_path", "")
+	config.BindEnvAndSetDefault("logs_config.storage_max_size_in_bytes", 0)
+	config.BindEnvAndSetDefault("logs_config.storage_max_disk_ratio", 0.80)
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_detection", false)
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_extra_patterns", []string{})
 	// The following auto_multi_line settings are experimental and may change
@@ -1363,7 +1439,9 @@ func bindEnvAndSetLogsConfigKeys(config Config, prefix string) {
 	config.BindEnv(prefix + "logs_dd_url") // Send the logs to a proxy. Must respect format '<HOST>:<PORT>' and '<PORT>' to be an integer
 	config.BindEnv(prefix + "dd_url")
 	config.BindEnv(prefix + "additional_endpoints")
+	config.BindEnv(prefix + "webhook_endpoints")
 	config.BindEnvAndSetDefault(prefix+"use_compression", true)
+	config.BindEnvAndSetDefault(prefix+"compression_kind", "gzip")
 	config.BindEnvAndSetDefault(prefix+"compression_level", 6) // Default level for the gzip/deflate algorithm
 	config.BindEnvAndSetDefault(prefix+"batch_wait", DefaultBatchWait)
 	config.BindEnvAndSetDefault(prefix+"connection_reset_interval", 0) // in seconds, 0 means disabled
@@ -1612,6 +1690,30 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetDogstatsdTagRules returns the rules keeping or dropping the tags of the DogStatsD metrics
+func GetDogstatsdTagRules() ([]MetricTagRule, error) {
+	var rules []MetricTagRule
+	if Datadog.IsSet("dogstatsd_tag_rules") {
+		err := Datadog.UnmarshalKey("dogstatsd_tag_rules", &rules)
+		if err != nil {
+			return []MetricTagRule{}, log.Errorf("Could not parse dogstatsd_tag_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
+// GetForwarderRoutingRules returns the routing rules of the forwarder
+func GetForwarderRoutingRules() ([]ForwarderRoutingRule, error) {
+	var rules []ForwarderRoutingRule
+	if Datadog.IsSet("forwarder_routing_rules") {
+		err := Datadog.UnmarshalKey("forwarder_routing_rules", &rules)
+		if err != nil {
+			return []ForwarderRoutingRule{}, log.Errorf("Could not parse forwarder_routing_rules: %v", err)
+		}
+	}
+	return rules, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
@@ -0,0 +1,257 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+)
+
+// CaptureAnalysis summarizes the traffic recorded in a capture file.
+type CaptureAnalysis struct {
+	Packets           int                   `json:"packets"`
+	Bytes             int                   `json:"bytes"`
+	MetricSamples     int                   `json:"metric_samples"`
+	Events            int                   `json:"events"`
+	ServiceChecks     int                   `json:"service_checks"`
+	MalformedPackets  int                   `json:"malformed_packets"`
+	MalformedMessages int                   `json:"malformed_messages"`
+	TopMetrics        []CaptureMetricStats  `json:"top_metrics"`
+	TopTagKeys        []CaptureTagKeyStats  `json:"top_tag_keys"`
+	Origins           []CaptureOriginVolume `json:"origins"`
+}
+
+// CaptureMetricStats holds the number of samples and of distinct tag sets of a metric.
+type CaptureMetricStats struct {
+	Name     string `json:"name"`
+	Samples  int    `json:"samples"`
+	Contexts int    `json:"contexts"`
+}
+
+// CaptureTagKeyStats holds the number of distinct values of a tag key.
+type CaptureTagKeyStats struct {
+	Key    string `json:"key"`
+	Values int    `json:"values"`
+}
+
+// CaptureOriginVolume holds the volume of traffic sent by a process.
+type CaptureOriginVolume struct {
+	Pid               int32  `json:"pid"`
+	ContainerID       string `json:"container_id"`
+	Packets           int    `json:"packets"`
+	Bytes             int    `json:"bytes"`
+	MalformedMessages int    `json:"malformed_messages"`
+}
+
+type captureMetric struct {
+	samples  int
+	contexts map[string]struct{}
+}
+
+// AnalyzeCapture reads all the packets of a capture and reports its top metrics,
+// the tag keys with the highest cardinality, the volume sent by each origin and
+// the malformed messages. The top lists are truncated to top entries when top
+// is positive. The packets are read as fast as possible, without their cadence.
+func AnalyzeCapture(reader *replay.TrafficCaptureReader, top int) (*CaptureAnalysis, error) {
+	// the pid map is missing from the oldest capture files, the containers are then unknown
+	pidMap, _, err := reader.ReadState()
+	if err != nil {
+		pidMap = nil
+	}
+
+	p := newParser(newFloat64ListPool())
+	metrics := make(map[string]*captureMetric)
+	tagValues := make(map[string]map[string]struct{})
+	origins := make(map[int32]*CaptureOriginVolume)
+	analysis := &CaptureAnalysis{}
+
+	reader.Seek(0)
+	for {
+		msg, err := reader.ReadNext()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		payload := msg.Payload
+		if int(msg.PayloadSize) <= len(payload) {
+			payload = payload[:msg.PayloadSize]
+		}
+		analysis.Packets++
+		analysis.Bytes += len(payload)
+
+		origin, found := origins[msg.Pid]
+		if !found {
+			origin = &CaptureOriginVolume{Pid: msg.Pid, ContainerID: pidMap[msg.Pid]}
+			origins[msg.Pid] = origin
+		}
+		origin.Packets++
+		origin.Bytes += len(payload)
+
+		malformed := false
+		for {
+			message := nextMessage(&payload, false)
+			if message == nil {
+				break
+			}
+			if len(message) == 0 {
+				continue
+			}
+
+			switch findMessageType(message) {
+			case serviceCheckType:
+				_, err = p.parseServiceCheck(message)
+				if err == nil {
+					analysis.ServiceChecks++
+				}
+			case eventType:
+				_, err = p.parseEvent(message)
+				if err == nil {
+					analysis.Events++
+				}
+			case metricSampleType:
+				var sample dogstatsdMetricSample
+				sample, err = p.parseMetricSample(message)
+				if err == nil {
+					analysis.MetricSamples++
+					countMetricSample(sample, metrics, tagValues)
+					if sample.values != nil {
+						p.float64List.put(sample.values)
+					}
+				}
+			}
+			if err != nil {
+				malformed = true
+				analysis.MalformedMessages++
+				origin.MalformedMessages++
+			}
+		}
+		if malformed {
+			analysis.MalformedPackets++
+		}
+	}
+
+	for name, metric := range metrics {
+		analysis.TopMetrics = append(analysis.TopMetrics, CaptureMetricStats{Name: name, Samples: metric.samples, Contexts: len(metric.contexts)})
+	}
+	sort.Slice(analysis.TopMetrics, func(i, j int) bool {
+		if analysis.TopMetrics[i].Samples != analysis.TopMetrics[j].Samples {
+			return analysis.TopMetrics[i].Samples > analysis.TopMetrics[j].Samples
+		}
+		return analysis.TopMetrics[i].Name < analysis.TopMetrics[j].Name
+	})
+
+	for key, values := range tagValues {
+		analysis.TopTagKeys = append(analysis.TopTagKeys, CaptureTagKeyStats{Key: key, Values: len(values)})
+	}
+	sort.Slice(analysis.TopTagKeys, func(i, j int) bool {
+		if analysis.TopTagKeys[i].Values != analysis.TopTagKeys[j].Values {
+			return analysis.TopTagKeys[i].Values > analysis.TopTagKeys[j].Values
+		}
+		return analysis.TopTagKeys[i].Key < analysis.TopTagKeys[j].Key
+	})
+
+	for _, origin := range origins {
+		analysis.Origins = append(analysis.Origins, *origin)
+	}
+	sort.Slice(analysis.Origins, func(i, j int) bool {
+		if analysis.Origins[i].Bytes != analysis.Origins[j].Bytes {
+			return analysis.Origins[i].Bytes > analysis.Origins[j].Bytes
+		}
+		return analysis.Origins[i].Pid < analysis.Origins[j].Pid
+	})
+
+	if top > 0 {
+		if len(analysis.TopMetrics) > top {
+			analysis.TopMetrics = analysis.TopMetrics[:top]
+		}
+		if len(analysis.TopTagKeys) > top {
+			analysis.TopTagKeys = analysis.TopTagKeys[:top]
+		}
+		if len(analysis.Origins) > top {
+			analysis.Origins = analysis.Origins[:top]
+		}
+	}
+
+	return analysis, nil
+}
+
+// countMetricSample records the context of a sample and the values of its tags.
+func countMetricSample(sample dogstatsdMetricSample, metrics map[string]*captureMetric, tagValues map[string]map[string]struct{}) {
+	metric, found := metrics[sample.name]
+	if !found {
+		metric = &captureMetric{contexts: make(map[string]struct{})}
+		metrics[sample.name] = metric
+	}
+	metric.samples++
+
+	tags := make([]string, len(sample.tags))
+	copy(tags, sample.tags)
+	sort.Strings(tags)
+	metric.contexts[strings.Join(tags, ",")] = struct{}{}
+
+	for _, tag := range sample.tags {
+		key, value := tag, ""
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			key, value = tag[:i], tag[i+1:]
+		}
+		values, found := tagValues[key]
+		if !found {
+			values = make(map[string]struct{})
+			tagValues[key] = values
+		}
+		values[value] = struct{}{}
+	}
+}
+
+// Format renders the analysis of a capture for the command line.
+func (a *CaptureAnalysis) Format() string {
+	buf := bytes.NewBuffer(nil)
+
+	fmt.Fprintf(buf, "Packets: %d (%d bytes)\n", a.Packets, a.Bytes)
+	fmt.Fprintf(buf, "Metric samples: %d, events: %d, service checks: %d\n", a.MetricSamples, a.Events, a.ServiceChecks)
+	fmt.Fprintf(buf, "Malformed messages: %d in %d packets\n\n", a.MalformedMessages, a.MalformedPackets)
+
+	header := fmt.Sprintf("%-60s | %-10s | %-10s\n", "Metric", "Samples", "Contexts")
+	buf.WriteString(header)
+	buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+	for _, metric := range a.TopMetrics {
+		fmt.Fprintf(buf, "%-60s | %-10d | %-10d\n", metric.Name, metric.Samples, metric.Contexts)
+	}
+	if len(a.TopMetrics) == 0 {
+		buf.WriteString("No metrics in the capture.\n")
+	}
+	buf.WriteString("\n")
+
+	header = fmt.Sprintf("%-60s | %-10s\n", "Tag key", "Values")
+	buf.WriteString(header)
+	buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+	for _, tagKey := range a.TopTagKeys {
+		fmt.Fprintf(buf, "%-60s | %-10d\n", tagKey.Key, tagKey.Values)
+	}
+	if len(a.TopTagKeys) == 0 {
+		buf.WriteString("No tags in the capture.\n")
+	}
+	buf.WriteString("\n")
+
+	header = fmt.Sprintf("%-10s | %-80s | %-10s | %-12s | %-10s\n", "PID", "Container", "Packets", "Bytes", "Malformed")
+	buf.WriteString(header)
+	buf.WriteString(strings.Repeat("-", len(header)) + "\n")
+	for _, origin := range a.Origins {
+		fmt.Fprintf(buf, "%-10d | %-80s | %-10d | %-12d | %-10d\n", origin.Pid, origin.ContainerID, origin.Packets, origin.Bytes, origin.MalformedMessages)
+	}
+	if len(a.Origins) == 0 {
+		buf.WriteString("No packets in the capture.\n")
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dogstatsd
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo"
+)
+
+// newCaptureReader returns a reader of a capture made of the given packets,
+// without tagger state. The header is taken from an existing capture file.
+func newCaptureReader(t *testing.T, msgs ...*pb.UnixDogstatsdMsg) *replay.TrafficCaptureReader {
+	file, err := replay.NewTrafficCaptureReader("replay/resources/test/datadog-capture.dog", 1, false)
+	require.NoError(t, err)
+
+	contents := append([]byte{}, file.Contents[:8]...)
+	for _, msg := range msgs {
+		msg.PayloadSize = int32(len(msg.Payload))
+		raw, err := proto.Marshal(msg)
+		require.NoError(t, err)
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(raw)))
+		contents = append(contents, size...)
+		contents = append(contents, raw...)
+	}
+	// state separator followed by an empty state
+	contents = append(contents, 0, 0, 0, 0, 0, 0, 0, 0)
+
+	return &replay.TrafficCaptureReader{Contents: contents, Version: file.Version}
+}
+
+func TestAnalyzeCapture(t *testing.T) {
+	reader := newCaptureReader(t,
+		&pb.UnixDogstatsdMsg{Pid: 12, Payload: []byte("app.requests:1|c|#env:prod,host:a\napp.requests:1|c|#host:b,env:prod\n")},
+		&pb.UnixDogstatsdMsg{Pid: 12, Payload: []byte("app.requests:1|c|#env:prod,host:a\napp.latency:1:2|d|#host:c")},
+		&pb.UnixDogstatsdMsg{Pid: 34, Payload: []byte("_e{5,4}:title|text\n_sc|app.check|0\ngibberish\napp.latency:abc|d")},
+	)
+
+	analysis, err := AnalyzeCapture(reader, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, analysis.Packets)
+	assert.Equal(t, 4, analysis.MetricSamples)
+	assert.Equal(t, 1, analysis.Events)
+	assert.Equal(t, 1, analysis.ServiceChecks)
+	assert.Equal(t, 2, analysis.MalformedMessages)
+	assert.Equal(t, 1, analysis.MalformedPackets)
+
+	assert.Equal(t, []CaptureMetricStats{
+		{Name: "app.requests", Samples: 3, Contexts: 2},
+		{Name: "app.latency", Samples: 1, Contexts: 1},
+	}, analysis.TopMetrics)
+	assert.Equal(t, []CaptureTagKeyStats{
+		{Key: "host", Values: 3},
+		{Key: "env", Values: 1},
+	}, analysis.TopTagKeys)
+
+	require.Len(t, analysis.Origins, 2)
+	assert.Equal(t, int32(12), analysis.Origins[0].Pid)
+	assert.Equal(t, 2, analysis.Origins[0].Packets)
+	assert.Equal(t, 0, analysis.Origins[0].MalformedMessages)
+	assert.Equal(t, int32(34), analysis.Origins[1].Pid)
+	assert.Equal(t, 2, analysis.Origins[1].MalformedMessages)
+
+	analysis, err = AnalyzeCapture(newCaptureReader(t), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, analysis.Packets)
+	assert.Contains(t, analysis.Format(), "No metrics in the capture.")
+}
+
+func TestAnalyzeCaptureTop(t *testing.T) {
+	reader := newCaptureReader(t,
+		&pb.UnixDogstatsdMsg{Pid: 1, Payload: []byte("a:1|c|#x:1\nb:1|c|#y:1\nb:1|c|#z:1\n")},
+		&pb.UnixDogstatsdMsg{Pid: 2, Payload: []byte("b:1|c")},
+	)
+
+	analysis, err := AnalyzeCapture(reader, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []CaptureMetricStats{{Name: "b", Samples: 3, Contexts: 3}}, analysis.TopMetrics)
+	assert.Len(t, analysis.TopTagKeys, 1)
+	assert.Len(t, analysis.Origins, 1)
+	assert.Equal(t, int32(1), analysis.Origins[0].Pid)
+}
+
+func TestAnalyzeCaptureFile(t *testing.T) {
+	reader, err := replay.NewTrafficCaptureReader("replay/resources/test/datadog-capture.dog.zstd", 1, false)
+	require.NoError(t, err)
+
+	analysis, err := AnalyzeCapture(reader, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 21, analysis.Packets)
+	assert.Equal(t, 21, analysis.MetricSamples)
+	assert.Equal(t, 0, analysis.MalformedMessages)
+	assert.Equal(t, []CaptureMetricStats{{Name: "jaime.uds.test", Samples: 21, Contexts: 1}}, analysis.TopMetrics)
+	assert.Len(t, analysis.Origins, 21)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -21,12 +22,26 @@ var (
 const (
 	matchTypeWildcard = "wildcard"
 	matchTypeRegex    = "regex"
+
+	actionMap  = "map"
+	actionDrop = "drop"
+
+	transformLowercase = "lowercase"
+	transformUppercase = "uppercase"
+	transformReplace   = "replace"
+
+	// MatchOrderFirst only tries the first profile whose prefix matches the metric name
+	MatchOrderFirst = "first"
+	// MatchOrderBest tries the profiles from the most specific prefix to the least
+	// specific one, until one of their mappings matches the metric name
+	MatchOrderBest = "best"
 )
 
 // MetricMapper contains mappings and cache instance
 type MetricMapper struct {
-	Profiles []MappingProfile
-	cache    *mapperCache
+	Profiles   []MappingProfile
+	matchOrder string
+	cache      *mapperCache
 }
 
 // MappingProfile represent a group of mappings
@@ -38,20 +53,31 @@ type MappingProfile struct {
 
 // MetricMapping represent one mapping rule
 type MetricMapping struct {
-	name  string
-	tags  map[string]string
-	regex *regexp.Regexp
+	name       string
+	tags       map[string]string
+	regex      *regexp.Regexp
+	drop       bool
+	transforms []valueTransform
+	dropTags   map[string]struct{}
+	renameTags map[string]string
 }
 
+// valueTransform transforms a value captured by a mapping rule
+type valueTransform func(string) string
+
 // MapResult represent the outcome of the mapping
 type MapResult struct {
-	Name    string
-	Tags    []string
-	matched bool
+	Name string
+	Tags []string
+	// Drop is true when the metric must be dropped
+	Drop       bool
+	matched    bool
+	dropTags   map[string]struct{}
+	renameTags map[string]string
 }
 
 // NewMetricMapper creates, validates, prepares a new MetricMapper
-func NewMetricMapper(configProfiles []config.MappingProfile, cacheSize int) (*MetricMapper, error) {
+func NewMetricMapper(configProfiles []config.MappingProfile, matchOrder string, cacheSize int) (*MetricMapper, error) {
 	var profiles []MappingProfile
 	for profileIndex, configProfile := range configProfiles {
 		if configProfile.Name == "" {
@@ -62,32 +88,108 @@ func NewMetricMapper(configProfiles []config.MappingProfile, cacheSize int) (*Me
 		}
 		profile := MappingProfile{Name: configProfile.Name, Prefix: configProfile.Prefix}
 		for i, currentMapping := range configProfile.Mappings {
-			matchType := currentMapping.MatchType
-			if matchType == "" {
-				matchType = matchTypeWildcard
-			}
-			if matchType != matchTypeWildcard && matchType != matchTypeRegex {
-				return nil, fmt.Errorf("profile: %s, mapping num %d: invalid match type, must be `wildcard` or `regex`", profile.Name, i)
-			}
-			if currentMapping.Name == "" {
-				return nil, fmt.Errorf("profile: %s, mapping num %d: name is required", profile.Name, i)
-			}
-			if currentMapping.Match == "" {
-				return nil, fmt.Errorf("profile: %s, mapping num %d: match is required", profile.Name, i)
-			}
-			regex, err := buildRegex(currentMapping.Match, matchType)
+			mapping, err := buildMapping(currentMapping)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("profile: %s, mapping num %d: %v", profile.Name, i, err)
 			}
-			profile.Mappings = append(profile.Mappings, &MetricMapping{name: currentMapping.Name, tags: currentMapping.Tags, regex: regex})
+			profile.Mappings = append(profile.Mappings, mapping)
 		}
 		profiles = append(profiles, profile)
 	}
+	switch matchOrder {
+	case "", MatchOrderFirst:
+		matchOrder = MatchOrderFirst
+	case MatchOrderBest:
+		// the profiles with the most specific prefixes are tried first
+		sort.SliceStable(profiles, func(i, j int) bool {
+			return prefixSpecificity(profiles[i].Prefix) > prefixSpecificity(profiles[j].Prefix)
+		})
+	default:
+		return nil, fmt.Errorf("invalid match order `%s`, must be `%s` or `%s`", matchOrder, MatchOrderFirst, MatchOrderBest)
+	}
 	cache, err := newMapperCache(cacheSize)
 	if err != nil {
 		return nil, err
 	}
-	return &MetricMapper{Profiles: profiles, cache: cache}, nil
+	return &MetricMapper{Profiles: profiles, matchOrder: matchOrder, cache: cache}, nil
+}
+
+func buildMapping(configMapping config.MetricMapping) (*MetricMapping, error) {
+	matchType := configMapping.MatchType
+	if matchType == "" {
+		matchType = matchTypeWildcard
+	}
+	if matchType != matchTypeWildcard && matchType != matchTypeRegex {
+		return nil, fmt.Errorf("invalid match type, must be `wildcard` or `regex`")
+	}
+	action := configMapping.Action
+	if action == "" {
+		action = actionMap
+	}
+	if action != actionMap && action != actionDrop {
+		return nil, fmt.Errorf("invalid action, must be `map` or `drop`")
+	}
+	if action == actionMap && configMapping.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if configMapping.Match == "" {
+		return nil, fmt.Errorf("match is required")
+	}
+	regex, err := buildRegex(configMapping.Match, matchType)
+	if err != nil {
+		return nil, err
+	}
+	mapping := &MetricMapping{
+		name:       configMapping.Name,
+		tags:       configMapping.Tags,
+		regex:      regex,
+		drop:       action == actionDrop,
+		renameTags: configMapping.RenameTags,
+	}
+	for _, configTransform := range configMapping.Transforms {
+		transform, err := buildTransform(configTransform)
+		if err != nil {
+			return nil, err
+		}
+		mapping.transforms = append(mapping.transforms, transform)
+	}
+	if len(configMapping.DropTags) > 0 {
+		mapping.dropTags = make(map[string]struct{}, len(configMapping.DropTags))
+		for _, tagKey := range configMapping.DropTags {
+			mapping.dropTags[tagKey] = struct{}{}
+		}
+	}
+	return mapping, nil
+}
+
+func buildTransform(configTransform config.MetricMappingTransform) (valueTransform, error) {
+	switch configTransform.Type {
+	case transformLowercase:
+		return strings.ToLower, nil
+	case transformUppercase:
+		return strings.ToUpper, nil
+	case transformReplace:
+		if configTransform.Pattern == "" {
+			return nil, fmt.Errorf("pattern is required by the `replace` transform")
+		}
+		pattern, err := regexp.Compile(configTransform.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transform pattern `%s`: %v", configTransform.Pattern, err)
+		}
+		return func(value string) string {
+			return pattern.ReplaceAllString(value, configTransform.Replacement)
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid transform type `%s`, must be `lowercase`, `uppercase` or `replace`", configTransform.Type)
+}
+
+// prefixSpecificity ranks the prefixes of the profiles, `*` matches everything
+// and is the least specific one.
+func prefixSpecificity(prefix string) int {
+	if prefix == "*" {
+		return 0
+	}
+	return len(prefix) + 1
 }
 
 func buildRegex(matchRe string, matchType string) (*regexp.Regexp, error) {
@@ -110,43 +212,113 @@ func buildRegex(matchRe string, matchType string) (*regexp.Regexp, error) {
 
 // Map returns a MapResult
 func (m *MetricMapper) Map(metricName string) *MapResult {
+	prefixMatched := false
 	for _, profile := range m.Profiles {
 		if !strings.HasPrefix(metricName, profile.Prefix) && profile.Prefix != "*" {
 			continue
 		}
-		result, cached := m.cache.get(metricName)
-		if cached {
-			if result.matched {
-				return result
+		if !prefixMatched {
+			prefixMatched = true
+			result, cached := m.cache.get(metricName)
+			if cached {
+				if result.matched {
+					return result
+				}
+				return nil
 			}
-			return nil
 		}
-		for _, mapping := range profile.Mappings {
-			matches := mapping.regex.FindStringSubmatchIndex(metricName)
-			if len(matches) == 0 {
-				continue
-			}
-
-			name := string(mapping.regex.ExpandString(
-				[]byte{},
-				mapping.name,
-				metricName,
-				matches,
-			))
-
-			var tags []string
-			for tagKey, tagValueExpr := range mapping.tags {
-				tagValue := string(mapping.regex.ExpandString([]byte{}, tagValueExpr, metricName, matches))
-				tags = append(tags, tagKey+":"+tagValue)
-			}
-
-			mapResult := &MapResult{Name: name, matched: true, Tags: tags}
+		if mapResult := profile.mapMetric(metricName); mapResult != nil {
 			m.cache.add(metricName, mapResult)
 			return mapResult
 		}
-		mapResult := &MapResult{matched: false}
-		m.cache.add(metricName, mapResult)
-		return nil
+		if m.matchOrder == MatchOrderFirst {
+			break
+		}
+	}
+	if prefixMatched {
+		m.cache.add(metricName, &MapResult{matched: false})
 	}
 	return nil
 }
+
+// mapMetric returns the result of the first mapping of the profile matching the metric name,
+// or nil if none of them matches
+func (p *MappingProfile) mapMetric(metricName string) *MapResult {
+	for _, mapping := range p.Mappings {
+		matches := mapping.regex.FindStringSubmatchIndex(metricName)
+		if len(matches) == 0 {
+			continue
+		}
+		if mapping.drop {
+			return &MapResult{Drop: true, matched: true}
+		}
+
+		// the transforms are applied to the captured values, the matches
+		// then index the transformed template rather than the metric name
+		template := metricName
+		if len(mapping.transforms) > 0 {
+			template, matches = mapping.transformCaptures(metricName, matches)
+		}
+
+		name := string(mapping.regex.ExpandString(
+			[]byte{},
+			mapping.name,
+			template,
+			matches,
+		))
+
+		var tags []string
+		for tagKey, tagValueExpr := range mapping.tags {
+			tagValue := string(mapping.regex.ExpandString([]byte{}, tagValueExpr, template, matches))
+			tags = append(tags, tagKey+":"+tagValue)
+		}
+
+		return &MapResult{Name: name, matched: true, Tags: tags, dropTags: mapping.dropTags, renameTags: mapping.renameTags}
+	}
+	return nil
+}
+
+// transformCaptures applies the transforms to the values captured in the metric name, it
+// returns a string made of the transformed values and the indexes of these values in it
+func (m *MetricMapping) transformCaptures(metricName string, matches []int) (string, []int) {
+	var template strings.Builder
+	transformed := make([]int, len(matches))
+	for i := 2; i+1 < len(matches); i += 2 {
+		if matches[i] < 0 {
+			transformed[i], transformed[i+1] = -1, -1
+			continue
+		}
+		value := metricName[matches[i]:matches[i+1]]
+		for _, transform := range m.transforms {
+			value = transform(value)
+		}
+		transformed[i] = template.Len()
+		template.WriteString(value)
+		transformed[i+1] = template.Len()
+	}
+	transformed[1] = template.Len()
+	return template.String(), transformed
+}
+
+// RewriteTags drops and renames the tags of a mapped metric as configured by its
+// mapping rule, the tags are rewritten in place.
+func (r *MapResult) RewriteTags(tags []string) []string {
+	if len(r.dropTags) == 0 && len(r.renameTags) == 0 {
+		return tags
+	}
+	rewritten := tags[:0]
+	for _, tag := range tags {
+		key, value := tag, ""
+		if sep := strings.IndexByte(tag, ':'); sep >= 0 {
+			key, value = tag[:sep], tag[sep:]
+		}
+		if _, drop := r.dropTags[key]; drop {
+			continue
+		}
+		if newKey, rename := r.renameTags[key]; rename {
+			tag = newKey + value
+		}
+		rewritten = append(rewritten, tag)
+	}
+	return rewritten
+}
@@ -469,6 +469,48 @@ dogstatsd_mapper_profiles:
 			},
 			expectedError: "invalid match type",
 		},
+		{
+			name: "Invalid action",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.duration"
+        action: rename
+        name: "test.job.duration"
+`,
+			expectedError: "invalid action",
+		},
+		{
+			name: "Invalid transform type",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.*"
+        name: "test.job"
+        transforms:
+          - type: capitalize
+`,
+			expectedError: "invalid transform type",
+		},
+		{
+			name: "Replace transform without pattern",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.*"
+        name: "test.job"
+        transforms:
+          - type: replace
+            replacement: "_"
+`,
+			expectedError: "pattern is required",
+		},
 		{
 			name: "Missing profile name",
 			config: `
@@ -521,9 +563,151 @@ func getMapper(configString string) (*MetricMapper, error) {
 	if err != nil {
 		return nil, err
 	}
-	mapper, err := NewMetricMapper(profiles, 1000)
+	mapper, err := NewMetricMapper(profiles, config.Datadog.GetString("dogstatsd_mapper_match_order"), 1000)
 	if err != nil {
 		return nil, err
 	}
 	return mapper, err
 }
+
+func TestMappingDropAction(t *testing.T) {
+	mapper, err := getMapper(`
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.debug.*"
+        action: drop
+      - match: "test.job.*"
+        name: "test.job"
+        tags:
+          job_name: "$1"
+`)
+	require.NoError(t, err)
+
+	result := mapper.Map("test.debug.my_job")
+	require.NotNil(t, result)
+	assert.True(t, result.Drop)
+
+	result = mapper.Map("test.job.my_job")
+	require.NotNil(t, result)
+	assert.False(t, result.Drop)
+	assert.Equal(t, "test.job", result.Name)
+}
+
+func TestMappingTransforms(t *testing.T) {
+	mapper, err := getMapper(`
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'Legacy.'
+    mappings:
+      - match: 'Legacy\.(\w+)\.(?P<host>[\w-]+)\.requests'
+        match_type: regex
+        name: "legacy.$1.requests"
+        tags:
+          host: "${host}"
+        transforms:
+          - type: lowercase
+          - type: replace
+            pattern: "-+"
+            replacement: "_"
+`)
+	require.NoError(t, err)
+
+	result := mapper.Map("Legacy.WebServer.Host--01.requests")
+	require.NotNil(t, result)
+	assert.Equal(t, "legacy.webserver.requests", result.Name)
+	assert.Equal(t, []string{"host:host_01"}, result.Tags)
+}
+
+func TestMappingRewriteTags(t *testing.T) {
+	mapper, err := getMapper(`
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.*"
+        name: "test.job"
+        tags:
+          job_name: "$1"
+        drop_tags:
+          - pid
+        rename_tags:
+          jobtype: job_type
+`)
+	require.NoError(t, err)
+
+	result := mapper.Map("test.job.my_job")
+	require.NotNil(t, result)
+	tags := result.RewriteTags([]string{"pid:1234", "jobtype:batch", "env:prod", "pid", "standalone"})
+	assert.Equal(t, []string{"job_type:batch", "env:prod", "standalone"}, tags)
+
+	// the mappings without tag rules keep the tags
+	mapper, err = getMapper(`
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.*"
+        name: "test.job"
+`)
+	require.NoError(t, err)
+	result = mapper.Map("test.job.my_job")
+	require.NotNil(t, result)
+	assert.Equal(t, []string{"pid:1234"}, result.RewriteTags([]string{"pid:1234"}))
+}
+
+func TestMappingMatchOrder(t *testing.T) {
+	profiles := `
+dogstatsd_mapper_profiles:
+  - name: catch_all
+    prefix: '*'
+    mappings:
+      - match: "*.*.*"
+        name: "generic.$3"
+        tags:
+          source: "$1"
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.job.*"
+        name: "test.job"
+        tags:
+          job_name: "$1"
+  - name: test_worker
+    prefix: 'test.worker.'
+    mappings:
+      - match: "test.worker.*"
+        name: "test.worker"
+        tags:
+          worker_name: "$1"
+`
+	config.Datadog.Set("dogstatsd_mapper_match_order", MatchOrderFirst)
+	defer config.Datadog.Set("dogstatsd_mapper_match_order", MatchOrderFirst)
+	mapper, err := getMapper(profiles)
+	require.NoError(t, err)
+	// the first profile matches all the metrics
+	result := mapper.Map("test.job.my_job")
+	require.NotNil(t, result)
+	assert.Equal(t, "generic.my_job", result.Name)
+
+	config.Datadog.Set("dogstatsd_mapper_match_order", MatchOrderBest)
+	mapper, err = getMapper(profiles)
+	require.NoError(t, err)
+	result = mapper.Map("test.job.my_job")
+	require.NotNil(t, result)
+	assert.Equal(t, "test.job", result.Name)
+	result = mapper.Map("test.worker.my_worker")
+	require.NotNil(t, result)
+	assert.Equal(t, "test.worker", result.Name)
+	// the less specific profiles are tried when the most specific ones do not match
+	result = mapper.Map("test.task.my_task")
+	require.NotNil(t, result)
+	assert.Equal(t, "generic.my_task", result.Name)
+	assert.Nil(t, mapper.Map("test.unknown"))
+
+	config.Datadog.Set("dogstatsd_mapper_match_order", "invalid")
+	_, err = getMapper(profiles)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid match order")
+}
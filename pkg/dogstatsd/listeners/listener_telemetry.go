@@ -2,8 +2,6 @@
 // under the Apache License Version 2.0.
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2016-present Datadog, Inc.
-//go:build windows
-// +build windows
 
 package listeners
 
@@ -24,27 +22,18 @@ type listenerTelemetry struct {
 }
 
 func newListenerTelemetry(metricName string, name string) *listenerTelemetry {
-	expvars := expvar.NewMap("dogstatsd-" + metricName)
-	packetReadingErrors := expvar.Int{}
-	packets := expvar.Int{}
-	bytes := expvar.Int{}
-
-	tlmPackets := telemetry.NewCounter("dogstatsd", metricName+"_packets",
-		[]string{"state"}, fmt.Sprintf("Dogstatsd %s packets count", name))
-	tlmPacketsBytes := telemetry.NewCounter("dogstatsd", metricName+"_packets_bytes",
-		nil, fmt.Sprintf("Dogstatsd %s packets bytes count", name))
-	expvars.Set("PacketReadingErrors", &packetReadingErrors)
-	expvars.Set("Packets", &packets)
-	expvars.Set("Bytes", &bytes)
-
-	return &listenerTelemetry{
-		expvars:             expvars,
-		packetReadingErrors: packetReadingErrors,
-		tlmPackets:          tlmPackets,
-		packets:             packets,
-		bytes:               bytes,
-		tlmPacketsBytes:     tlmPacketsBytes,
+	t := &listenerTelemetry{
+		expvars: expvar.NewMap("dogstatsd-" + metricName),
+		tlmPackets: telemetry.NewCounter("dogstatsd", metricName+"_packets",
+			[]string{"state"}, fmt.Sprintf("Dogstatsd %s packets count", name)),
+		tlmPacketsBytes: telemetry.NewCounter("dogstatsd", metricName+"_packets_bytes",
+			nil, fmt.Sprintf("Dogstatsd %s packets bytes count", name)),
 	}
+	t.expvars.Set("PacketReadingErrors", &t.packetReadingErrors)
+	t.expvars.Set("Packets", &t.packets)
+	t.expvars.Set("Bytes", &t.bytes)
+
+	return t
 }
 
 func (t *listenerTelemetry) onReadSuccess(n int) {
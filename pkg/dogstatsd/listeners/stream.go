@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+)
+
+// streamFraming is the way the messages are delimited in a stream.
+type streamFraming int
+
+const (
+	// newlineFraming delimits the messages with a '\n'
+	newlineFraming streamFraming = iota
+	// lengthPrefixedFraming prefixes each frame with its size as a 32 bits little
+	// endian integer, a frame can contain several messages separated by a '\n'
+	lengthPrefixedFraming
+)
+
+const lengthPrefixSize = 4
+
+// parseStreamFraming returns the framing matching its configuration value.
+func parseStreamFraming(value string) (streamFraming, error) {
+	switch value {
+	case "", "newline":
+		return newlineFraming, nil
+	case "length_prefixed":
+		return lengthPrefixedFraming, nil
+	}
+	return newlineFraming, fmt.Errorf("invalid framing %q, must be one of: newline, length_prefixed", value)
+}
+
+// readStream reads the messages of a stream until it is closed and calls handle
+// with each block of complete messages. It returns nil once the stream is closed
+// by the client. The content passed to handle is only valid until it returns.
+func readStream(r io.Reader, framing streamFraming, buffer []byte, handle func([]byte)) error {
+	if framing == lengthPrefixedFraming {
+		return readLengthPrefixedStream(r, buffer, handle)
+	}
+	return readNewlineStream(r, buffer, handle)
+}
+
+func readNewlineStream(r io.Reader, buffer []byte, handle func([]byte)) error {
+	startWriteIndex := 0
+	for {
+		bytesRead, err := r.Read(buffer[startWriteIndex:])
+		endIndex := startWriteIndex + bytesRead
+
+		// When there is no '\n', the message is partial. LastIndexByte returns -1 and messageSize is 0.
+		// If there is a '\n', at least one message is completed and '\n' is part of this message.
+		messageSize := bytes.LastIndexByte(buffer[:endIndex], '\n') + 1
+		if messageSize > 0 {
+			handle(buffer[:messageSize])
+		}
+
+		startWriteIndex = endIndex - messageSize
+		// If the message is bigger than the buffer size, reset startWriteIndex to continue reading next messages.
+		if startWriteIndex >= len(buffer) {
+			startWriteIndex = 0
+		} else {
+			copy(buffer, buffer[messageSize:endIndex])
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func readLengthPrefixedStream(r io.Reader, buffer []byte, handle func([]byte)) error {
+	var prefix [lengthPrefixSize]byte
+	for {
+		if _, err := io.ReadFull(r, prefix[:]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		size := binary.LittleEndian.Uint32(prefix[:])
+		if uint64(size) > uint64(len(buffer)) {
+			return fmt.Errorf("frame of %d bytes is larger than the buffer of %d bytes", size, len(buffer))
+		}
+		if _, err := io.ReadFull(r, buffer[:size]); err != nil {
+			return err
+		}
+		if size > 0 {
+			handle(buffer[:size])
+		}
+	}
+}
+
+// streamConnections tracks the connections of a stream listener, so that they
+// are closed when the listener stops.
+type streamConnections struct {
+	mu          sync.Mutex
+	connections map[net.Conn]struct{}
+	stopped     bool
+	wg          sync.WaitGroup
+}
+
+func newStreamConnections() *streamConnections {
+	return &streamConnections{connections: make(map[net.Conn]struct{})}
+}
+
+// add tracks a new connection, it returns false and closes the connection if the
+// listener is stopped. remove must be called once a tracked connection is handled.
+func (c *streamConnections) add(conn net.Conn) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped {
+		conn.Close()
+		return false
+	}
+	c.connections[conn] = struct{}{}
+	c.wg.Add(1)
+	return true
+}
+
+// remove closes a connection and stops tracking it.
+func (c *streamConnections) remove(conn net.Conn) {
+	c.mu.Lock()
+	delete(c.connections, conn)
+	c.mu.Unlock()
+	conn.Close()
+	c.wg.Done()
+}
+
+// count returns the number of tracked connections.
+func (c *streamConnections) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.connections)
+}
+
+// closeAll closes the connections and waits until they are all handled, the
+// connections added afterwards are closed right away.
+func (c *streamConnections) closeAll() {
+	c.mu.Lock()
+	c.stopped = true
+	for conn := range c.connections {
+		conn.Close()
+	}
+	c.mu.Unlock()
+	c.wg.Wait()
+}
+
+// isClosedConnError returns true if the error is the one returned when reading
+// from a connection closed by the listener.
+func isClosedConnError(err error) bool {
+	return strings.HasSuffix(err.Error(), " use of closed network connection")
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lengthPrefixed(frames ...string) []byte {
+	var buf bytes.Buffer
+	for _, frame := range frames {
+		binary.Write(&buf, binary.LittleEndian, uint32(len(frame)))
+		buf.WriteString(frame)
+	}
+	return buf.Bytes()
+}
+
+func readAllStream(t *testing.T, content []byte, framing streamFraming, bufferSize int) ([]string, error) {
+	var received []string
+	err := readStream(bytes.NewReader(content), framing, make([]byte, bufferSize), func(messages []byte) {
+		received = append(received, string(messages))
+	})
+	return received, err
+}
+
+func TestParseStreamFraming(t *testing.T) {
+	framing, err := parseStreamFraming("")
+	require.NoError(t, err)
+	assert.Equal(t, newlineFraming, framing)
+	framing, err = parseStreamFraming("length_prefixed")
+	require.NoError(t, err)
+	assert.Equal(t, lengthPrefixedFraming, framing)
+	_, err = parseStreamFraming("json")
+	assert.Error(t, err)
+}
+
+func TestReadNewlineStream(t *testing.T) {
+	received, err := readAllStream(t, []byte("a:1|c\nb:2|c\nincomplete:3|c"), newlineFraming, 64)
+	require.NoError(t, err)
+	// the message which is not terminated is dropped
+	assert.Equal(t, []string{"a:1|c\nb:2|c\n"}, received)
+}
+
+func TestReadNewlineStreamMessagesAcrossReads(t *testing.T) {
+	// the buffer is smaller than the content, messages are split across reads
+	received, err := readAllStream(t, []byte("a:1|c\nb:2|c\nc:3|c\n"), newlineFraming, 8)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:1|c\n", "b:2|c\n", "c:3|c\n"}, received)
+}
+
+func TestReadLengthPrefixedStream(t *testing.T) {
+	received, err := readAllStream(t, lengthPrefixed("a:1|c", "", "b:2|c\nc:3|c"), lengthPrefixedFraming, 64)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a:1|c", "b:2|c\nc:3|c"}, received)
+}
+
+func TestReadLengthPrefixedStreamErrors(t *testing.T) {
+	// the frame is larger than the buffer
+	received, err := readAllStream(t, lengthPrefixed("a:1|c", "a_very_long_metric_name:1|c"), lengthPrefixedFraming, 16)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"a:1|c"}, received)
+
+	// the stream is closed in the middle of a frame
+	content := lengthPrefixed("a:1|c")
+	_, err = readAllStream(t, content[:len(content)-1], lengthPrefixedFraming, 16)
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var tcpTelemetry = newListenerTelemetry("tcp", "TCP")
+
+// TCPListener implements the StatsdListener interface for TCP protocol.
+// It listens to a given TCP address and sends back packets ready to be
+// processed. The messages are either delimited by a newline or sent in
+// length-prefixed frames.
+// Origin detection is not implemented for TCP.
+type TCPListener struct {
+	listener        net.Listener
+	packetsBuffer   *packets.Buffer
+	packetAssembler *packets.Assembler
+	bufferSize      int
+	framing         streamFraming
+	trafficCapture  *replay.TrafficCapture // Currently ignored
+	connections     *streamConnections
+}
+
+// NewTCPListener returns an idle TCP Statsd listener
+func NewTCPListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture *replay.TrafficCapture) (*TCPListener, error) {
+	var url string
+
+	if config.Datadog.GetBool("dogstatsd_non_local_traffic") == true {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%d", config.Datadog.GetInt("dogstatsd_tcp_port"))
+	} else {
+		url = net.JoinHostPort(config.GetBindHost(), config.Datadog.GetString("dogstatsd_tcp_port"))
+	}
+
+	framing, err := parseStreamFraming(config.Datadog.GetString("dogstatsd_tcp_framing"))
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-tcp: %s", err)
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	bufferSize := config.Datadog.GetInt("dogstatsd_buffer_size")
+	packetsBufferSize := config.Datadog.GetInt("dogstatsd_packet_buffer_size")
+	flushTimeout := config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+
+	packetsBuffer := packets.NewBuffer(uint(packetsBufferSize), flushTimeout, packetOut)
+	packetAssembler := packets.NewAssembler(flushTimeout, packetsBuffer, sharedPacketPoolManager, packets.TCP)
+
+	l := &TCPListener{
+		listener:        listener,
+		packetsBuffer:   packetsBuffer,
+		packetAssembler: packetAssembler,
+		bufferSize:      bufferSize,
+		framing:         framing,
+		trafficCapture:  capture,
+		connections:     newStreamConnections(),
+	}
+	log.Debugf("dogstatsd-tcp: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *TCPListener) Listen() {
+	log.Infof("dogstatsd-tcp: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			// listener has been closed
+			if isClosedConnError(err) {
+				return
+			}
+			log.Errorf("dogstatsd-tcp: error accepting connection: %v", err)
+			continue
+		}
+		if !l.connections.add(conn) {
+			return
+		}
+		go l.listenConnection(conn)
+	}
+}
+
+// listenConnection reads the messages sent on a connection until it is closed.
+func (l *TCPListener) listenConnection(conn net.Conn) {
+	defer l.connections.remove(conn)
+	tlmTCPConnections.Inc()
+	defer tlmTCPConnections.Dec()
+
+	log.Debugf("dogstatsd-tcp: new connection from %s", conn.RemoteAddr())
+	err := readStream(conn, l.framing, make([]byte, l.bufferSize), func(messages []byte) {
+		t1 := time.Now()
+		tcpTelemetry.onReadSuccess(len(messages))
+
+		// packetAssembler merges multiple packets together and sends them when its buffer is full
+		l.packetAssembler.AddMessage(messages)
+
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "tcp")
+	})
+	if err != nil && !isClosedConnError(err) {
+		log.Errorf("dogstatsd-tcp: error reading from %s, closing the connection: %v", conn.RemoteAddr(), err)
+		tcpTelemetry.onReadError()
+		tlmTCPConnectionErrors.Inc()
+	}
+}
+
+// Stop closes the TCP connections and stops listening
+func (l *TCPListener) Stop() {
+	l.listener.Close()
+	l.connections.closeAll()
+
+	l.packetAssembler.Close()
+	l.packetsBuffer.Close()
+}
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+var (
+	packetPoolTCP        = packets.NewPool(config.Datadog.GetInt("dogstatsd_buffer_size"))
+	packetPoolManagerTCP = packets.NewPoolManager(packetPoolTCP)
+)
+
+func newTestTCPListener(t *testing.T, framing string, packetOut chan packets.Packets) *TCPListener {
+	config.Datadog.SetDefault("dogstatsd_tcp_port", 0)
+	config.Datadog.SetDefault("dogstatsd_non_local_traffic", false)
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", framing)
+	t.Cleanup(func() { config.Datadog.SetDefault("dogstatsd_tcp_framing", "newline") })
+
+	s, err := NewTCPListener(packetOut, packetPoolManagerTCP, nil)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	return s
+}
+
+func receivePackets(t *testing.T, packetChannel chan packets.Packets) packets.Packets {
+	select {
+	case pkts := <-packetChannel:
+		return pkts
+	case <-time.After(2 * time.Second):
+		assert.FailNow(t, "Timeout on receive channel")
+	}
+	return nil
+}
+
+func TestNewTCPListenerWithInvalidFraming(t *testing.T) {
+	config.Datadog.SetDefault("dogstatsd_tcp_port", 0)
+	config.Datadog.SetDefault("dogstatsd_tcp_framing", "json")
+	defer config.Datadog.SetDefault("dogstatsd_tcp_framing", "newline")
+
+	s, err := NewTCPListener(nil, packetPoolManagerTCP, nil)
+	assert.Nil(t, s)
+	assert.Error(t, err)
+}
+
+func TestTCPReceiveNewlineDelimited(t *testing.T) {
+	packetChannel := make(chan packets.Packets)
+	s := newTestTCPListener(t, "newline", packetChannel)
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write([]byte("daemon:666|g|#sometag1:somevalue1\ndaemon:"))
+	conn.Write([]byte("667|g\n"))
+
+	var contents []byte
+	for len(contents) < len("daemon:666|g|#sometag1:somevalue1\ndaemon:667|g\n") {
+		pkts := receivePackets(t, packetChannel)
+		for _, packet := range pkts {
+			assert.Equal(t, packets.TCP, packet.Source)
+			assert.Equal(t, "", packet.Origin)
+			if len(contents) > 0 {
+				contents = append(contents, '\n')
+			}
+			contents = append(contents, packet.Contents...)
+		}
+	}
+	assert.Equal(t, "daemon:666|g|#sometag1:somevalue1\ndaemon:667|g\n", string(contents))
+}
+
+func TestTCPReceiveLengthPrefixed(t *testing.T) {
+	packetChannel := make(chan packets.Packets)
+	s := newTestTCPListener(t, "length_prefixed", packetChannel)
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.Write(lengthPrefixed("daemon:666|g|#sometag1:somevalue1"))
+
+	pkts := receivePackets(t, packetChannel)
+	require.Len(t, pkts, 1)
+	assert.Equal(t, "daemon:666|g|#sometag1:somevalue1", string(pkts[0].Contents))
+	assert.Equal(t, packets.TCP, pkts[0].Source)
+}
+
+func TestTCPStopClosesConnections(t *testing.T) {
+	s := newTestTCPListener(t, "newline", make(chan packets.Packets))
+	go s.Listen()
+
+	conn, err := net.Dial("tcp", s.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Eventually(t, func() bool { return s.connections.count() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	_, err = net.Dial("tcp", s.listener.Addr().String())
+	assert.Error(t, err)
+}
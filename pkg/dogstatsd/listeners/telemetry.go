@@ -24,6 +24,18 @@ var (
 	tlmUDSPacketsBytes = telemetry.NewCounter("dogstatsd", "uds_packets_bytes",
 		nil, "Dogstatsd UDS packets bytes")
 
+	// TCP
+	tlmTCPConnections = telemetry.NewGauge("dogstatsd", "tcp_connections",
+		nil, "Dogstatsd TCP active connections")
+	tlmTCPConnectionErrors = telemetry.NewCounter("dogstatsd", "tcp_connection_errors",
+		nil, "Dogstatsd TCP connections closed because of an error")
+
+	// UDS stream
+	tlmUDSStreamConnections = telemetry.NewGauge("dogstatsd", "uds_stream_connections",
+		nil, "Dogstatsd UDS stream active connections")
+	tlmUDSStreamConnectionErrors = telemetry.NewCounter("dogstatsd", "uds_stream_connection_errors",
+		nil, "Dogstatsd UDS stream connections closed because of an error")
+
 	tlmListener            = telemetry.NewHistogramNoOp()
 	defaultListenerBuckets = []float64{300, 500, 1000, 1500, 2000, 2500, 3000, 10000, 20000, 50000}
 )
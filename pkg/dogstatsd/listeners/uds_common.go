@@ -57,17 +57,8 @@ func NewUDSListener(packetOut chan packets.Packets, sharedPacketPoolManager *pac
 	if addrErr != nil {
 		return nil, fmt.Errorf("dogstatsd-uds: can't ResolveUnixAddr: %v", addrErr)
 	}
-	fileInfo, err := os.Stat(socketPath)
-	// Socket file already exists
-	if err == nil {
-		// Make sure it's a UNIX socket
-		if fileInfo.Mode()&os.ModeSocket == 0 {
-			return nil, fmt.Errorf("dogstatsd-uds: cannot reuse %s socket path: path already exists and is not a UNIX socket", socketPath)
-		}
-		err = os.Remove(socketPath)
-		if err != nil {
-			return nil, fmt.Errorf("dogstatsd-uds: cannot remove stale UNIX socket: %v", err)
-		}
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds: %v", err)
 	}
 
 	conn, err := net.ListenUnixgram("unixgram", address)
@@ -135,6 +126,24 @@ func NewUDSListener(packetOut chan packets.Packets, sharedPacketPoolManager *pac
 	return listener, nil
 }
 
+// removeStaleSocket removes the socket left at socketPath by a previous run,
+// it fails if another kind of file exists at this path.
+func removeStaleSocket(socketPath string) error {
+	fileInfo, err := os.Stat(socketPath)
+	// Socket file already exists
+	if err == nil {
+		// Make sure it's a UNIX socket
+		if fileInfo.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("cannot reuse %s socket path: path already exists and is not a UNIX socket", socketPath)
+		}
+		err = os.Remove(socketPath)
+		if err != nil {
+			return fmt.Errorf("cannot remove stale UNIX socket: %v", err)
+		}
+	}
+	return nil
+}
+
 // Listen runs the intake loop. Should be called in its own goroutine
 func (l *UDSListener) Listen() {
 	t1 := time.Now()
@@ -66,7 +66,34 @@ func processUDSOrigin(ancillary []byte) (int, string, error) {
 	if err != nil {
 		return 0, packets.NoOrigin, err
 	}
+	return processUDSCredentials(cred)
+}
+
+// processUDSPeerOrigin determines the origin of a stream connection from the
+// credentials of the peer process, which are stored by the Linux kernel when
+// the connection is established and read with SO_PEERCRED.
+func processUDSPeerOrigin(conn *net.UnixConn) (int, string, error) {
+	rawconn, err := conn.SyscallConn()
+	if err != nil {
+		return 0, packets.NoOrigin, err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	err = rawconn.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return 0, packets.NoOrigin, err
+	}
+	if credErr != nil {
+		return 0, packets.NoOrigin, credErr
+	}
+	return processUDSCredentials(cred)
+}
 
+// processUDSCredentials returns the PID and the entity of the process which
+// sent the credentials.
+func processUDSCredentials(cred *unix.Ucred) (int, string, error) {
 	if cred.Pid == 0 {
 		return 0, packets.NoOrigin, fmt.Errorf("matched PID for the process is 0, it belongs " +
 			"probably to another namespace. Is the agent in host PID mode?")
@@ -15,6 +15,7 @@ package listeners
 
 import (
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
@@ -54,3 +55,21 @@ func TestUDSPassCred(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, enabled, 1)
 }
+
+func TestUDSPeerOrigin(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "dsd-stream.socket")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	require.NoError(t, err)
+	defer listener.Close()
+
+	client, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := listener.AcceptUnix()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the entity of the test process cannot be resolved, but its PID is found
+	pid, _, _ := processUDSPeerOrigin(conn)
+	assert.Equal(t, os.Getpid(), pid)
+}
@@ -0,0 +1,145 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/replay"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var udsStreamTelemetry = newListenerTelemetry("uds_stream", "UDS stream")
+
+// UDSStreamListener implements the StatsdListener interface for Unix Domain
+// Socket stream protocol. It listens to a given socket path and sends back
+// packets ready to be processed. Each frame is prefixed with its size.
+// Unlike datagrams, which are dropped when the receive buffer of the socket is
+// full, the clients are blocked until the agent is able to read their frames.
+// Origin detection is done once per connection, with the credentials of the
+// process which opened it.
+type UDSStreamListener struct {
+	listener                *net.UnixListener
+	packetsBuffer           *packets.Buffer
+	sharedPacketPoolManager *packets.PoolManager
+	bufferSize              int
+	trafficCapture          *replay.TrafficCapture // Currently ignored
+	connections             *streamConnections
+	OriginDetection         bool
+}
+
+// NewUDSStreamListener returns an idle UDS stream Statsd listener
+func NewUDSStreamListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, capture *replay.TrafficCapture) (*UDSStreamListener, error) {
+	socketPath := config.Datadog.GetString("dogstatsd_stream_socket")
+
+	address, err := net.ResolveUnixAddr("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: can't ResolveUnixAddr: %v", err)
+	}
+	if err := removeStaleSocket(socketPath); err != nil {
+		return nil, fmt.Errorf("dogstatsd-uds-stream: %v", err)
+	}
+
+	listener, err := net.ListenUnix("unix", address)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+	err = os.Chmod(socketPath, 0722)
+	if err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("can't set the socket at write only: %s", err)
+	}
+
+	l := &UDSStreamListener{
+		listener: listener,
+		packetsBuffer: packets.NewBuffer(uint(config.Datadog.GetInt("dogstatsd_packet_buffer_size")),
+			config.Datadog.GetDuration("dogstatsd_packet_buffer_flush_timeout"), packetOut),
+		sharedPacketPoolManager: sharedPacketPoolManager,
+		bufferSize:              config.Datadog.GetInt("dogstatsd_buffer_size"),
+		trafficCapture:          capture,
+		connections:             newStreamConnections(),
+		OriginDetection:         config.Datadog.GetBool("dogstatsd_origin_detection"),
+	}
+
+	log.Debugf("dogstatsd-uds-stream: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+// Listen runs the intake loop. Should be called in its own goroutine
+func (l *UDSStreamListener) Listen() {
+	log.Infof("dogstatsd-uds-stream: starting to listen on %s", l.listener.Addr())
+	for {
+		conn, err := l.listener.AcceptUnix()
+		if err != nil {
+			// listener has been closed
+			if isClosedConnError(err) {
+				return
+			}
+			log.Errorf("dogstatsd-uds-stream: error accepting connection: %v", err)
+			continue
+		}
+		if !l.connections.add(conn) {
+			return
+		}
+		go l.listenConnection(conn)
+	}
+}
+
+// listenConnection reads the frames sent on a connection until it is closed.
+func (l *UDSStreamListener) listenConnection(conn *net.UnixConn) {
+	defer l.connections.remove(conn)
+	tlmUDSStreamConnections.Inc()
+	defer tlmUDSStreamConnections.Dec()
+
+	origin := packets.NoOrigin
+	if l.OriginDetection {
+		_, container, err := processUDSPeerOrigin(conn)
+		if err != nil {
+			log.Warnf("dogstatsd-uds-stream: error processing origin, data will not be tagged : %v", err)
+			udsOriginDetectionErrors.Add(1)
+			tlmUDSOriginDetectionError.Inc()
+		} else {
+			origin = container
+		}
+	}
+
+	err := readStream(conn, lengthPrefixedFraming, make([]byte, l.bufferSize), func(frame []byte) {
+		t1 := time.Now()
+		udsStreamTelemetry.onReadSuccess(len(frame))
+
+		// retrieve an available packet from the packet pool,
+		// which will be pushed back by the server when processed.
+		packet := l.sharedPacketPoolManager.Get().(*packets.Packet)
+		n := copy(packet.Buffer, frame)
+		packet.Contents = packet.Buffer[:n]
+		packet.Origin = origin
+		packet.Source = packets.UDSStream
+
+		// packetsBuffer handles the forwarding of the packets to the dogstatsd server intake channel,
+		// it blocks when the intake is full which blocks the client as well.
+		l.packetsBuffer.Append(packet)
+
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "uds_stream")
+	})
+	if err != nil && !isClosedConnError(err) {
+		log.Errorf("dogstatsd-uds-stream: error reading packet, closing the connection: %v", err)
+		udsStreamTelemetry.onReadError()
+		tlmUDSStreamConnectionErrors.Inc()
+	}
+}
+
+// Stop closes the UDS stream connections and stops listening,
+// the socket file is removed when the listener is closed.
+func (l *UDSStreamListener) Stop() {
+	l.listener.Close()
+	l.connections.closeAll()
+	l.packetsBuffer.Close()
+}
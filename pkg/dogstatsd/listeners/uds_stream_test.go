@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+// UDS won't work in windows
+
+package listeners
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/dogstatsd/packets"
+)
+
+func newTestUDSStreamListener(t *testing.T, packetsChannel chan packets.Packets) (*UDSStreamListener, string) {
+	socketPath := filepath.Join(t.TempDir(), "dsd-stream.socket")
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_stream_socket", socketPath)
+	mockConfig.Set("dogstatsd_origin_detection", false)
+
+	s, err := NewUDSStreamListener(packetsChannel, packetPoolManagerUDS, nil)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	return s, socketPath
+}
+
+func TestNewUDSStreamListenerFileExists(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "dsd-stream.socket")
+	require.NoError(t, ioutil.WriteFile(socketPath, []byte{}, 0666))
+	mockConfig := config.Mock()
+	mockConfig.Set("dogstatsd_stream_socket", socketPath)
+
+	s, err := NewUDSStreamListener(nil, packetPoolManagerUDS, nil)
+	assert.Error(t, err)
+	assert.Nil(t, s)
+}
+
+func TestStartStopUDSStreamListener(t *testing.T) {
+	s, socketPath := newTestUDSStreamListener(t, nil)
+
+	fi, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, "Srwx-w--w-", fi.Mode().String())
+
+	go s.Listen()
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Eventually(t, func() bool { return s.connections.count() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	s.Stop()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	_, err = net.Dial("unix", socketPath)
+	assert.Error(t, err)
+}
+
+func TestUDSStreamReceive(t *testing.T) {
+	packetsChannel := make(chan packets.Packets)
+	s, socketPath := newTestUDSStreamListener(t, packetsChannel)
+	go s.Listen()
+	defer s.Stop()
+
+	conn, err := net.Dial("unix", socketPath)
+	require.NoError(t, err)
+	defer conn.Close()
+	content := lengthPrefixed("daemon:666|g|#sometag1:somevalue1", "daemon:667|g")
+	// the frames can be split across writes
+	conn.Write(content[:10])
+	conn.Write(content[10:])
+
+	var received []string
+	for len(received) < 2 {
+		select {
+		case pkts := <-packetsChannel:
+			for _, packet := range pkts {
+				assert.Equal(t, packets.NoOrigin, packet.Origin)
+				assert.Equal(t, packets.UDSStream, packet.Source)
+				received = append(received, string(packet.Contents))
+			}
+		case <-time.After(2 * time.Second):
+			assert.FailNow(t, "Timeout on receive channel")
+		}
+	}
+	assert.Equal(t, []string{"daemon:666|g|#sometag1:somevalue1", "daemon:667|g"}, received)
+}
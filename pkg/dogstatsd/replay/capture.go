@@ -55,7 +55,8 @@ func (tc *TrafficCapture) IsOngoing() bool {
 }
 
 // Start starts a TrafficCapture and returns an error in the event of an issue.
-func (tc *TrafficCapture) Start(p string, d time.Duration, compressed bool) error {
+// Only the traffic matching the filter is captured, a nil filter captures everything.
+func (tc *TrafficCapture) Start(p string, d time.Duration, compressed bool, filter *CaptureFilter) error {
 	if tc.IsOngoing() {
 		return fmt.Errorf("Ongoing capture in progress")
 	}
@@ -65,7 +66,7 @@ func (tc *TrafficCapture) Start(p string, d time.Duration, compressed bool) erro
 		return err
 	}
 
-	go tc.Writer.Capture(p, d, compressed)
+	go tc.Writer.Capture(p, d, compressed, filter)
 
 	return nil
 
@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"strings"
+)
+
+var (
+	eventPrefix        = []byte("_e{")
+	serviceCheckPrefix = []byte("_sc|")
+)
+
+// CaptureFilter restricts the traffic written by a capture. A packet is captured
+// when its origin matches the PIDs or container IDs of the filter, and only the
+// messages of this packet matching its metric name prefixes and tags are kept.
+// Each empty criterion matches everything, the values of a criterion are ORed.
+type CaptureFilter struct {
+	MetricPrefixes []string
+	Pids           []int32
+	ContainerIDs   []string
+	// Tags are either complete `key:value` tags or tag keys
+	Tags []string
+}
+
+// IsEmpty returns whether the filter lets all the traffic through.
+func (f *CaptureFilter) IsEmpty() bool {
+	return f == nil || (len(f.MetricPrefixes) == 0 && len(f.Pids) == 0 && len(f.ContainerIDs) == 0 && len(f.Tags) == 0)
+}
+
+// matchOrigin returns whether a packet sent by the given origin should be captured.
+func (f *CaptureFilter) matchOrigin(pid int32, containerID string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if len(f.Pids) > 0 && !containsPid(f.Pids, pid) {
+		return false
+	}
+	if len(f.ContainerIDs) > 0 && !containsString(f.ContainerIDs, containerID) {
+		return false
+	}
+	return true
+}
+
+// filterPayload returns the messages of the payload matching the filter, the
+// payload is returned as is when all of them match and is left untouched otherwise.
+func (f *CaptureFilter) filterPayload(payload []byte) []byte {
+	if f.IsEmpty() || (len(f.MetricPrefixes) == 0 && len(f.Tags) == 0) {
+		return payload
+	}
+
+	var filtered []byte
+	dropped := false
+	remaining := payload
+	for len(remaining) > 0 {
+		var message []byte
+		if i := bytes.IndexByte(remaining, '\n'); i >= 0 {
+			message, remaining = remaining[:i+1], remaining[i+1:]
+		} else {
+			message, remaining = remaining, nil
+		}
+		if f.matchMessage(bytes.TrimRight(message, "\r\n")) {
+			filtered = append(filtered, message...)
+		} else {
+			dropped = true
+		}
+	}
+
+	if !dropped {
+		return payload
+	}
+	return filtered
+}
+
+// matchMessage returns whether a single DogStatsD message matches the metric
+// name prefixes and the tags of the filter. Events and service checks never
+// match a metric name prefix.
+func (f *CaptureFilter) matchMessage(message []byte) bool {
+	if len(message) == 0 {
+		return false
+	}
+
+	isMetric := !bytes.HasPrefix(message, eventPrefix) && !bytes.HasPrefix(message, serviceCheckPrefix)
+	if len(f.MetricPrefixes) > 0 {
+		if !isMetric {
+			return false
+		}
+		name := message
+		if i := bytes.IndexByte(message, ':'); i >= 0 {
+			name = message[:i]
+		}
+		if !hasAnyPrefix(name, f.MetricPrefixes) {
+			return false
+		}
+	}
+
+	if len(f.Tags) > 0 {
+		return f.matchTags(message)
+	}
+	return true
+}
+
+// matchTags returns whether one of the tags of the message matches the filter.
+func (f *CaptureFilter) matchTags(message []byte) bool {
+	for _, field := range bytes.Split(message, []byte("|")) {
+		if len(field) == 0 || field[0] != '#' {
+			continue
+		}
+		for _, tag := range strings.Split(string(field[1:]), ",") {
+			for _, expected := range f.Tags {
+				if tag == expected || (!strings.Contains(expected, ":") && strings.HasPrefix(tag, expected+":")) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(name []byte, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if bytes.HasPrefix(name, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPid(pids []int32, pid int32) bool {
+	for _, p := range pids {
+		if p == pid {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureFilterEmpty(t *testing.T) {
+	var filter *CaptureFilter
+	assert.True(t, filter.IsEmpty())
+	assert.True(t, (&CaptureFilter{}).IsEmpty())
+	assert.True(t, filter.matchOrigin(0, ""))
+
+	payload := []byte("foo:1|c\nbar:1|c")
+	assert.Equal(t, payload, filter.filterPayload(payload))
+}
+
+func TestCaptureFilterOrigin(t *testing.T) {
+	filter := &CaptureFilter{Pids: []int32{12, 34}}
+	assert.True(t, filter.matchOrigin(12, ""))
+	assert.True(t, filter.matchOrigin(34, "container"))
+	assert.False(t, filter.matchOrigin(56, ""))
+
+	filter = &CaptureFilter{ContainerIDs: []string{"container"}}
+	assert.True(t, filter.matchOrigin(12, "container"))
+	assert.False(t, filter.matchOrigin(12, "other"))
+	assert.False(t, filter.matchOrigin(12, ""))
+
+	filter = &CaptureFilter{Pids: []int32{12}, ContainerIDs: []string{"container"}}
+	assert.True(t, filter.matchOrigin(12, "container"))
+	assert.False(t, filter.matchOrigin(34, "container"))
+}
+
+func TestCaptureFilterMetricPrefixes(t *testing.T) {
+	filter := &CaptureFilter{MetricPrefixes: []string{"app.", "db."}}
+
+	payload := []byte("app.requests:1|c\nother.requests:1|c\ndb.queries:2|c|#env:prod\n")
+	assert.Equal(t, "app.requests:1|c\ndb.queries:2|c|#env:prod\n", string(filter.filterPayload(payload)))
+
+	// the payload is returned as is when all its messages match
+	payload = []byte("app.requests:1|c\napp.errors:1|c")
+	assert.Equal(t, payload, filter.filterPayload(payload))
+
+	// events and service checks have no metric name
+	payload = []byte("_e{5,4}:title|text\n_sc|app.check|0\nother:1|g\n")
+	assert.Empty(t, filter.filterPayload(payload))
+}
+
+func TestCaptureFilterTags(t *testing.T) {
+	filter := &CaptureFilter{Tags: []string{"env:prod", "team"}}
+
+	payload := []byte("a:1|c|#env:prod\nb:1|c|#env:dev\nc:1|c|@0.5|#team:core,env:dev\nd:1|c\n_sc|check|0|#env:prod\n")
+	assert.Equal(t, "a:1|c|#env:prod\nc:1|c|@0.5|#team:core,env:dev\n_sc|check|0|#env:prod\n", string(filter.filterPayload(payload)))
+
+	filter = &CaptureFilter{MetricPrefixes: []string{"a"}, Tags: []string{"env:prod"}}
+	payload = []byte("a:1|c|#env:prod\na:1|c|#env:dev\nb:1|c|#env:prod")
+	assert.Equal(t, "a:1|c|#env:prod\n", string(filter.filterPayload(payload)))
+}
+
+func TestCaptureWriterApplyFilter(t *testing.T) {
+	writer := NewTrafficCaptureWriter(1)
+	writer.filter = &CaptureFilter{Pids: []int32{12}, MetricPrefixes: []string{"app."}}
+
+	payload := []byte("app.requests:1|c\nother:1|c\n")
+	msg := &CaptureBuffer{}
+	msg.Pb.Pid = 12
+	msg.Pb.Payload = payload
+	msg.Pb.PayloadSize = int32(len(payload))
+
+	assert.True(t, writer.applyFilter(msg))
+	assert.Equal(t, "app.requests:1|c\n", string(msg.Pb.Payload))
+	assert.Equal(t, int32(len("app.requests:1|c\n")), msg.Pb.PayloadSize)
+	// the packet buffer is left untouched
+	assert.Equal(t, "app.requests:1|c\nother:1|c\n", string(payload))
+
+	msg.Pb.Pid = 34
+	assert.False(t, writer.applyFilter(msg))
+
+	msg.Pb.Pid = 12
+	msg.Pb.Payload = []byte("other:1|c")
+	msg.Pb.PayloadSize = int32(len(msg.Pb.Payload))
+	assert.False(t, writer.applyFilter(msg))
+}
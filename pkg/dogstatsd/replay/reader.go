@@ -228,7 +228,6 @@ func (tc *TrafficCaptureReader) ReadState() (map[int32]string, map[string]*pb.En
 	pbState := &pb.TaggerState{}
 	err := proto.Unmarshal(tc.Contents[length-int(sz)-4:length-4], pbState)
 	if err != nil {
-		tc.Unlock()
 		return nil, nil, err
 	}
 
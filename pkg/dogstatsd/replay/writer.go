@@ -71,6 +71,7 @@ type TrafficCaptureWriter struct {
 	Location string
 	shutdown chan struct{}
 	ongoing  bool
+	filter   *CaptureFilter
 
 	sharedPacketPoolManager *packets.PoolManager
 	oobPacketPoolManager    *packets.PoolManager
@@ -102,19 +103,22 @@ func (tc *TrafficCaptureWriter) Path() (string, error) {
 }
 
 // ProcessMessage receives a capture buffer and writes it to disk while also tracking
-// the PID map to be persisted to the taggerState. Should not normally be called directly.
+// the PID map to be persisted to the taggerState. The messages not matching the
+// capture filter are left out. Should not normally be called directly.
 func (tc *TrafficCaptureWriter) ProcessMessage(msg *CaptureBuffer) error {
 
 	tc.Lock()
 
-	err := tc.WriteNext(msg)
-	if err != nil {
-		tc.Unlock()
-		return err
-	}
+	if tc.applyFilter(msg) {
+		err := tc.WriteNext(msg)
+		if err != nil {
+			tc.Unlock()
+			return err
+		}
 
-	if msg.ContainerID != "" {
-		tc.taggerState[msg.Pid] = msg.ContainerID
+		if msg.ContainerID != "" {
+			tc.taggerState[msg.Pid] = msg.ContainerID
+		}
 	}
 
 	if tc.sharedPacketPoolManager != nil {
@@ -129,6 +133,27 @@ func (tc *TrafficCaptureWriter) ProcessMessage(msg *CaptureBuffer) error {
 	return nil
 }
 
+// applyFilter removes the messages not matching the capture filter from the
+// payload of the capture buffer, it returns false if no message is left.
+func (tc *TrafficCaptureWriter) applyFilter(msg *CaptureBuffer) bool {
+	if tc.filter.IsEmpty() {
+		return true
+	}
+	if !tc.filter.matchOrigin(msg.Pb.Pid, msg.ContainerID) {
+		return false
+	}
+
+	// the payload is shared with the packet being processed, it is
+	// not modified in place
+	payload := tc.filter.filterPayload(msg.Pb.Payload[:msg.Pb.PayloadSize])
+	if len(payload) == 0 {
+		return false
+	}
+	msg.Pb.Payload = payload
+	msg.Pb.PayloadSize = int32(len(payload))
+	return true
+}
+
 // ValidateLocation validates the location passed as an argument is writable.
 // The location and/or and error if any are returned.
 func (tc *TrafficCaptureWriter) ValidateLocation(l string) (string, error) {
@@ -174,8 +199,9 @@ func (tc *TrafficCaptureWriter) ValidateLocation(l string) (string, error) {
 }
 
 // Capture start the traffic capture and writes the packets to file at the
-// specified location and for the specified duration.
-func (tc *TrafficCaptureWriter) Capture(l string, d time.Duration, compressed bool) {
+// specified location and for the specified duration. Only the traffic matching
+// the filter is written, a nil filter captures everything.
+func (tc *TrafficCaptureWriter) Capture(l string, d time.Duration, compressed bool, filter *CaptureFilter) {
 
 	log.Debug("Starting capture...")
 
@@ -212,6 +238,7 @@ func (tc *TrafficCaptureWriter) Capture(l string, d time.Duration, compressed bo
 	}
 	tc.File = fp
 	target = tc.File
+	tc.filter = filter
 
 	if compressed {
 		tc.zWriter = zstd.NewWriter(target)
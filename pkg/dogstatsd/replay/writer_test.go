@@ -58,7 +58,7 @@ func writerTest(t *testing.T, z bool) {
 		defer wg.Done()
 
 		close(start)
-		writer.Capture("foo/bar", iterations*sleepInterval, z)
+		writer.Capture("foo/bar", iterations*sleepInterval, z, nil)
 	}(&wg)
 
 	enqueued := 0
@@ -43,6 +43,7 @@ var (
 	dogstatsdMetricPackets            = expvar.Int{}
 	dogstatsdPacketsLastSec           = expvar.Int{}
 	dogstatsdUnterminatedMetricErrors = expvar.Int{}
+	dogstatsdMapperDroppedMetrics     = expvar.Int{}
 
 	tlmProcessed = telemetry.NewCounter("dogstatsd", "processed",
 		[]string{"message_type", "state", "origin"}, "Count of service checks/events/metrics processed by dogstatsd")
@@ -68,6 +69,7 @@ func init() {
 	dogstatsdExpvars.Set("MetricParseErrors", &dogstatsdMetricParseErrors)
 	dogstatsdExpvars.Set("MetricPackets", &dogstatsdMetricPackets)
 	dogstatsdExpvars.Set("UnterminatedMetricErrors", &dogstatsdUnterminatedMetricErrors)
+	dogstatsdExpvars.Set("MapperDroppedMetrics", &dogstatsdMapperDroppedMetrics)
 }
 
 // used in debug mode to add the origin on the processed metric as a tag
@@ -243,6 +245,15 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 			udsListenerRunning = true
 		}
 	}
+	streamSocketPath := config.Datadog.GetString("dogstatsd_stream_socket")
+	if len(streamSocketPath) > 0 {
+		unixStreamListener, err := listeners.NewUDSStreamListener(packetsChannel, sharedPacketPoolManager, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, unixStreamListener)
+		}
+	}
 	if config.Datadog.GetInt("dogstatsd_port") > 0 {
 		udpListener, err := listeners.NewUDPListener(packetsChannel, sharedPacketPoolManager, capture)
 		if err != nil {
@@ -252,6 +263,15 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 		}
 	}
 
+	if config.Datadog.GetInt("dogstatsd_tcp_port") > 0 {
+		tcpListener, err := listeners.NewTCPListener(packetsChannel, sharedPacketPoolManager, capture)
+		if err != nil {
+			log.Errorf(err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, tcpListener)
+		}
+	}
+
 	pipeName := config.Datadog.GetString("dogstatsd_pipe_name")
 	if len(pipeName) > 0 {
 		namedPipeListener, err := listeners.NewNamedPipeListener(pipeName, packetsChannel, sharedPacketPoolManager, capture)
@@ -387,7 +407,8 @@ func NewServer(demultiplexer aggregator.Demultiplexer, serverless bool) (*Server
 	if err != nil {
 		log.Warnf("Could not parse mapping profiles: %v", err)
 	} else if len(mappings) != 0 {
-		mapperInstance, err := mapper.NewMetricMapper(mappings, cacheSize)
+		matchOrder := config.Datadog.GetString("dogstatsd_mapper_match_order")
+		mapperInstance, err := mapper.NewMetricMapper(mappings, matchOrder, cacheSize)
 		if err != nil {
 			log.Warnf("Could not create metric mapper: %v", err)
 		} else {
@@ -426,9 +447,10 @@ func (s *Server) handleMessages(serverless bool) {
 }
 
 // Capture starts a traffic capture at the specified path and with the specified duration,
-// an empty path will default to the default location. Returns an error if any.
-func (s *Server) Capture(p string, d time.Duration, compressed bool) error {
-	return s.TCapture.Start(p, d, compressed)
+// an empty path will default to the default location. Only the traffic matching the
+// filter is captured, a nil filter captures everything. Returns an error if any.
+func (s *Server) Capture(p string, d time.Duration, compressed bool, filter *replay.CaptureFilter) error {
+	return s.TCapture.Start(p, d, compressed, filter)
 }
 
 func (s *Server) forwarder(fcon net.Conn, packetsChannel chan packets.Packets) {
@@ -644,10 +666,18 @@ func (s *Server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 
 	if s.mapper != nil {
 		mapResult := s.mapper.Map(sample.name)
+		if mapResult != nil && mapResult.Drop {
+			log.Tracef("Dogstatsd mapper: metric %q dropped", sample.name)
+			dogstatsdMapperDroppedMetrics.Add(1)
+			if len(sample.values) > 0 {
+				s.sharedFloat64List.put(sample.values)
+			}
+			return metricSamples, nil
+		}
 		if mapResult != nil {
 			log.Tracef("Dogstatsd mapper: metric mapped from %q to %q with tags %v", sample.name, mapResult.Name, mapResult.Tags)
 			sample.name = mapResult.Name
-			sample.tags = append(sample.tags, mapResult.Tags...)
+			sample.tags = append(mapResult.RewriteTags(sample.tags), mapResult.Tags...)
 		}
 	}
 	metricSamples = enrichMetricSample(metricSamples, sample, s.metricPrefix, s.metricPrefixBlacklist, s.metricBlocklist, s.defaultHostname, origin, s.entityIDPrecedenceEnabled, s.ServerlessMode)
@@ -765,6 +765,33 @@ dogstatsd_mapper_profiles:
 			},
 			expectedCacheSize: 1000,
 		},
+		{
+			name: "Drop and rewrite tags",
+			config: `
+dogstatsd_mapper_profiles:
+  - name: test
+    prefix: 'test.'
+    mappings:
+      - match: "test.debug.*"
+        action: drop
+      - match: "test.job.duration.*"
+        name: "test.job.duration"
+        tags:
+          job_name: "$1"
+        drop_tags:
+          - pid
+        rename_tags:
+          jobtype: job_type
+`,
+			packets: []string{
+				"test.debug.my_job_name:666|g",
+				"test.job.duration.my_job_name:666|g|#pid:1234,jobtype:batch",
+			},
+			expectedSamples: []MetricSample{
+				{Name: "test.job.duration", Tags: []string{"job_type:batch", "job_name:my_job_name"}, Mtype: metrics.GaugeType, Value: 666.0},
+			},
+			expectedCacheSize: 1000,
+		},
 		{
 			name: "Cache size",
 			config: `
@@ -52,4 +52,6 @@ var (
 	OrchestratorEndpoint = transaction.Endpoint{Route: "/api/v2/orch", Name: "orchestrator"}
 	// ContainerLifecycleEndpoint is an event platform endpoint used to send container lifecycle events
 	ContainerLifecycleEndpoint = transaction.Endpoint{Route: "/api/v2/contlcycle", Name: "contlcycle"}
+	// RemoteWriteEndpoint is used to send Prometheus remote write payloads, the path is part of the configured URLs
+	RemoteWriteEndpoint = transaction.Endpoint{Route: "", Name: "remote_write"}
 )
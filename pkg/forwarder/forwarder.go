@@ -7,6 +7,7 @@ package forwarder
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"path"
 	"strconv"
@@ -67,6 +68,7 @@ type Forwarder interface {
 	SubmitSketchSeries(payload Payloads, extra http.Header) error
 	SubmitHostMetadata(payload Payloads, extra http.Header) error
 	SubmitAgentChecksMetadata(payload Payloads, extra http.Header) error
+	SubmitProcessesMetadata(payload Payloads, extra http.Header) error
 	SubmitMetadata(payload Payloads, extra http.Header) error
 	SubmitProcessChecks(payload Payloads, extra http.Header) (chan Response, error)
 	SubmitProcessDiscoveryChecks(payload Payloads, extra http.Header) (chan Response, error)
@@ -76,6 +78,10 @@ type Forwarder interface {
 	SubmitConnectionChecks(payload Payloads, extra http.Header) (chan Response, error)
 	SubmitOrchestratorChecks(payload Payloads, extra http.Header, payloadType int) (chan Response, error)
 	SubmitContainerLifecycleEvents(payload Payloads, extra http.Header) error
+	SubmitRemoteWrite(payload Payloads, extra http.Header) error
+	SubmitV1SeriesToDomain(domain string, payload Payloads, extra http.Header) error
+	SubmitSeriesToDomain(domain string, payload Payloads, extra http.Header) error
+	SubmitSketchSeriesToDomain(domain string, payload Payloads, extra http.Header) error
 }
 
 // Compile-time check to ensure that DefaultForwarder implements the Forwarder interface
@@ -105,6 +111,7 @@ type Options struct {
 	DomainResolvers                map[string]resolver.DomainResolver
 	ConnectionResetInterval        time.Duration
 	CompletionHandler              transaction.HTTPCompletionHandler
+	RoutingRules                   []config.ForwarderRoutingRule
 }
 
 // SetFeature sets forwarder features in a feature set
@@ -134,7 +141,9 @@ func NewOptions(keysPerDomain map[string][]string) *Options {
 			vectorMetricsURL,
 		)
 	}
-	return NewOptionsWithResolvers(resolvers)
+	options := NewOptionsWithResolvers(resolvers)
+	options.RoutingRules, _ = config.GetForwarderRoutingRules()
+	return options
 }
 
 // NewOptionsWithResolvers creates new Options with default values
@@ -204,6 +213,11 @@ type DefaultForwarder struct {
 
 	completionHandler transaction.HTTPCompletionHandler
 
+	// routing rules by domain, the domains without rule receive all the payloads
+	routingRules map[string]*routingRule
+	// domains with metric prefixes, by domain in the routing rules
+	routedDomains map[string]string
+
 	agentName                       string
 	queueDurationCapacity           *retry.QueueDurationCapacity
 	retryQueueDurationCapacityMutex sync.Mutex
@@ -224,10 +238,13 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 		},
 		completionHandler: options.CompletionHandler,
 		agentName:         agentName,
+		routingRules:      map[string]*routingRule{},
+		routedDomains:     map[string]string{},
 	}
 	var optionalRemovalPolicy *retry.FileRemovalPolicy
 	storageMaxSize := config.Datadog.GetInt64("forwarder_storage_max_size_in_bytes")
 	var diskUsageLimit *retry.DiskUsageLimit
+	var storageEncoding *retry.StorageEncoding
 
 	// Disk Persistence is a core-only feature for now.
 	if storageMaxSize == 0 {
@@ -255,6 +272,13 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 		diskRatio := config.Datadog.GetFloat64("forwarder_storage_max_disk_ratio")
 		diskUsageLimit = retry.NewDiskUsageLimit(storagePath, filesystem.NewDisk(), storageMaxSize, diskRatio)
 
+		storageEncoding, err = newStorageEncoding()
+		if err != nil {
+			// Do not store the transactions unencrypted when an encryption was requested.
+			log.Errorf("Retry queue storage on disk disabled. Cannot initialize the encoding of the retry files: %v", err)
+			diskUsageLimit = nil
+		}
+
 	} else {
 		log.Infof("Retry queue storage on disk is disabled because the feature is unavailable for this process.")
 	}
@@ -264,7 +288,12 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 	transactionContainerSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: false}
 	var queueDiskSpaceUsedList []retry.QueueDiskSpaceUsed
 
+	routingRules := newRoutingRules(options.RoutingRules)
 	for domain, resolver := range options.DomainResolvers {
+		routingDomain := normalizeRoutingDomain(domain)
+		rule, hasRule := routingRules[routingDomain]
+		delete(routingRules, routingDomain)
+
 		domain, _ := config.AddAgentVersionToDomain(domain, "app")
 		resolver.SetBaseDomain(domain)
 		if resolver.GetAPIKeys() == nil || len(resolver.GetAPIKeys()) == 0 {
@@ -285,8 +314,15 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 				domainFolderPath,
 				diskUsageLimit,
 				transactionContainerSort,
-				resolver)
+				resolver,
+				storageEncoding)
 			f.domainResolvers[domain] = resolver
+			if hasRule {
+				f.routingRules[domain] = rule
+				if len(rule.metricPrefixes) > 0 {
+					f.routedDomains[routingDomain] = domain
+				}
+			}
 			queueDiskSpaceUsedList = append(queueDiskSpaceUsedList, transactionContainer)
 			fwd := newDomainForwarder(
 				domain,
@@ -302,6 +338,10 @@ func NewDefaultForwarder(options *Options) *DefaultForwarder {
 		}
 	}
 
+	for domain := range routingRules {
+		log.Warnf("The forwarder routing rule of %s is ignored: the domain is not configured", domain)
+	}
+
 	timeInterval := config.Datadog.GetInt("forwarder_retry_queue_capacity_time_interval_sec")
 	if f.agentName != "" {
 		f.queueDurationCapacity = retry.NewQueueDurationCapacity(
@@ -333,6 +373,21 @@ func getAgentName(options *Options) string {
 	return ""
 }
 
+// newStorageEncoding returns the encoding of the retry files. The encryption key is
+// read from `forwarder_storage_encryption_key`, which can be resolved by the secrets
+// backend, or else from the file `forwarder_storage_encryption_key_file`.
+func newStorageEncoding() (*retry.StorageEncoding, error) {
+	key := config.Datadog.GetString("forwarder_storage_encryption_key")
+	if keyFile := config.Datadog.GetString("forwarder_storage_encryption_key_file"); key == "" && keyFile != "" {
+		content, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the encryption key file: %v", err)
+		}
+		key = string(content)
+	}
+	return retry.NewStorageEncoding(key, config.Datadog.GetBool("forwarder_storage_compression"))
+}
+
 // Start initialize and runs the forwarder.
 func (f *DefaultForwarder) Start() error {
 	// Lock so we can't stop a Forwarder while is starting
@@ -424,11 +479,41 @@ func (f *DefaultForwarder) createHTTPTransactions(endpoint transaction.Endpoint,
 }
 
 func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, priority transaction.Priority, storableOnDisk bool) []*transaction.HTTPTransaction {
+	return f.createRoutedHTTPTransactions(routingPayloadTypes[endpoint.Name], endpoint, payloads, apiKeyInQueryString, extra, priority, storableOnDisk)
+}
+
+// createRoutedHTTPTransactions creates the transactions of the domains whose
+// routing rule allows the payload type.
+func (f *DefaultForwarder) createRoutedHTTPTransactions(payloadType string, endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, priority transaction.Priority, storableOnDisk bool) []*transaction.HTTPTransaction {
+	return f.createDomainsHTTPTransactions(func(domain string) bool {
+		return f.routingRules[domain].allows(payloadType)
+	}, endpoint, payloads, apiKeyInQueryString, extra, priority, storableOnDisk)
+}
+
+// createFilteredHTTPTransactions creates the transactions of a domain with metric
+// prefixes in its routing rule, for payloads built from the matching metrics only.
+func (f *DefaultForwarder) createFilteredHTTPTransactions(routingDomain string, endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header) ([]*transaction.HTTPTransaction, error) {
+	domain, found := f.routedDomains[normalizeRoutingDomain(routingDomain)]
+	if !found {
+		return nil, fmt.Errorf("no routing rule with metric prefixes for the domain %s", routingDomain)
+	}
+	if !f.routingRules[domain].allowsFiltered(routingPayloadTypes[endpoint.Name]) {
+		return nil, nil
+	}
+	return f.createDomainsHTTPTransactions(func(d string) bool {
+		return d == domain
+	}, endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityNormal, true), nil
+}
+
+func (f *DefaultForwarder) createDomainsHTTPTransactions(sendToDomain func(domain string) bool, endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header, priority transaction.Priority, storableOnDisk bool) []*transaction.HTTPTransaction {
 	transactions := make([]*transaction.HTTPTransaction, 0, len(payloads)*len(f.domainForwarders))
 	allowArbitraryTags := config.Datadog.GetBool("allow_arbitrary_tags")
 
 	for _, payload := range payloads {
 		for domain, dr := range f.domainResolvers {
+			if !sendToDomain(domain) {
+				continue
+			}
 			for _, apiKey := range dr.GetAPIKeys() {
 				t := transaction.NewHTTPTransaction()
 				t.Domain, _ = dr.Resolve(endpoint)
@@ -523,7 +608,7 @@ func (f *DefaultForwarder) SubmitHostMetadata(payload Payloads, extra http.Heade
 		func(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*transaction.HTTPTransaction {
 			// Host metadata contains the API KEY and should not be stored on disk.
 			storableOnDisk := false
-			return f.createAdvancedHTTPTransactions(endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityHigh, storableOnDisk)
+			return f.createRoutedHTTPTransactions(RoutingMetadata, endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityHigh, storableOnDisk)
 		})
 }
 
@@ -533,7 +618,15 @@ func (f *DefaultForwarder) SubmitAgentChecksMetadata(payload Payloads, extra htt
 		func(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*transaction.HTTPTransaction {
 			// Agentchecks metadata contains the API KEY and should not be stored on disk.
 			storableOnDisk := false
-			return f.createAdvancedHTTPTransactions(endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityNormal, storableOnDisk)
+			return f.createRoutedHTTPTransactions(RoutingMetadata, endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityNormal, storableOnDisk)
+		})
+}
+
+// SubmitProcessesMetadata will send a legacy processes metadata payload to Datadog backend.
+func (f *DefaultForwarder) SubmitProcessesMetadata(payload Payloads, extra http.Header) error {
+	return f.submitV1IntakeWithTransactionsFactory(payload, extra,
+		func(endpoint transaction.Endpoint, payloads Payloads, apiKeyInQueryString bool, extra http.Header) []*transaction.HTTPTransaction {
+			return f.createRoutedHTTPTransactions(RoutingMetadata, endpoint, payloads, apiKeyInQueryString, extra, transaction.TransactionPriorityNormal, true)
 		})
 }
 
@@ -556,6 +649,36 @@ func (f *DefaultForwarder) SubmitSeries(payload Payloads, extra http.Header) err
 	return f.sendHTTPTransactions(transactions)
 }
 
+// SubmitV1SeriesToDomain will send timeseries built from the metrics matching the
+// prefixes of the routing rule of a domain to the v1 endpoint of this domain.
+func (f *DefaultForwarder) SubmitV1SeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	transactions, err := f.createFilteredHTTPTransactions(domain, endpoints.V1SeriesEndpoint, payload, true, extra)
+	if err != nil {
+		return err
+	}
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSeriesToDomain will send timeseries built from the metrics matching the
+// prefixes of the routing rule of a domain to the v2 endpoint of this domain.
+func (f *DefaultForwarder) SubmitSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	transactions, err := f.createFilteredHTTPTransactions(domain, endpoints.SeriesEndpoint, payload, false, extra)
+	if err != nil {
+		return err
+	}
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSketchSeriesToDomain will send sketches built from the metrics matching
+// the prefixes of the routing rule of a domain to this domain.
+func (f *DefaultForwarder) SubmitSketchSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	transactions, err := f.createFilteredHTTPTransactions(domain, endpoints.SketchSeriesEndpoint, payload, false, extra)
+	if err != nil {
+		return err
+	}
+	return f.sendHTTPTransactions(transactions)
+}
+
 // SubmitV1CheckRuns will send service checks to v1 endpoint (this will be removed once
 // the backend handles v2 endpoints).
 func (f *DefaultForwarder) SubmitV1CheckRuns(payload Payloads, extra http.Header) error {
@@ -630,6 +753,12 @@ func (f *DefaultForwarder) SubmitContainerLifecycleEvents(payload Payloads, extr
 	return f.sendHTTPTransactions(transactions)
 }
 
+// SubmitRemoteWrite sends Prometheus remote write payloads
+func (f *DefaultForwarder) SubmitRemoteWrite(payload Payloads, extra http.Header) error {
+	transactions := f.createHTTPTransactions(endpoints.RemoteWriteEndpoint, payload, false, extra)
+	return f.sendHTTPTransactions(transactions)
+}
+
 func (f *DefaultForwarder) submitProcessLikePayload(ep transaction.Endpoint, payload Payloads, extra http.Header, retryable bool) (chan Response, error) {
 	transactions := f.createHTTPTransactions(ep, payload, false, extra)
 	results := make(chan Response, len(transactions))
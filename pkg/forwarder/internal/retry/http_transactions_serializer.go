@@ -36,10 +36,12 @@ type HTTPTransactionsSerializer struct {
 	apiKeyToPlaceholder *strings.Replacer
 	placeholderToAPIKey *strings.Replacer
 	resolver            resolver.DomainResolver
+	encoding            *StorageEncoding
 }
 
-// NewHTTPTransactionsSerializer creates a new instance of HTTPTransactionsSerializer
-func NewHTTPTransactionsSerializer(resolver resolver.DomainResolver) *HTTPTransactionsSerializer {
+// NewHTTPTransactionsSerializer creates a new instance of HTTPTransactionsSerializer.
+// The optional encoding is applied to the serialized transactions.
+func NewHTTPTransactionsSerializer(resolver resolver.DomainResolver, optionalEncoding *StorageEncoding) *HTTPTransactionsSerializer {
 	apiKeyToPlaceholder, placeholderToAPIKey := createReplacers(resolver.GetAPIKeys())
 
 	return &HTTPTransactionsSerializer{
@@ -49,6 +51,7 @@ func NewHTTPTransactionsSerializer(resolver resolver.DomainResolver) *HTTPTransa
 		apiKeyToPlaceholder: apiKeyToPlaceholder,
 		placeholderToAPIKey: placeholderToAPIKey,
 		resolver:            resolver,
+		encoding:            optionalEncoding,
 	}
 }
 
@@ -95,13 +98,20 @@ func (s *HTTPTransactionsSerializer) Add(transaction *transaction.HTTPTransactio
 func (s *HTTPTransactionsSerializer) GetBytesAndReset() ([]byte, error) {
 	out, err := proto.Marshal(&s.collection)
 	s.collection.Values = nil
-	return out, err
+	if err != nil {
+		return nil, err
+	}
+	return s.encoding.encode(out)
 }
 
 // Deserialize deserializes from bytes.
 func (s *HTTPTransactionsSerializer) Deserialize(bytes []byte) ([]transaction.Transaction, int, error) {
 	collection := HttpTransactionProtoCollection{}
 
+	bytes, err := s.encoding.decode(bytes)
+	if err != nil {
+		return nil, 0, err
+	}
 	if err := proto.Unmarshal(bytes, &collection); err != nil {
 		return nil, 0, err
 	}
@@ -6,6 +6,7 @@
 package retry
 
 import (
+	"encoding/base64"
 	"net/http"
 	"reflect"
 	"testing"
@@ -36,7 +37,7 @@ func runTestHTTPSerializeDeserializeWithResolver(t *testing.T, d string, r resol
 	a := assert.New(t)
 	tr := createHTTPTransactionTests(d)
 
-	serializer := NewHTTPTransactionsSerializer(r)
+	serializer := NewHTTPTransactionsSerializer(r, nil)
 
 	a.NoError(serializer.Add(tr))
 	bytes, err := serializer.GetBytesAndReset()
@@ -61,7 +62,7 @@ func runTestHTTPSerializeDeserializeWithResolver(t *testing.T, d string, r resol
 func TestPartialDeserialize(t *testing.T) {
 	a := assert.New(t)
 	initialTransaction := createHTTPTransactionTests(domain)
-	serializer := NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domain, nil))
+	serializer := NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domain, nil), nil)
 
 	a.NoError(serializer.Add(initialTransaction))
 	a.NoError(serializer.Add(initialTransaction))
@@ -83,7 +84,7 @@ func TestPartialDeserialize(t *testing.T) {
 func TestHTTPTransactionSerializerMissingAPIKey(t *testing.T) {
 	r := require.New(t)
 
-	serializer := NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domain, []string{apiKey1, apiKey2}))
+	serializer := NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domain, []string{apiKey1, apiKey2}), nil)
 
 	r.NoError(serializer.Add(createHTTPTransactionWithHeaderTests(http.Header{"Key": []string{apiKey1}}, domain)))
 	r.NoError(serializer.Add(createHTTPTransactionWithHeaderTests(http.Header{"Key": []string{apiKey2}}, domain)))
@@ -94,12 +95,112 @@ func TestHTTPTransactionSerializerMissingAPIKey(t *testing.T) {
 	r.NoError(err)
 	r.Equal(0, errorCount)
 
-	serializerMissingAPIKey := NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domain, []string{apiKey1}))
+	serializerMissingAPIKey := NewHTTPTransactionsSerializer(resolver.NewSingleDomainResolver(domain, []string{apiKey1}), nil)
 	_, errorCount, err = serializerMissingAPIKey.Deserialize(bytes)
 	r.NoError(err)
 	r.Equal(1, errorCount)
 }
 
+func TestHTTPSerializeDeserializeWithEncoding(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+	r := resolver.NewSingleDomainResolver(domain, []string{apiKey1, apiKey2})
+
+	for _, tc := range []struct {
+		name     string
+		key      string
+		compress bool
+	}{
+		{"compression", "", true},
+		{"encryption", key, false},
+		{"compression and encryption", key, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			encoding, err := NewStorageEncoding(tc.key, tc.compress)
+			a.NoError(err)
+			serializer := NewHTTPTransactionsSerializer(r, encoding)
+			tr := createHTTPTransactionTests(domain)
+
+			a.NoError(serializer.Add(tr))
+			bytes, err := serializer.GetBytesAndReset()
+			a.NoError(err)
+			if tc.key != "" {
+				a.NotContains(string(bytes), "value1")
+			}
+
+			transactions, errorCount, err := serializer.Deserialize(bytes)
+			a.NoError(err)
+			a.Equal(0, errorCount)
+			a.Len(transactions, 1)
+			assertTransactionEqual(a, tr, transactions[0].(*transaction.HTTPTransaction))
+		})
+	}
+}
+
+func TestHTTPDeserializeWithoutEncoding(t *testing.T) {
+	a := assert.New(t)
+	r := resolver.NewSingleDomainResolver(domain, []string{apiKey1, apiKey2})
+	tr := createHTTPTransactionTests(domain)
+
+	// Files written before the encoding was enabled
+	serializer := NewHTTPTransactionsSerializer(r, nil)
+	a.NoError(serializer.Add(tr))
+	bytes, err := serializer.GetBytesAndReset()
+	a.NoError(err)
+
+	encoding, err := NewStorageEncoding(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), true)
+	a.NoError(err)
+	transactions, errorCount, err := NewHTTPTransactionsSerializer(r, encoding).Deserialize(bytes)
+	a.NoError(err)
+	a.Equal(0, errorCount)
+	a.Len(transactions, 1)
+	assertTransactionEqual(a, tr, transactions[0].(*transaction.HTTPTransaction))
+
+	// Compressed files written before the encoding was disabled
+	encoding, err = NewStorageEncoding("", true)
+	a.NoError(err)
+	serializer = NewHTTPTransactionsSerializer(r, encoding)
+	a.NoError(serializer.Add(tr))
+	bytes, err = serializer.GetBytesAndReset()
+	a.NoError(err)
+
+	transactions, _, err = NewHTTPTransactionsSerializer(r, nil).Deserialize(bytes)
+	a.NoError(err)
+	a.Len(transactions, 1)
+}
+
+func TestHTTPDeserializeInvalidEncryptionKey(t *testing.T) {
+	a := assert.New(t)
+	r := resolver.NewSingleDomainResolver(domain, []string{apiKey1})
+
+	encoding, err := NewStorageEncoding(base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), false)
+	a.NoError(err)
+	serializer := NewHTTPTransactionsSerializer(r, encoding)
+	a.NoError(serializer.Add(createHTTPTransactionTests(domain)))
+	bytes, err := serializer.GetBytesAndReset()
+	a.NoError(err)
+
+	otherEncoding, err := NewStorageEncoding(base64.StdEncoding.EncodeToString([]byte("fedcba9876543210")), false)
+	a.NoError(err)
+	_, _, err = NewHTTPTransactionsSerializer(r, otherEncoding).Deserialize(bytes)
+	a.Error(err)
+
+	_, _, err = NewHTTPTransactionsSerializer(r, nil).Deserialize(bytes)
+	a.Error(err)
+
+	// The flags of the header are authenticated
+	bytes[2] |= storageEncodingCompressed
+	_, _, err = serializer.Deserialize(bytes)
+	a.Error(err)
+}
+
+func TestNewStorageEncodingInvalidKey(t *testing.T) {
+	_, err := NewStorageEncoding("not base64!", false)
+	assert.Error(t, err)
+	_, err = NewStorageEncoding(base64.StdEncoding.EncodeToString([]byte("short")), false)
+	assert.Error(t, err)
+}
+
 func TestHTTPTransactionFieldsCount(t *testing.T) {
 	tr := transaction.HTTPTransaction{}
 	transactionType := reflect.TypeOf(tr)
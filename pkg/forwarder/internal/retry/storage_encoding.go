@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// The encoded retry files start with a header made of `storageEncodingMarker`,
+// the version of the encoding and the flags describing how the content is encoded.
+// A protobuf message cannot start with 0xfe (field 31 with the invalid wire type 6),
+// so the files written without encoding are still read as they are.
+const (
+	storageEncodingMarker     = 0xfe
+	storageEncodingVersion    = 1
+	storageEncodingHeaderSize = 3
+
+	storageEncodingCompressed = 1 << 0
+	storageEncodingEncrypted  = 1 << 1
+)
+
+// StorageEncoding compresses and encrypts the transactions stored on the disk.
+// A nil StorageEncoding stores the transactions as they are but can still read
+// the compressed files.
+type StorageEncoding struct {
+	compress bool
+	aead     cipher.AEAD
+}
+
+// NewStorageEncoding creates a new instance of StorageEncoding. When key is not
+// empty, it must be a base64 encoded AES key of 16, 24 or 32 bytes and the
+// content is encrypted with AES-GCM.
+func NewStorageEncoding(key string, compress bool) (*StorageEncoding, error) {
+	e := &StorageEncoding{compress: compress}
+
+	key = strings.TrimSpace(key)
+	if key != "" {
+		rawKey, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("the encryption key is not valid base64: %v", err)
+		}
+		block, err := aes.NewCipher(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key: %v", err)
+		}
+		if e.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// encode returns the encoded content.
+func (e *StorageEncoding) encode(content []byte) ([]byte, error) {
+	if e == nil || (!e.compress && e.aead == nil) {
+		return content, nil
+	}
+
+	header := []byte{storageEncodingMarker, storageEncodingVersion, 0}
+	if e.compress {
+		header[2] |= storageEncodingCompressed
+
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		content = buf.Bytes()
+	}
+
+	if e.aead == nil {
+		return append(header, content...), nil
+	}
+
+	header[2] |= storageEncodingEncrypted
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	out := append(header, nonce...)
+	// The header is authenticated so its flags cannot be altered.
+	return e.aead.Seal(out, nonce, content, header), nil
+}
+
+// decode returns the decoded content. The content without header is returned as it is.
+func (e *StorageEncoding) decode(content []byte) ([]byte, error) {
+	if len(content) == 0 || content[0] != storageEncodingMarker {
+		return content, nil
+	}
+	if len(content) < storageEncodingHeaderSize {
+		return nil, errors.New("truncated header")
+	}
+	if version := content[1]; version != storageEncodingVersion {
+		return nil, fmt.Errorf("unsupported encoding version %v", version)
+	}
+
+	header := content[:storageEncodingHeaderSize]
+	flags := header[2]
+	content = content[storageEncodingHeaderSize:]
+
+	if flags&storageEncodingEncrypted != 0 {
+		if e == nil || e.aead == nil {
+			return nil, errors.New("the content is encrypted but no encryption key is configured")
+		}
+		nonceSize := e.aead.NonceSize()
+		if len(content) < nonceSize {
+			return nil, errors.New("truncated content")
+		}
+		var err error
+		content, err = e.aead.Open(nil, content[:nonceSize], content[nonceSize:], header)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decrypt the content: %v", err)
+		}
+	}
+
+	if flags&storageEncodingCompressed != 0 {
+		r, err := zlib.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if content, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
@@ -47,12 +47,13 @@ func BuildTransactionRetryQueue(
 	optionalDomainFolderPath string,
 	optionalDiskUsageLimit *DiskUsageLimit,
 	dropPrioritySorter TransactionPrioritySorter,
-	resolver resolver.DomainResolver) *TransactionRetryQueue {
+	resolver resolver.DomainResolver,
+	optionalStorageEncoding *StorageEncoding) *TransactionRetryQueue {
 	var storage DiskTransactionSerializer
 	var err error
 
 	if optionalDomainFolderPath != "" && optionalDiskUsageLimit != nil {
-		serializer := NewHTTPTransactionsSerializer(resolver)
+		serializer := NewHTTPTransactionsSerializer(resolver, optionalStorageEncoding)
 		storage, err = newOnDiskRetryQueue(serializer, optionalDomainFolderPath, optionalDiskUsageLimit, newOnDiskRetryQueueTelemetry(resolver.GetBaseDomain()))
 
 		// If the storage on disk cannot be used, log the error and continue.
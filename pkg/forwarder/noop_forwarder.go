@@ -39,6 +39,11 @@ func (f NoopForwarder) SubmitAgentChecksMetadata(payload Payloads, extra http.He
 	return nil
 }
 
+// SubmitProcessesMetadata does nothing.
+func (f NoopForwarder) SubmitProcessesMetadata(payload Payloads, extra http.Header) error {
+	return nil
+}
+
 // SubmitMetadata does nothing.
 func (f NoopForwarder) SubmitMetadata(payload Payloads, extra http.Header) error { return nil }
 
@@ -81,3 +86,23 @@ func (f NoopForwarder) SubmitOrchestratorChecks(payload Payloads, extra http.Hea
 func (f NoopForwarder) SubmitContainerLifecycleEvents(payload Payloads, extra http.Header) error {
 	return nil
 }
+
+// SubmitRemoteWrite does nothing.
+func (f NoopForwarder) SubmitRemoteWrite(payload Payloads, extra http.Header) error {
+	return nil
+}
+
+// SubmitV1SeriesToDomain does nothing.
+func (f NoopForwarder) SubmitV1SeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	return nil
+}
+
+// SubmitSeriesToDomain does nothing.
+func (f NoopForwarder) SubmitSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	return nil
+}
+
+// SubmitSketchSeriesToDomain does nothing.
+func (f NoopForwarder) SubmitSketchSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	return nil
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Payload types of the routing rules
+const (
+	RoutingSeries    = "series"
+	RoutingSketches  = "sketches"
+	RoutingCheckRuns = "check_runs"
+	RoutingEvents    = "events"
+	RoutingMetadata  = "metadata"
+	RoutingProcesses = "processes"
+)
+
+// routingPayloadTypes maps the endpoint names to their payload type. The v1
+// intake endpoint is used by the events and by the host, agent checks and
+// processes metadata, the latter are submitted with the metadata type.
+var routingPayloadTypes = map[string]string{
+	endpoints.V1SeriesEndpoint.Name:         RoutingSeries,
+	endpoints.SeriesEndpoint.Name:           RoutingSeries,
+	endpoints.V1SketchSeriesEndpoint.Name:   RoutingSketches,
+	endpoints.SketchSeriesEndpoint.Name:     RoutingSketches,
+	endpoints.V1CheckRunsEndpoint.Name:      RoutingCheckRuns,
+	endpoints.ServiceChecksEndpoint.Name:    RoutingCheckRuns,
+	endpoints.V1IntakeEndpoint.Name:         RoutingEvents,
+	endpoints.EventsEndpoint.Name:           RoutingEvents,
+	endpoints.V1MetadataEndpoint.Name:       RoutingMetadata,
+	endpoints.HostMetadataEndpoint.Name:     RoutingMetadata,
+	endpoints.ProcessesEndpoint.Name:        RoutingProcesses,
+	endpoints.ProcessDiscoveryEndpoint.Name: RoutingProcesses,
+	endpoints.RtProcessesEndpoint.Name:      RoutingProcesses,
+	endpoints.ContainerEndpoint.Name:        RoutingProcesses,
+	endpoints.RtContainerEndpoint.Name:      RoutingProcesses,
+	endpoints.ConnectionsEndpoint.Name:      RoutingProcesses,
+}
+
+// routingRule restricts the payloads sent to a domain. The series and the
+// sketches of a domain with metric prefixes are not sent with the payloads of
+// the other domains: they are built from the matching metrics only by the
+// serializer and submitted to the domain alone.
+type routingRule struct {
+	payloadTypes   map[string]struct{} // nil if all the payload types are sent
+	metricPrefixes []string
+}
+
+// denyAllRoutingRule is used for the domains with an invalid rule, so that
+// they don't receive the payloads the rule was meant to keep from them.
+var denyAllRoutingRule = &routingRule{payloadTypes: map[string]struct{}{}}
+
+func newRoutingRule(configRule config.ForwarderRoutingRule) (*routingRule, error) {
+	rule := &routingRule{metricPrefixes: configRule.MetricPrefixes}
+
+	if len(configRule.PayloadTypes) > 0 {
+		rule.payloadTypes = make(map[string]struct{}, len(configRule.PayloadTypes))
+		for _, payloadType := range configRule.PayloadTypes {
+			switch payloadType {
+			case RoutingSeries, RoutingSketches, RoutingCheckRuns, RoutingEvents, RoutingMetadata, RoutingProcesses:
+				rule.payloadTypes[payloadType] = struct{}{}
+			default:
+				return nil, fmt.Errorf("unknown payload type %q", payloadType)
+			}
+		}
+	}
+
+	if len(rule.metricPrefixes) > 0 && !rule.sendsType(RoutingSeries) && !rule.sendsType(RoutingSketches) {
+		return nil, fmt.Errorf("metric_prefixes are set but neither %s nor %s are sent", RoutingSeries, RoutingSketches)
+	}
+	return rule, nil
+}
+
+// newRoutingRules returns the routing rules by domain. The domain of an invalid
+// rule receives no payload.
+func newRoutingRules(configRules []config.ForwarderRoutingRule) map[string]*routingRule {
+	rules := make(map[string]*routingRule, len(configRules))
+	for _, configRule := range configRules {
+		domain := normalizeRoutingDomain(configRule.Domain)
+		if domain == "" {
+			log.Errorf("Invalid forwarder routing rule: the domain is required")
+			continue
+		}
+		if _, found := rules[domain]; found {
+			log.Errorf("Several forwarder routing rules are set for %s, no payload will be sent to it", configRule.Domain)
+			rules[domain] = denyAllRoutingRule
+			continue
+		}
+
+		rule, err := newRoutingRule(configRule)
+		if err != nil {
+			log.Errorf("Invalid forwarder routing rule for %s, no payload will be sent to it: %s", configRule.Domain, err)
+			rule = denyAllRoutingRule
+		}
+		rules[domain] = rule
+	}
+	return rules
+}
+
+func normalizeRoutingDomain(domain string) string {
+	return strings.TrimRight(domain, "/")
+}
+
+// sendsType returns true if the payloads of this type are sent to the domain,
+// filtered or not.
+func (r *routingRule) sendsType(payloadType string) bool {
+	if r.payloadTypes == nil {
+		return true
+	}
+	_, found := r.payloadTypes[payloadType]
+	return found
+}
+
+// allows returns true if the payloads of this type submitted to all the domains
+// are sent to the domain of the rule. A nil rule allows all the payloads.
+func (r *routingRule) allows(payloadType string) bool {
+	if r == nil {
+		return true
+	}
+	if len(r.metricPrefixes) > 0 && (payloadType == RoutingSeries || payloadType == RoutingSketches) {
+		return false
+	}
+	return r.sendsType(payloadType)
+}
+
+// allowsFiltered returns true if the payloads of this type built from the
+// metrics matching the prefixes of the rule are sent to its domain.
+func (r *routingRule) allowsFiltered(payloadType string) bool {
+	return r != nil && len(r.metricPrefixes) > 0 && r.sendsType(payloadType)
+}
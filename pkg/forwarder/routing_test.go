@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package forwarder
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/endpoints"
+	"github.com/DataDog/datadog-agent/pkg/forwarder/transaction"
+)
+
+func newRoutedForwarder(rules []config.ForwarderRoutingRule) *DefaultForwarder {
+	options := NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysWithMultipleDomains))
+	options.RoutingRules = rules
+	return NewDefaultForwarder(options)
+}
+
+func transactionDomains(transactions []*transaction.HTTPTransaction) []string {
+	domains := []string{}
+	for _, t := range transactions {
+		domains = append(domains, t.Domain)
+	}
+	return domains
+}
+
+func TestNewRoutingRule(t *testing.T) {
+	rule, err := newRoutingRule(config.ForwarderRoutingRule{Domain: "datadog.bar"})
+	require.NoError(t, err)
+	assert.True(t, rule.allows(RoutingSeries))
+	assert.True(t, rule.allows(RoutingProcesses))
+	assert.False(t, rule.allowsFiltered(RoutingSeries))
+
+	rule, err = newRoutingRule(config.ForwarderRoutingRule{Domain: "datadog.bar", PayloadTypes: []string{RoutingSeries, RoutingEvents}})
+	require.NoError(t, err)
+	assert.True(t, rule.allows(RoutingSeries))
+	assert.True(t, rule.allows(RoutingEvents))
+	assert.False(t, rule.allows(RoutingSketches))
+	assert.False(t, rule.allows(""))
+
+	rule, err = newRoutingRule(config.ForwarderRoutingRule{Domain: "datadog.bar", PayloadTypes: []string{RoutingSeries, RoutingMetadata}, MetricPrefixes: []string{"prod."}})
+	require.NoError(t, err)
+	assert.False(t, rule.allows(RoutingSeries))
+	assert.True(t, rule.allowsFiltered(RoutingSeries))
+	assert.False(t, rule.allowsFiltered(RoutingSketches))
+	assert.True(t, rule.allows(RoutingMetadata))
+
+	_, err = newRoutingRule(config.ForwarderRoutingRule{Domain: "datadog.bar", PayloadTypes: []string{"unknown"}})
+	assert.Error(t, err)
+	_, err = newRoutingRule(config.ForwarderRoutingRule{Domain: "datadog.bar", PayloadTypes: []string{RoutingEvents}, MetricPrefixes: []string{"prod."}})
+	assert.Error(t, err)
+
+	// a nil rule allows everything
+	var nilRule *routingRule
+	assert.True(t, nilRule.allows(RoutingSeries))
+	assert.False(t, nilRule.allowsFiltered(RoutingSeries))
+}
+
+func TestNewRoutingRulesInvalid(t *testing.T) {
+	rules := newRoutingRules([]config.ForwarderRoutingRule{
+		{Domain: "datadog.bar", PayloadTypes: []string{"unknown"}},
+		{Domain: "datadog.foo/", PayloadTypes: []string{RoutingSeries}},
+		{Domain: "datadog.foo", PayloadTypes: []string{RoutingEvents}},
+		{PayloadTypes: []string{RoutingEvents}},
+	})
+	require.Len(t, rules, 2)
+	// the domains with an invalid or several rules receive nothing
+	for _, payloadType := range []string{RoutingSeries, RoutingEvents, RoutingMetadata} {
+		assert.False(t, rules["datadog.bar"].allows(payloadType))
+		assert.False(t, rules["datadog.foo"].allows(payloadType))
+	}
+}
+
+func TestCreateHTTPTransactionsWithRoutingRules(t *testing.T) {
+	forwarder := newRoutedForwarder([]config.ForwarderRoutingRule{
+		{Domain: "datadog.bar", PayloadTypes: []string{RoutingSeries, RoutingMetadata}},
+	})
+	p := []byte("A payload")
+	payloads := Payloads{&p}
+
+	transactions := forwarder.createHTTPTransactions(endpoints.SeriesEndpoint, payloads, false, make(http.Header))
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain, "datadog.bar"}, transactionDomains(transactions))
+
+	transactions = forwarder.createHTTPTransactions(endpoints.V1CheckRunsEndpoint, payloads, true, make(http.Header))
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain}, transactionDomains(transactions))
+
+	// the v1 intake endpoint is used by the events and by the metadata
+	transactions = forwarder.createHTTPTransactions(endpoints.V1IntakeEndpoint, payloads, true, make(http.Header))
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain}, transactionDomains(transactions))
+	transactions = forwarder.createRoutedHTTPTransactions(RoutingMetadata, endpoints.V1IntakeEndpoint, payloads, true, make(http.Header), transaction.TransactionPriorityHigh, false)
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain, "datadog.bar"}, transactionDomains(transactions))
+}
+
+func TestCreateHTTPTransactionsWithMetricPrefixes(t *testing.T) {
+	forwarder := newRoutedForwarder([]config.ForwarderRoutingRule{
+		{Domain: "datadog.bar", MetricPrefixes: []string{"prod."}},
+	})
+	p := []byte("A payload")
+	payloads := Payloads{&p}
+
+	// the unfiltered series and sketches are not sent to the domain
+	transactions := forwarder.createHTTPTransactions(endpoints.SeriesEndpoint, payloads, false, make(http.Header))
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain}, transactionDomains(transactions))
+	transactions = forwarder.createHTTPTransactions(endpoints.SketchSeriesEndpoint, payloads, false, make(http.Header))
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain}, transactionDomains(transactions))
+	transactions = forwarder.createHTTPTransactions(endpoints.V1CheckRunsEndpoint, payloads, true, make(http.Header))
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain, "datadog.bar"}, transactionDomains(transactions))
+
+	// the filtered ones are only sent to the domain
+	transactions, err := forwarder.createFilteredHTTPTransactions("datadog.bar", endpoints.V1SeriesEndpoint, payloads, true, make(http.Header))
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, "datadog.bar", transactions[0].Domain)
+	assert.Equal(t, "/api/v1/series?api_key=api-key-3", transactions[0].Endpoint.Route)
+
+	_, err = forwarder.createFilteredHTTPTransactions(testDomain, endpoints.SeriesEndpoint, payloads, false, make(http.Header))
+	assert.Error(t, err)
+}
+
+func TestCreateHTTPTransactionsWithRoutingRuleOfVersionedDomain(t *testing.T) {
+	options := NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysWithMultipleDomains))
+	options.RoutingRules = []config.ForwarderRoutingRule{{Domain: testDomain + "/", PayloadTypes: []string{RoutingEvents}}}
+	forwarder := NewDefaultForwarder(options)
+	p := []byte("A payload")
+
+	transactions := forwarder.createHTTPTransactions(endpoints.SeriesEndpoint, Payloads{&p}, false, make(http.Header))
+	assert.Equal(t, []string{"datadog.bar"}, transactionDomains(transactions))
+}
+
+func TestSubmitV1IntakeWithRoutingRules(t *testing.T) {
+	forwarder := newRoutedForwarder([]config.ForwarderRoutingRule{
+		{Domain: "datadog.bar", PayloadTypes: []string{RoutingMetadata}},
+	})
+	forwarder.Start()
+	defer forwarder.Stop()
+
+	// Overwrite the domainForwarders input channels to collect the transactions.
+	inputQueues := map[string]chan transaction.Transaction{}
+	for domain, df := range forwarder.domainForwarders {
+		inputQueue := make(chan transaction.Transaction, 10)
+		bk := df.highPrio
+		df.highPrio = inputQueue
+		defer func(df *domainForwarder) { df.highPrio = bk }(df)
+		inputQueues[domain] = inputQueue
+	}
+	p := []byte("A payload")
+
+	// the events are not sent to the domain
+	assert.Nil(t, forwarder.SubmitV1Intake(Payloads{&p}, make(http.Header)))
+	assert.Len(t, inputQueues[testVersionDomain], 2)
+	assert.Len(t, inputQueues["datadog.bar"], 0)
+
+	// the resources payload of the processes metadata is
+	assert.Nil(t, forwarder.SubmitProcessesMetadata(Payloads{&p}, make(http.Header)))
+	assert.Len(t, inputQueues[testVersionDomain], 4)
+	require.Len(t, inputQueues["datadog.bar"], 1)
+	tr := (<-inputQueues["datadog.bar"]).(*transaction.HTTPTransaction)
+	assert.Equal(t, endpoints.V1IntakeEndpoint.Name, tr.Endpoint.Name)
+	assert.Equal(t, "application/json", tr.Headers.Get("Content-Type"))
+}
@@ -112,6 +112,11 @@ func (f *SyncForwarder) SubmitAgentChecksMetadata(payload Payloads, extra http.H
 	return f.SubmitV1Intake(payload, extra)
 }
 
+// SubmitProcessesMetadata will send a legacy processes metadata payload to Datadog backend.
+func (f *SyncForwarder) SubmitProcessesMetadata(payload Payloads, extra http.Header) error {
+	return f.SubmitV1Intake(payload, extra)
+}
+
 // SubmitProcessChecks sends process checks
 func (f *SyncForwarder) SubmitProcessChecks(payload Payloads, extra http.Header) (chan Response, error) {
 	return f.defaultForwarder.submitProcessLikePayload(endpoints.ProcessesEndpoint, payload, extra, true)
@@ -151,3 +156,39 @@ func (f *SyncForwarder) SubmitOrchestratorChecks(payload Payloads, extra http.He
 func (f *SyncForwarder) SubmitContainerLifecycleEvents(payload Payloads, extra http.Header) error {
 	return f.defaultForwarder.SubmitContainerLifecycleEvents(payload, extra)
 }
+
+// SubmitRemoteWrite sends Prometheus remote write payloads
+func (f *SyncForwarder) SubmitRemoteWrite(payload Payloads, extra http.Header) error {
+	transactions := f.defaultForwarder.createHTTPTransactions(endpoints.RemoteWriteEndpoint, payload, false, extra)
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitV1SeriesToDomain will send timeseries built from the metrics matching the
+// prefixes of the routing rule of a domain to the v1 endpoint of this domain.
+func (f *SyncForwarder) SubmitV1SeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	transactions, err := f.defaultForwarder.createFilteredHTTPTransactions(domain, endpoints.V1SeriesEndpoint, payload, true, extra)
+	if err != nil {
+		return err
+	}
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSeriesToDomain will send timeseries built from the metrics matching the
+// prefixes of the routing rule of a domain to the v2 endpoint of this domain.
+func (f *SyncForwarder) SubmitSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	transactions, err := f.defaultForwarder.createFilteredHTTPTransactions(domain, endpoints.SeriesEndpoint, payload, false, extra)
+	if err != nil {
+		return err
+	}
+	return f.sendHTTPTransactions(transactions)
+}
+
+// SubmitSketchSeriesToDomain will send sketches built from the metrics matching
+// the prefixes of the routing rule of a domain to this domain.
+func (f *SyncForwarder) SubmitSketchSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	transactions, err := f.defaultForwarder.createFilteredHTTPTransactions(domain, endpoints.SketchSeriesEndpoint, payload, false, extra)
+	if err != nil {
+		return err
+	}
+	return f.sendHTTPTransactions(transactions)
+}
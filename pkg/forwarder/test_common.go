@@ -123,6 +123,11 @@ func (tf *MockedForwarder) SubmitAgentChecksMetadata(payload Payloads, extra htt
 	return tf.Called(payload, extra).Error(0)
 }
 
+// SubmitProcessesMetadata updates the internal mock struct
+func (tf *MockedForwarder) SubmitProcessesMetadata(payload Payloads, extra http.Header) error {
+	return tf.Called(payload, extra).Error(0)
+}
+
 // SubmitMetadata updates the internal mock struct
 func (tf *MockedForwarder) SubmitMetadata(payload Payloads, extra http.Header) error {
 	return tf.Called(payload, extra).Error(0)
@@ -167,3 +172,23 @@ func (tf *MockedForwarder) SubmitOrchestratorChecks(payload Payloads, extra http
 func (tf *MockedForwarder) SubmitContainerLifecycleEvents(payload Payloads, extra http.Header) error {
 	return tf.Called(payload, extra).Error(0)
 }
+
+// SubmitRemoteWrite mock
+func (tf *MockedForwarder) SubmitRemoteWrite(payload Payloads, extra http.Header) error {
+	return tf.Called(payload, extra).Error(0)
+}
+
+// SubmitV1SeriesToDomain mock
+func (tf *MockedForwarder) SubmitV1SeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	return tf.Called(domain, payload, extra).Error(0)
+}
+
+// SubmitSeriesToDomain mock
+func (tf *MockedForwarder) SubmitSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	return tf.Called(domain, payload, extra).Error(0)
+}
+
+// SubmitSketchSeriesToDomain mock
+func (tf *MockedForwarder) SubmitSketchSeriesToDomain(domain string, payload Payloads, extra http.Header) error {
+	return tf.Called(domain, payload, extra).Error(0)
+}
@@ -30,10 +30,17 @@ const defaultCleanupPeriod = 300 * time.Second
 // latest version of the API used by the auditor to retrieve the registry from disk.
 const registryAPIVersion = 2
 
+// CompletedOffset is the offset registered for an input which has been fully
+// collected and must not be read again, such as a compressed archive.
+const CompletedOffset = "completed"
+
 // Registry holds a list of offsets.
 type Registry interface {
 	GetOffset(identifier string) string
 	GetTailingMode(identifier string) string
+	GetFingerprint(identifier string) string
+	GetIdentifierByFingerprint(fingerprint string) string
+	KeepAlive(identifier string)
 }
 
 // A RegistryEntry represents an entry in the registry where we keep track
@@ -43,6 +50,9 @@ type RegistryEntry struct {
 	Offset             string
 	TailingMode        string
 	IngestionTimestamp int64
+	// Fingerprint identifies the content of the input the offset applies to,
+	// it is set for the files when fingerprinting is enabled.
+	Fingerprint string `json:",omitempty"`
 }
 
 // JSONRegistry represents the registry that will be written on disk
@@ -149,6 +159,45 @@ func (a *RegistryAuditor) GetTailingMode(identifier string) string {
 	return entry.TailingMode
 }
 
+// GetFingerprint returns the fingerprint of the input matching identifier,
+// returns an empty string if it does not exist.
+func (a *RegistryAuditor) GetFingerprint(identifier string) string {
+	r := a.readOnlyRegistryCopy()
+	entry, exists := r[identifier]
+	if !exists {
+		return ""
+	}
+	return entry.Fingerprint
+}
+
+// GetIdentifierByFingerprint returns the identifier of the most recently updated
+// entry with the given fingerprint, returns an empty string if there is none.
+func (a *RegistryAuditor) GetIdentifierByFingerprint(fingerprint string) string {
+	if fingerprint == "" {
+		return ""
+	}
+	a.registryMutex.Lock()
+	defer a.registryMutex.Unlock()
+	var identifier string
+	var lastUpdated time.Time
+	for id, entry := range a.registry {
+		if entry.Fingerprint == fingerprint && (identifier == "" || entry.LastUpdated.After(lastUpdated)) {
+			identifier, lastUpdated = id, entry.LastUpdated
+		}
+	}
+	return identifier
+}
+
+// KeepAlive prevents the entry matching identifier from expiring, this is used
+// for the inputs which are not updated anymore but still exist.
+func (a *RegistryAuditor) KeepAlive(identifier string) {
+	a.registryMutex.Lock()
+	defer a.registryMutex.Unlock()
+	if entry, exists := a.registry[identifier]; exists {
+		entry.LastUpdated = time.Now().UTC()
+	}
+}
+
 // run keeps up to date the registry depending on different events
 func (a *RegistryAuditor) run() {
 	cleanUpTicker := time.NewTicker(defaultCleanupPeriod)
@@ -171,7 +220,7 @@ func (a *RegistryAuditor) run() {
 			}
 			// update the registry with new entry
 			for _, msg := range payload.Messages {
-				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.IngestionTimestamp)
+				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Fingerprint, msg.IngestionTimestamp)
 			}
 		case <-cleanUpTicker.C:
 			// remove expired offsets from registry
@@ -224,7 +273,7 @@ func (a *RegistryAuditor) cleanupRegistry() {
 }
 
 // updateRegistry updates the registry entry matching identifier with new the offset and timestamp
-func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, ingestionTimestamp int64) {
+func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, fingerprint string, ingestionTimestamp int64) {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	if identifier == "" {
@@ -247,6 +296,7 @@ func (a *RegistryAuditor) updateRegistry(identifier string, offset string, taili
 		Offset:             offset,
 		TailingMode:        tailingMode,
 		IngestionTimestamp: ingestionTimestamp,
+		Fingerprint:        fingerprint,
 	}
 }
 
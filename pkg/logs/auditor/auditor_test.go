@@ -62,11 +62,11 @@ func (suite *AuditorTestSuite) TestAuditorStartStop() {
 func (suite *AuditorTestSuite) TestAuditorUpdatesRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.Equal(0, len(suite.a.registry))
-	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", "", 0)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("42", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("end", suite.a.registry[suite.source.Config.Path].TailingMode)
-	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", 1)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", "", 1)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("43", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("beginning", suite.a.registry[suite.source.Config.Path].TailingMode)
@@ -123,6 +123,38 @@ func (suite *AuditorTestSuite) TestAuditorCleansupRegistry() {
 	suite.Equal("43", suite.a.registry[otherpath].Offset)
 }
 
+func (suite *AuditorTestSuite) TestAuditorFindsIdentifierByFingerprint() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	suite.a.updateRegistry("file:/var/log/app.log", "42", "end", "abc", 0)
+	suite.a.updateRegistry("file:/var/log/other.log", "43", "end", "", 0)
+	suite.Equal("abc", suite.a.GetFingerprint("file:/var/log/app.log"))
+	suite.Equal("file:/var/log/app.log", suite.a.GetIdentifierByFingerprint("abc"))
+	suite.Equal("", suite.a.GetIdentifierByFingerprint("def"))
+	suite.Equal("", suite.a.GetIdentifierByFingerprint(""))
+
+	suite.a.registry["file:/var/log/app.log"].LastUpdated = time.Now().UTC().Add(-time.Minute)
+	suite.a.updateRegistry("file:/var/log/app.log.1", "44", "end", "abc", 0)
+	suite.Equal("file:/var/log/app.log.1", suite.a.GetIdentifierByFingerprint("abc"))
+
+	suite.a.flushRegistry()
+	suite.a.registry = suite.a.recoverRegistry()
+	suite.Equal("abc", suite.a.GetFingerprint("file:/var/log/app.log.1"))
+}
+
+func (suite *AuditorTestSuite) TestAuditorKeepsAliveEntries() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	suite.a.registry[suite.source.Config.Path] = &RegistryEntry{
+		LastUpdated: time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC),
+		Offset:      CompletedOffset,
+	}
+
+	suite.a.KeepAlive(suite.source.Config.Path)
+	suite.a.KeepAlive("otherpath")
+	suite.a.cleanupRegistry()
+	suite.Equal(1, len(suite.a.registry))
+	suite.Equal(CompletedOffset, suite.a.registry[suite.source.Config.Path].Offset)
+}
+
 func TestScannerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuditorTestSuite))
 }
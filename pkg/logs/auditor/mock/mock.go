@@ -9,15 +9,27 @@ package mock
 type Registry struct {
 	offset      string
 	tailingMode string
+	entries     map[string]entry
+}
+
+// entry is an offset set for a given identifier.
+type entry struct {
+	offset      string
+	fingerprint string
 }
 
 // NewRegistry returns a new registry.
 func NewRegistry() *Registry {
-	return &Registry{}
+	return &Registry{
+		entries: make(map[string]entry),
+	}
 }
 
-// GetOffset returns the offset.
+// GetOffset returns the offset set for the identifier, or the offset.
 func (r *Registry) GetOffset(identifier string) string {
+	if e, exists := r.entries[identifier]; exists {
+		return e.offset
+	}
 	return r.offset
 }
 
@@ -35,3 +47,26 @@ func (r *Registry) GetTailingMode(identifier string) string {
 func (r *Registry) SetTailingMode(tailingMode string) {
 	r.tailingMode = tailingMode
 }
+
+// SetEntry sets the offset and the fingerprint of an identifier.
+func (r *Registry) SetEntry(identifier string, offset string, fingerprint string) {
+	r.entries[identifier] = entry{offset: offset, fingerprint: fingerprint}
+}
+
+// GetFingerprint returns the fingerprint set for the identifier.
+func (r *Registry) GetFingerprint(identifier string) string {
+	return r.entries[identifier].fingerprint
+}
+
+// GetIdentifierByFingerprint returns an identifier set with the fingerprint.
+func (r *Registry) GetIdentifierByFingerprint(fingerprint string) string {
+	for identifier, e := range r.entries {
+		if fingerprint != "" && e.fingerprint == fingerprint {
+			return identifier
+		}
+	}
+	return ""
+}
+
+// KeepAlive does nothing.
+func (r *Registry) KeepAlive(identifier string) {}
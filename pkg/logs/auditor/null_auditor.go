@@ -32,6 +32,15 @@ func (a *NullAuditor) GetOffset(identifier string) string { return "" }
 // GetTailingMode returns an empty string.
 func (a *NullAuditor) GetTailingMode(identifier string) string { return "" }
 
+// GetFingerprint returns an empty string.
+func (a *NullAuditor) GetFingerprint(identifier string) string { return "" }
+
+// GetIdentifierByFingerprint returns an empty string.
+func (a *NullAuditor) GetIdentifierByFingerprint(fingerprint string) string { return "" }
+
+// KeepAlive does nothing
+func (a *NullAuditor) KeepAlive(identifier string) {}
+
 // Start starts the NullAuditor main loop.
 func (a *NullAuditor) Start() {
 	go a.run()
@@ -60,6 +60,7 @@ type Destination struct {
 	destinationsContext *client.DestinationsContext
 	protocol            config.IntakeProtocol
 	origin              config.IntakeOrigin
+	webhook             *webhookSettings // set when sending to a webhook endpoint instead of a Datadog intake
 
 	// Concurrency
 	climit chan struct{} // semaphore for limiting concurrent background sends
@@ -250,26 +251,37 @@ func (d *Destination) unconditionalSend(payload *message.Payload) (err error) {
 	if err != nil {
 		return err
 	}
+	body, encoding := payload.Encoded, payload.Encoding
+	if d.webhook != nil {
+		body, encoding, err = d.webhook.encode(payload)
+		if err != nil || body == nil {
+			return err
+		}
+	}
 	metrics.BytesSent.Add(int64(payload.UnencodedSize))
-	metrics.EncodedBytesSent.Add(int64(len(payload.Encoded)))
+	metrics.EncodedBytesSent.Add(int64(len(body)))
 
-	req, err := http.NewRequest("POST", d.url, bytes.NewReader(payload.Encoded))
+	req, err := http.NewRequest("POST", d.url, bytes.NewReader(body))
 	if err != nil {
 		// the request could not be built,
 		// this can happen when the method or the url are valid.
 		return err
 	}
-	req.Header.Set("DD-API-KEY", d.apiKey)
 	req.Header.Set("Content-Type", d.contentType)
-	if payload.Encoding != "" {
-		req.Header.Set("Content-Encoding", payload.Encoding)
-	}
-	if d.protocol != "" {
-		req.Header.Set("DD-PROTOCOL", string(d.protocol))
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
 	}
-	if d.origin != "" {
-		req.Header.Set("DD-EVP-ORIGIN", string(d.origin))
-		req.Header.Set("DD-EVP-ORIGIN-VERSION", version.AgentVersion)
+	if d.webhook != nil {
+		d.webhook.setHeaders(req)
+	} else {
+		req.Header.Set("DD-API-KEY", d.apiKey)
+		if d.protocol != "" {
+			req.Header.Set("DD-PROTOCOL", string(d.protocol))
+		}
+		if d.origin != "" {
+			req.Header.Set("DD-EVP-ORIGIN", string(d.origin))
+			req.Header.Set("DD-EVP-ORIGIN-VERSION", version.AgentVersion)
+		}
 	}
 	req = req.WithContext(ctx)
 
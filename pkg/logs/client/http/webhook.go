@@ -0,0 +1,278 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// NDJSONContentType is the content type of the newline delimited JSON payloads.
+const NDJSONContentType = "application/x-ndjson"
+
+// webhookRecord is a log as encoded for the Datadog intake by the processor.
+type webhookRecord struct {
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+	Hostname  string `json:"hostname"`
+	Service   string `json:"service"`
+	Source    string `json:"ddsource"`
+	Tags      string `json:"ddtags"`
+}
+
+// webhookEncoder re-encodes the logs of a payload in the format of a webhook endpoint.
+type webhookEncoder interface {
+	encode(records []webhookRecord) ([]byte, error)
+	contentType() string
+}
+
+// NewWebhookDestination returns a new Destination sending the logs to a webhook endpoint,
+// the payloads are never retried and do not update the auditor.
+func NewWebhookDestination(webhook config.WebhookEndpoint,
+	destinationsContext *client.DestinationsContext,
+	maxConcurrentBackgroundSends int,
+	telemetryName string) *Destination {
+
+	var host string
+	if u, err := url.Parse(webhook.URL); err == nil {
+		host = u.Host
+	}
+	encoder := newWebhookEncoder(webhook)
+	d := newDestination(config.Endpoint{Host: host, ConnectionResetInterval: webhook.ConnectionResetInterval},
+		encoder.contentType(),
+		destinationsContext,
+		time.Second*10,
+		maxConcurrentBackgroundSends,
+		false,
+		telemetryName)
+	d.url = webhook.URL
+	d.webhook = &webhookSettings{
+		encoder:        encoder,
+		headers:        webhook.Headers,
+		username:       webhook.Username,
+		password:       webhook.Password,
+		bearerToken:    webhook.BearerToken,
+		useCompression: webhook.UseCompression,
+	}
+	return d
+}
+
+// webhookSettings holds the parameters of a destination sending to a webhook endpoint.
+type webhookSettings struct {
+	encoder        webhookEncoder
+	headers        map[string]string
+	username       string
+	password       string
+	bearerToken    string
+	useCompression bool
+}
+
+// encode re-encodes the logs of the payload, it returns a nil body when there is no log to send.
+func (w *webhookSettings) encode(payload *message.Payload) (body []byte, encoding string, err error) {
+	records := make([]webhookRecord, 0, len(payload.Messages))
+	for _, msg := range payload.Messages {
+		var record webhookRecord
+		if err := json.Unmarshal(msg.Content, &record); err != nil {
+			log.Debugf("Could not decode log for webhook: %v", err)
+			continue
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, "", nil
+	}
+	body, err = w.encoder.encode(records)
+	if err != nil || !w.useCompression {
+		return body, "", err
+	}
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "gzip", nil
+}
+
+// setHeaders sets the authentication and custom headers of the request.
+func (w *webhookSettings) setHeaders(req *http.Request) {
+	if w.username != "" || w.password != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	if w.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.bearerToken)
+	}
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+}
+
+func newWebhookEncoder(webhook config.WebhookEndpoint) webhookEncoder {
+	switch webhook.Format {
+	case config.JSONArrayWebhookFormat:
+		return jsonArrayEncoder{}
+	case config.LokiWebhookFormat:
+		return lokiEncoder{}
+	case config.ElasticsearchWebhookFormat:
+		index := webhook.Index
+		if index == "" {
+			index = "logs"
+		}
+		return elasticsearchEncoder{index: index}
+	default:
+		return ndjsonEncoder{}
+	}
+}
+
+// ndjsonEncoder encodes one JSON object per line.
+type ndjsonEncoder struct{}
+
+func (ndjsonEncoder) encode(records []webhookRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (ndjsonEncoder) contentType() string {
+	return NDJSONContentType
+}
+
+// jsonArrayEncoder encodes a JSON array of objects.
+type jsonArrayEncoder struct{}
+
+func (jsonArrayEncoder) encode(records []webhookRecord) ([]byte, error) {
+	return json.Marshal(records)
+}
+
+func (jsonArrayEncoder) contentType() string {
+	return JSONContentType
+}
+
+// lokiEncoder encodes a Loki push request, the logs are grouped in streams
+// labelled with their service, source, host and level.
+type lokiEncoder struct{}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type lokiPushRequest struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+func (lokiEncoder) encode(records []webhookRecord) ([]byte, error) {
+	streams := make(map[string]*lokiStream)
+	var keys []string
+	for _, record := range records {
+		labels := map[string]string{
+			"service": record.Service,
+			"source":  record.Source,
+			"host":    record.Hostname,
+			"level":   record.Status,
+		}
+		for name, value := range labels {
+			if value == "" {
+				delete(labels, name)
+			}
+		}
+		key := strings.Join([]string{record.Service, record.Source, record.Hostname, record.Status}, "\x00")
+		stream, exists := streams[key]
+		if !exists {
+			stream = &lokiStream{Stream: labels}
+			streams[key] = stream
+			keys = append(keys, key)
+		}
+		line := record.Message
+		if record.Tags != "" {
+			line += " ddtags=" + strconv.Quote(record.Tags)
+		}
+		ts := strconv.FormatInt(record.Timestamp*int64(time.Millisecond), 10)
+		stream.Values = append(stream.Values, [2]string{ts, line})
+	}
+	sort.Strings(keys)
+	request := lokiPushRequest{Streams: make([]*lokiStream, 0, len(keys))}
+	for _, key := range keys {
+		request.Streams = append(request.Streams, streams[key])
+	}
+	return json.Marshal(request)
+}
+
+func (lokiEncoder) contentType() string {
+	return JSONContentType
+}
+
+// elasticsearchEncoder encodes an Elasticsearch bulk request indexing the logs in an index.
+type elasticsearchEncoder struct {
+	index string
+}
+
+type elasticsearchAction struct {
+	Index struct {
+		Index string `json:"_index"`
+	} `json:"index"`
+}
+
+type elasticsearchDocument struct {
+	Timestamp string   `json:"@timestamp"`
+	Message   string   `json:"message"`
+	Status    string   `json:"status"`
+	Hostname  string   `json:"hostname"`
+	Service   string   `json:"service"`
+	Source    string   `json:"ddsource"`
+	Tags      []string `json:"ddtags,omitempty"`
+}
+
+func (e elasticsearchEncoder) encode(records []webhookRecord) ([]byte, error) {
+	var action elasticsearchAction
+	action.Index.Index = e.index
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, record := range records {
+		document := elasticsearchDocument{
+			Timestamp: time.Unix(0, record.Timestamp*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano),
+			Message:   record.Message,
+			Status:    record.Status,
+			Hostname:  record.Hostname,
+			Service:   record.Service,
+			Source:    record.Source,
+		}
+		if record.Tags != "" {
+			document.Tags = strings.Split(record.Tags, ",")
+		}
+		if err := encoder.Encode(action); err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(document); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (elasticsearchEncoder) contentType() string {
+	return NDJSONContentType
+}
@@ -0,0 +1,164 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newWebhookPayload(t *testing.T, records ...webhookRecord) *message.Payload {
+	payload := &message.Payload{Encoded: []byte("datadog payload")}
+	for _, record := range records {
+		content, err := json.Marshal(record)
+		require.NoError(t, err)
+		payload.Messages = append(payload.Messages, message.NewMessage(content, nil, "", 0))
+	}
+	return payload
+}
+
+var testRecords = []webhookRecord{
+	{Message: "hello", Status: "info", Timestamp: 1500000000123, Hostname: "host", Service: "web", Source: "nginx", Tags: "env:prod,team:a"},
+	{Message: "world", Status: "error", Timestamp: 1500000000456, Hostname: "host", Service: "web", Source: "nginx"},
+	{Message: "again", Status: "info", Timestamp: 1500000000789, Hostname: "host", Service: "web", Source: "nginx"},
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	body, err := ndjsonEncoder{}.encode(testRecords[:2])
+	require.NoError(t, err)
+	assert.Equal(t, `{"message":"hello","status":"info","timestamp":1500000000123,"hostname":"host","service":"web","ddsource":"nginx","ddtags":"env:prod,team:a"}
+{"message":"world","status":"error","timestamp":1500000000456,"hostname":"host","service":"web","ddsource":"nginx","ddtags":""}
+`, string(body))
+}
+
+func TestJSONArrayEncoder(t *testing.T) {
+	body, err := jsonArrayEncoder{}.encode(testRecords[1:2])
+	require.NoError(t, err)
+	assert.Equal(t, `[{"message":"world","status":"error","timestamp":1500000000456,"hostname":"host","service":"web","ddsource":"nginx","ddtags":""}]`, string(body))
+}
+
+func TestLokiEncoder(t *testing.T) {
+	body, err := lokiEncoder{}.encode(testRecords)
+	require.NoError(t, err)
+
+	var request lokiPushRequest
+	require.NoError(t, json.Unmarshal(body, &request))
+	require.Len(t, request.Streams, 2)
+	assert.Equal(t, map[string]string{"service": "web", "source": "nginx", "host": "host", "level": "error"}, request.Streams[0].Stream)
+	assert.Equal(t, [][2]string{{"1500000000456000000", "world"}}, request.Streams[0].Values)
+	assert.Equal(t, map[string]string{"service": "web", "source": "nginx", "host": "host", "level": "info"}, request.Streams[1].Stream)
+	assert.Equal(t, [][2]string{
+		{"1500000000123000000", `hello ddtags="env:prod,team:a"`},
+		{"1500000000789000000", "again"},
+	}, request.Streams[1].Values)
+}
+
+func TestElasticsearchEncoder(t *testing.T) {
+	body, err := elasticsearchEncoder{index: "archive"}.encode(testRecords[:1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"index":{"_index":"archive"}}
+{"@timestamp":"2017-07-14T02:40:00.123Z","message":"hello","status":"info","hostname":"host","service":"web","ddsource":"nginx","ddtags":["env:prod","team:a"]}
+`, string(body))
+}
+
+func TestWebhookDestinationSend(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		requests <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	defer destinationsContext.Stop()
+
+	destination := NewWebhookDestination(config.WebhookEndpoint{
+		URL:            server.URL + "/ingest",
+		Format:         config.NDJSONWebhookFormat,
+		Headers:        map[string]string{"x-archive": "logs"},
+		BearerToken:    "secret",
+		UseCompression: true,
+	}, destinationsContext, 1, "")
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	destination.Start(input, output, nil)
+
+	payload := newWebhookPayload(t, testRecords[1])
+	input <- payload
+	assert.Equal(t, payload, <-output)
+
+	request := <-requests
+	assert.Equal(t, "/ingest", request.URL.Path)
+	assert.Equal(t, NDJSONContentType, request.Header.Get("Content-Type"))
+	assert.Equal(t, "gzip", request.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Bearer secret", request.Header.Get("Authorization"))
+	assert.Equal(t, "logs", request.Header.Get("X-Archive"))
+	assert.Empty(t, request.Header.Get("DD-API-KEY"))
+	assert.Equal(t, `{"message":"world","status":"error","timestamp":1500000000456,"hostname":"host","service":"web","ddsource":"nginx","ddtags":""}
+`, string(<-bodies))
+	close(input)
+}
+
+func TestWebhookDestinationBasicAuth(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+	}))
+	defer server.Close()
+
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	defer destinationsContext.Stop()
+
+	destination := NewWebhookDestination(config.WebhookEndpoint{
+		URL:      server.URL,
+		Format:   config.ElasticsearchWebhookFormat,
+		Username: "user",
+		Password: "pass",
+	}, destinationsContext, 1, "")
+
+	require.NoError(t, destination.unconditionalSend(newWebhookPayload(t, testRecords[0])))
+	request := <-requests
+	username, password, ok := request.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+	assert.Equal(t, NDJSONContentType, request.Header.Get("Content-Type"))
+	assert.Empty(t, request.Header.Get("Content-Encoding"))
+}
+
+func TestWebhookDestinationSkipsPayloadsWithoutLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Fail(t, "no request should be sent")
+	}))
+	defer server.Close()
+
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	defer destinationsContext.Stop()
+
+	destination := NewWebhookDestination(config.WebhookEndpoint{URL: server.URL}, destinationsContext, 1, "")
+	payload := &message.Payload{Messages: []*message.Message{message.NewMessage([]byte("not json"), nil, "", 0)}}
+	assert.NoError(t, destination.unconditionalSend(payload))
+}
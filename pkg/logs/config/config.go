@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -85,6 +86,58 @@ func GlobalProcessingRules() ([]*ProcessingRule, error) {
 	return rules, nil
 }
 
+// DedupConfig holds the settings of the deduplication of identical log lines.
+type DedupConfig struct {
+	// Window is the duration during which the identical lines of a source are collapsed,
+	// deduplication is disabled when it is zero.
+	Window time.Duration
+	// AfterMasking compares the lines once the mask_sequences rules have been applied.
+	AfterMasking bool
+}
+
+// GlobalDedupConfig returns the deduplication settings applied to all logs.
+func GlobalDedupConfig() DedupConfig {
+	window := coreConfig.Datadog.GetInt("logs_config.dedup_window")
+	if window < 0 {
+		log.Warnf("Invalid logs_config.dedup_window: %v should be >= 0, deduplication is disabled", window)
+		window = 0
+	}
+	return DedupConfig{
+		Window:       time.Duration(window) * time.Second,
+		AfterMasking: coreConfig.Datadog.GetBool("logs_config.dedup_after_masking"),
+	}
+}
+
+// DiskBufferConfig holds the settings of the buffer persisting the payloads on disk
+// before they are sent.
+type DiskBufferConfig struct {
+	// Path is the directory in which the payloads are stored.
+	Path string
+	// MaxSizeInBytes is the maximum disk space used by the payloads, the buffer
+	// is disabled when it is zero.
+	MaxSizeInBytes int64
+	// MaxDiskRatio is the maximum ratio of the disk capacity that can be used.
+	MaxDiskRatio float64
+}
+
+// GlobalDiskBufferConfig returns the settings of the disk buffer of the pipelines.
+func GlobalDiskBufferConfig() DiskBufferConfig {
+	path := coreConfig.Datadog.GetString("logs_config.storage_path")
+	if path == "" {
+		path = filepath.Join(coreConfig.Datadog.GetString("run_path"), "logs_to_send")
+	}
+	maxSize := coreConfig.Datadog.GetInt64("logs_config.storage_max_size_in_bytes")
+	if maxSize < 0 {
+		log.Warnf("Invalid logs_config.storage_max_size_in_bytes: %v should be >= 0, the disk buffer is disabled", maxSize)
+		maxSize = 0
+	}
+	return DiskBufferConfig{
+		Path:           path,
+		MaxSizeInBytes: maxSize,
+		MaxDiskRatio:   coreConfig.Datadog.GetFloat64("logs_config.storage_max_disk_ratio"),
+	}
+}
+
 // HasMultiLineRule returns true if the rule set contains a multi_line rule
 func HasMultiLineRule(rules []*ProcessingRule) bool {
 	for _, rule := range rules {
@@ -203,6 +256,7 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 	main := Endpoint{
 		APIKey:                  logsConfig.getLogsAPIKey(),
 		UseCompression:          logsConfig.useCompression(),
+		CompressionKind:         logsConfig.compressionKind(),
 		CompressionLevel:        logsConfig.compressionLevel(),
 		ConnectionResetInterval: logsConfig.connectionResetInterval(),
 		BackoffBase:             logsConfig.senderBackoffBase(),
@@ -269,7 +323,9 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 	for i := 0; i < len(additionals); i++ {
 		additionals[i].UseSSL = main.UseSSL
 		additionals[i].APIKey = coreConfig.SanitizeAPIKey(additionals[i].APIKey)
+		// the payloads are compressed once for all the endpoints
 		additionals[i].UseCompression = main.UseCompression
+		additionals[i].CompressionKind = main.CompressionKind
 		additionals[i].CompressionLevel = main.CompressionLevel
 		additionals[i].BackoffBase = main.BackoffBase
 		additionals[i].BackoffMax = main.BackoffMax
@@ -292,7 +348,15 @@ func BuildHTTPEndpointsWithConfig(logsConfig *LogsConfigKeys, endpointPrefix str
 	batchMaxSize := logsConfig.batchMaxSize()
 	batchMaxContentSize := logsConfig.batchMaxContentSize()
 
-	return NewEndpointsWithBatchSettings(main, additionals, false, true, batchWait, batchMaxConcurrentSend, batchMaxSize, batchMaxContentSize), nil
+	endpoints := NewEndpointsWithBatchSettings(main, additionals, false, true, batchWait, batchMaxConcurrentSend, batchMaxSize, batchMaxContentSize)
+
+	// webhooks are only supported with HTTP as they are sent the batches built for the Datadog intake
+	endpoints.Webhooks = logsConfig.getWebhookEndpoints()
+	for i := 0; i < len(endpoints.Webhooks); i++ {
+		endpoints.Webhooks[i].ConnectionResetInterval = main.ConnectionResetInterval
+	}
+
+	return endpoints, nil
 }
 
 // parseAddress returns the host and the port of the address.
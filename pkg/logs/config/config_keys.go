@@ -110,6 +110,17 @@ func (l *LogsConfigKeys) compressionLevel() int {
 	return l.getConfig().GetInt(l.getConfigKey("compression_level"))
 }
 
+func (l *LogsConfigKeys) compressionKind() string {
+	key := l.getConfigKey("compression_kind")
+	kind := l.getConfig().GetString(key)
+	switch kind {
+	case GzipCompressionKind, ZstdCompressionKind:
+		return kind
+	}
+	log.Warnf("Invalid %s: %v should be one of %s or %s, fallback on %s", key, kind, GzipCompressionKind, ZstdCompressionKind, GzipCompressionKind)
+	return GzipCompressionKind
+}
+
 func (l *LogsConfigKeys) useCompression() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_compression"))
 }
@@ -150,6 +161,42 @@ func (l *LogsConfigKeys) getAdditionalEndpoints() []Endpoint {
 	return endpoints
 }
 
+func (l *LogsConfigKeys) getWebhookEndpoints() []WebhookEndpoint {
+	var webhooks []WebhookEndpoint
+	var err error
+	configKey := l.getConfigKey("webhook_endpoints")
+	raw := l.getConfig().Get(configKey)
+	if raw == nil {
+		return webhooks
+	}
+	if s, ok := raw.(string); ok && s != "" {
+		err = json.Unmarshal([]byte(s), &webhooks)
+	} else {
+		err = l.getConfig().UnmarshalKey(configKey, &webhooks)
+	}
+	if err != nil {
+		log.Warnf("Could not parse webhook_endpoints for logs: %v", err)
+		return nil
+	}
+	valid := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.URL == "" {
+			log.Warnf("Ignoring webhook endpoint for logs without url")
+			continue
+		}
+		switch webhook.Format {
+		case "":
+			webhook.Format = NDJSONWebhookFormat
+		case NDJSONWebhookFormat, JSONArrayWebhookFormat, LokiWebhookFormat, ElasticsearchWebhookFormat:
+		default:
+			log.Warnf("Ignoring webhook endpoint for logs %s with invalid format %s", webhook.URL, webhook.Format)
+			continue
+		}
+		valid = append(valid, webhook)
+	}
+	return valid
+}
+
 func (l *LogsConfigKeys) expectedTagsDuration() time.Duration {
 	return l.getConfig().GetDuration(l.getConfigKey("expected_tags_duration"))
 }
@@ -154,6 +154,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsEnvVar() {
 		Port:             443,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    3,
 		BackoffBase:      1.0,
@@ -169,6 +170,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsEnvVar() {
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    3,
 		BackoffBase:      1.0,
@@ -183,6 +185,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsEnvVar() {
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    3,
 		BackoffBase:      1.0,
@@ -266,6 +269,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 		Port:             443,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -280,6 +284,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -293,6 +298,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -340,6 +346,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig2() {
 		Port:             443,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -357,6 +364,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig2() {
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -370,6 +378,7 @@ func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig2() {
 		Port:             1234,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -445,6 +454,7 @@ func (suite *ConfigTestSuite) TestEndpointsSetLogsDDUrl() {
 		Port:             443,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -491,6 +501,7 @@ func (suite *ConfigTestSuite) TestEndpointsSetDDSite() {
 		Port:             0,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -527,6 +538,7 @@ func (suite *ConfigTestSuite) TestBuildServerlessEndpoints() {
 		Port:             0,
 		UseSSL:           true,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -562,6 +574,7 @@ func getTestEndpoint(host string, port int, ssl bool) Endpoint {
 		Port:             port,
 		UseSSL:           ssl,
 		UseCompression:   true,
+		CompressionKind:  "gzip",
 		CompressionLevel: 6,
 		BackoffFactor:    coreConfig.DefaultLogsSenderBackoffFactor,
 		BackoffBase:      coreConfig.DefaultLogsSenderBackoffBase,
@@ -642,3 +655,61 @@ func (suite *ConfigTestSuite) TestBuildEndpointsWithoutVector() {
 	suite.Nil(err)
 	suite.Equal(expectedEndpoints, endpoints)
 }
+
+func (suite *ConfigTestSuite) TestHTTPEndpointsCompressionKind() {
+	suite.config.Set("api_key", "123")
+	suite.config.Set("logs_config.compression_kind", "zstd")
+	suite.config.Set("logs_config.compression_level", 3)
+	suite.config.Set("logs_config.additional_endpoints", `[{"api_key": "456", "host": "additional.endpoint", "port": 1234}]`)
+
+	endpoints, err := BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	for _, endpoint := range endpoints.Endpoints {
+		suite.Equal(ZstdCompressionKind, endpoint.CompressionKind)
+		suite.Equal(3, endpoint.CompressionLevel)
+	}
+	suite.Equal("Reliable: Sending zstd compressed logs in HTTPS to agent-http-intake.logs.datadoghq.com on port 443", endpoints.Main.GetStatus("Reliable: ", true))
+
+	suite.config.Set("logs_config.compression_kind", "lz4")
+	endpoints, err = BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Equal(GzipCompressionKind, endpoints.Main.CompressionKind)
+}
+
+func (suite *ConfigTestSuite) TestHTTPEndpointsWebhooks() {
+	suite.config.Set("api_key", "123")
+	suite.config.Set("logs_config.connection_reset_interval", 30)
+	suite.config.Set("logs_config.webhook_endpoints", `[
+		{"url": "https://archive.example.com/ingest", "headers": {"X-Scope": "logs"}, "bearer_token": "secret"},
+		{"url": "http://loki:3100/loki/api/v1/push", "format": "loki", "use_compression": true},
+		{"url": "http://es:9200/_bulk", "format": "xml"},
+		{"format": "json_array"}
+	]`)
+
+	endpoints, err := BuildHTTPEndpoints("test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.Equal([]WebhookEndpoint{
+		{
+			URL:                     "https://archive.example.com/ingest",
+			Format:                  NDJSONWebhookFormat,
+			Headers:                 map[string]string{"X-Scope": "logs"},
+			BearerToken:             "secret",
+			ConnectionResetInterval: 30 * time.Second,
+		},
+		{
+			URL:                     "http://loki:3100/loki/api/v1/push",
+			Format:                  LokiWebhookFormat,
+			UseCompression:          true,
+			ConnectionResetInterval: 30 * time.Second,
+		},
+	}, endpoints.Webhooks)
+	suite.Equal([]string{
+		"Reliable: Sending compressed logs in HTTPS to agent-http-intake.logs.datadoghq.com on port 443",
+		"Webhook: Sending uncompressed logs in ndjson format to https://archive.example.com/ingest",
+		"Webhook: Sending compressed logs in loki format to http://loki:3100/loki/api/v1/push",
+	}, endpoints.GetStatus())
+
+	endpoints, err = buildTCPEndpoints(defaultLogsConfigKeys())
+	suite.Nil(err)
+	suite.Empty(endpoints.Webhooks)
+}
@@ -32,14 +32,29 @@ const (
 	EPIntakeVersion2
 )
 
+// Compression kinds of the HTTP endpoints
+const (
+	GzipCompressionKind = "gzip"
+	ZstdCompressionKind = "zstd"
+)
+
+// Payload formats of the webhook endpoints
+const (
+	NDJSONWebhookFormat        = "ndjson"
+	JSONArrayWebhookFormat     = "json_array"
+	LokiWebhookFormat          = "loki"
+	ElasticsearchWebhookFormat = "elasticsearch"
+)
+
 // Endpoint holds all the organization and network parameters to send logs to Datadog.
 type Endpoint struct {
 	APIKey                  string `mapstructure:"api_key" json:"api_key"`
 	Host                    string
 	Port                    int
 	UseSSL                  bool
-	UseCompression          bool `mapstructure:"use_compression" json:"use_compression"`
-	CompressionLevel        int  `mapstructure:"compression_level" json:"compression_level"`
+	UseCompression          bool   `mapstructure:"use_compression" json:"use_compression"`
+	CompressionKind         string `mapstructure:"compression_kind" json:"compression_kind"`
+	CompressionLevel        int    `mapstructure:"compression_level" json:"compression_level"`
 	ProxyAddress            string
 	IsReliable              bool `mapstructure:"is_reliable" json:"is_reliable"`
 	ConnectionResetInterval time.Duration
@@ -56,11 +71,38 @@ type Endpoint struct {
 	Origin    IntakeOrigin
 }
 
+// WebhookEndpoint holds the parameters to send logs to an arbitrary HTTP endpoint
+// that is not a Datadog intake, the logs are re-encoded in the format of the endpoint.
+type WebhookEndpoint struct {
+	URL            string            `mapstructure:"url" json:"url"`
+	Format         string            `mapstructure:"format" json:"format"`
+	Headers        map[string]string `mapstructure:"headers" json:"headers"`
+	Username       string            `mapstructure:"username" json:"username"`
+	Password       string            `mapstructure:"password" json:"password"`
+	BearerToken    string            `mapstructure:"bearer_token" json:"bearer_token"`
+	UseCompression bool              `mapstructure:"use_compression" json:"use_compression"`
+	// Index is the index the logs are written to by the elasticsearch format.
+	Index                   string `mapstructure:"index" json:"index"`
+	ConnectionResetInterval time.Duration
+}
+
+// GetStatus returns the webhook endpoint status
+func (w *WebhookEndpoint) GetStatus(prefix string) string {
+	compression := "uncompressed"
+	if w.UseCompression {
+		compression = "compressed"
+	}
+	return fmt.Sprintf("%sSending %s logs in %s format to %s", prefix, compression, w.Format, w.URL)
+}
+
 // GetStatus returns the endpoint status
 func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
 	compression := "uncompressed"
 	if e.UseCompression {
 		compression = "compressed"
+		if e.CompressionKind == ZstdCompressionKind {
+			compression = "zstd compressed"
+		}
 	}
 
 	host := e.Host
@@ -97,6 +139,7 @@ func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
 type Endpoints struct {
 	Main                   Endpoint
 	Endpoints              []Endpoint
+	Webhooks               []WebhookEndpoint
 	UseProto               bool
 	UseHTTP                bool
 	BatchWait              time.Duration
@@ -114,6 +157,9 @@ func (e *Endpoints) GetStatus() []string {
 	for _, endpoint := range e.GetUnReliableEndpoints() {
 		result = append(result, endpoint.GetStatus("Unreliable: ", e.UseHTTP))
 	}
+	for _, webhook := range e.Webhooks {
+		result = append(result, webhook.GetStatus("Webhook: "))
+	}
 	return result
 }
 
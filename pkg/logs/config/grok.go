@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// grokPatterns holds the grok-like patterns that can be used in `extract_grok` rules.
+var grokPatterns = map[string]string{
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"INT":               `[+-]?\d+`,
+	"POSINT":            `\b[1-9]\d*\b`,
+	"NUMBER":            `[+-]?(?:\d+(?:\.\d*)?|\.\d+)`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"UUID":              `[A-Fa-f0-9]{8}-(?:[A-Fa-f0-9]{4}-){3}[A-Fa-f0-9]{12}`,
+	"IPV4":              `(?:\d{1,3}\.){3}\d{1,3}`,
+	"IPV6":              `[A-Fa-f0-9]{0,4}(?::[A-Fa-f0-9]{0,4}){2,7}`,
+	"IP":                `(?:[A-Fa-f0-9]{0,4}(?::[A-Fa-f0-9]{0,4}){2,7}|(?:\d{1,3}\.){3}\d{1,3})`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?\b`,
+	"URIPATH":           `(?:/[^\s?#]*)+`,
+	"LOGLEVEL":          `(?i:alert|trace|debug|notice|info(?:rmation)?|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?)`,
+	"TIMESTAMP_ISO8601": `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(?::\d{2}(?:[.,]\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?`,
+	"HTTPDATE":          `\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`,
+	"SYSLOGTIMESTAMP":   `\w{3} +\d{1,2} \d{2}:\d{2}:\d{2}`,
+}
+
+// grokTokenPattern matches the `%{SYNTAX}` and `%{SYNTAX:name}` tokens of a grok pattern.
+var grokTokenPattern = regexp.MustCompile(`%\{(\w+)(?::(\w+))?\}`)
+
+// ExpandGrokPattern translates a grok-like pattern into a regular expression,
+// `%{SYNTAX:name}` tokens become named capture groups and `%{SYNTAX}` tokens
+// non-capturing groups, the rest of the pattern is kept as is.
+func ExpandGrokPattern(pattern string) (string, error) {
+	var expanded strings.Builder
+	last := 0
+	for _, loc := range grokTokenPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		syntax := pattern[loc[2]:loc[3]]
+		re, exists := grokPatterns[syntax]
+		if !exists {
+			return "", fmt.Errorf("unknown grok pattern %s", syntax)
+		}
+		expanded.WriteString(pattern[last:loc[0]])
+		if loc[4] >= 0 {
+			expanded.WriteString("(?P<" + pattern[loc[4]:loc[5]] + ">" + re + ")")
+		} else {
+			expanded.WriteString("(?:" + re + ")")
+		}
+		last = loc[1]
+	}
+	expanded.WriteString(pattern[last:])
+	return expanded.String(), nil
+}
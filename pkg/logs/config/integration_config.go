@@ -23,6 +23,7 @@ const (
 	WindowsEventType  = "windows_event"
 	SnmpTrapsType     = "snmp_traps"
 	StringChannelType = "string_channel"
+	SyslogType        = "syslog"
 
 	// UTF16BE for UTF-16 Big endian encoding
 	UTF16BE string = "utf-16-be"
@@ -39,6 +40,10 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
+	Protocol    string // Syslog
+	TLSCert     string `mapstructure:"tls_cert" json:"tls_cert"`           // TCP, Syslog
+	TLSKey      string `mapstructure:"tls_key" json:"tls_key"`             // TCP, Syslog
+	TLSClientCA string `mapstructure:"tls_client_ca" json:"tls_client_ca"` // TCP, Syslog
 	Path        string // File, Journald
 
 	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
@@ -144,6 +149,16 @@ func (c *LogsConfig) Validate() error {
 		return fmt.Errorf("tcp source must have a port")
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
+	case c.Type == SyslogType && c.Port == 0:
+		return fmt.Errorf("syslog source must have a port")
+	case c.Type == SyslogType && c.Protocol != "" && c.Protocol != TCPType && c.Protocol != UDPType:
+		return fmt.Errorf("invalid protocol '%v' for syslog source, must be tcp or udp", c.Protocol)
+	case (c.TLSCert == "") != (c.TLSKey == ""):
+		return fmt.Errorf("tls_cert and tls_key must be both set to enable TLS")
+	case c.TLSClientCA != "" && c.TLSCert == "":
+		return fmt.Errorf("tls_client_ca requires tls_cert and tls_key to be set")
+	case c.TLSCert != "" && c.Type != TCPType && !(c.Type == SyslogType && c.Protocol != UDPType):
+		return fmt.Errorf("TLS is only supported by tcp sources")
 	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
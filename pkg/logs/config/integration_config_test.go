@@ -21,6 +21,10 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: FileType, Path: "/var/log/foo.log"},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: SyslogType, Port: 514},
+		{Type: SyslogType, Port: 6514, Protocol: TCPType},
+		{Type: TCPType, Port: 1234, TLSCert: "/etc/certs/server.crt", TLSKey: "/etc/certs/server.key"},
+		{Type: SyslogType, Port: 6514, TLSCert: "/etc/certs/server.crt", TLSKey: "/etc/certs/server.key", TLSClientCA: "/etc/certs/ca.crt"},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: SnmpTrapsType},
@@ -38,6 +42,12 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: FileType},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: SyslogType},
+		{Type: SyslogType, Port: 514, Protocol: "http"},
+		{Type: TCPType, Port: 1234, TLSCert: "/etc/certs/server.crt"},
+		{Type: TCPType, Port: 1234, TLSClientCA: "/etc/certs/ca.crt"},
+		{Type: UDPType, Port: 1234, TLSCert: "/etc/certs/server.crt", TLSKey: "/etc/certs/server.key"},
+		{Type: SyslogType, Port: 514, Protocol: UDPType, TLSCert: "/etc/certs/server.crt", TLSKey: "/etc/certs/server.key"},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
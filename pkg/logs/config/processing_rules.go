@@ -8,33 +8,70 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 // Processing rule types
 const (
-	ExcludeAtMatch = "exclude_at_match"
-	IncludeAtMatch = "include_at_match"
-	MaskSequences  = "mask_sequences"
-	MultiLine      = "multi_line"
+	ExcludeAtMatch  = "exclude_at_match"
+	IncludeAtMatch  = "include_at_match"
+	MaskSequences   = "mask_sequences"
+	MultiLine       = "multi_line"
+	ExtractRegex    = "extract_regex"
+	ExtractGrok     = "extract_grok"
+	ExtractKeyValue = "extract_key_value"
+	ExtractJSON     = "extract_json"
+	Sample          = "sample"
+	RateLimit       = "rate_limit"
 )
 
-// ProcessingRule defines an exclusion or a masking rule to
+// Targets an extracted field can be remapped to
+const (
+	RemapTag       = "tag"
+	RemapService   = "service"
+	RemapStatus    = "status"
+	RemapTimestamp = "timestamp"
+)
+
+// ProcessingRule defines an exclusion, a masking, an extraction or a throttling rule to
 // be applied on log lines
 type ProcessingRule struct {
 	Type               string
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Remap maps the name of a field extracted by an extraction rule to the attribute
+	// of the message it is promoted to: "service", "status", "timestamp", "tag" or "tag:<tag_name>".
+	Remap map[string]string
+	// TimestampFormat is the format used to parse a field remapped to "timestamp",
+	// either "rfc3339" (default), "unix", "unix_ms" or a Go time layout.
+	TimestampFormat string `mapstructure:"timestamp_format" json:"timestamp_format"`
+	// Keep and Every define a sample rule keeping Keep out of every Every matching lines.
+	Keep  int
+	Every int
+	// MaxPerSecond is the number of matching lines per second and per source a rate_limit rule lets through.
+	MaxPerSecond int `mapstructure:"max_per_second" json:"max_per_second"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
 }
 
+// IsExtractionRule returns true if the rule extracts fields from log lines.
+func (r *ProcessingRule) IsExtractionRule() bool {
+	switch r.Type {
+	case ExtractRegex, ExtractGrok, ExtractKeyValue, ExtractJSON:
+		return true
+	}
+	return false
+}
+
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
 // Each processing rule must have:
 // - a valid name
 // - a valid type
-// - a valid pattern that compiles
+// - a valid pattern that compiles, optional for key/value, JSON and throttling rules
+// - a valid remapping for extraction rules
+// - valid limits for throttling rules
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -42,7 +79,7 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		}
 
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
+		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine, ExtractRegex, ExtractGrok, ExtractKeyValue, ExtractJSON, Sample, RateLimit:
 			break
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
@@ -51,34 +88,104 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		}
 
 		if rule.Pattern == "" {
-			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+			if !hasOptionalPattern(rule) {
+				return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
+			}
+		} else {
+			pattern, err := expandPattern(rule)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s for processing rule: %s: %v", rule.Pattern, rule.Name, err)
+			}
+			_, err = regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
+			}
 		}
-		_, err := regexp.Compile(rule.Pattern)
-		if err != nil {
-			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
+
+		if rule.IsExtractionRule() {
+			if err := validateRemap(rule); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case rule.Type == Sample && (rule.Keep <= 0 || rule.Every <= 0 || rule.Keep > rule.Every):
+			return fmt.Errorf("invalid sampling for processing rule: %s, keep must be between 1 and every", rule.Name)
+		case rule.Type == RateLimit && rule.MaxPerSecond <= 0:
+			return fmt.Errorf("invalid max_per_second for processing rule: %s, it must be positive", rule.Name)
 		}
 	}
 	return nil
 }
 
+// hasOptionalPattern returns true if the pattern of the rule only filters the lines the rule applies to.
+func hasOptionalPattern(rule *ProcessingRule) bool {
+	switch rule.Type {
+	case ExtractKeyValue, ExtractJSON, Sample, RateLimit:
+		return true
+	}
+	return false
+}
+
+// validateRemap checks that an extraction rule promotes its fields to known targets.
+func validateRemap(rule *ProcessingRule) error {
+	if len(rule.Remap) == 0 {
+		return fmt.Errorf("no remap provided for processing rule: %s", rule.Name)
+	}
+	for field, target := range rule.Remap {
+		switch {
+		case target == RemapService, target == RemapStatus, target == RemapTimestamp, target == RemapTag:
+		case strings.HasPrefix(target, RemapTag+":") && len(target) > len(RemapTag)+1:
+		default:
+			return fmt.Errorf("invalid remap target %s for field %s of processing rule: %s", target, field, rule.Name)
+		}
+	}
+	return nil
+}
+
+// expandPattern returns the regular expression matching the pattern of the rule.
+func expandPattern(rule *ProcessingRule) (string, error) {
+	if rule.Type == ExtractGrok {
+		return ExpandGrokPattern(rule.Pattern)
+	}
+	return rule.Pattern, nil
+}
+
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
-		re, err := regexp.Compile(rule.Pattern)
-		if err != nil {
-			return err
-		}
-		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
-			rule.Regex = re
-		case MaskSequences:
-			rule.Regex = re
-			rule.Placeholder = []byte(rule.ReplacePlaceholder)
-		case MultiLine:
-			rule.Regex, err = regexp.Compile("^" + rule.Pattern)
+		if rule.Pattern == "" && hasOptionalPattern(rule) {
+			// the rule applies to all lines
+			rule.Regex = nil
+		} else {
+			pattern, err := expandPattern(rule)
 			if err != nil {
 				return err
 			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return err
+			}
+			switch rule.Type {
+			case ExcludeAtMatch, IncludeAtMatch, ExtractRegex, ExtractGrok, ExtractKeyValue, ExtractJSON, Sample, RateLimit:
+				rule.Regex = re
+			case MaskSequences:
+				rule.Regex = re
+				rule.Placeholder = []byte(rule.ReplacePlaceholder)
+			case MultiLine:
+				rule.Regex, err = regexp.Compile("^" + rule.Pattern)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if rule.IsExtractionRule() {
+			// field names are matched case-insensitively as YAML configurations lowercase map keys
+			remap := make(map[string]string, len(rule.Remap))
+			for field, target := range rule.Remap {
+				remap[strings.ToLower(field)] = target
+			}
+			rule.Remap = remap
 		}
 	}
 	return nil
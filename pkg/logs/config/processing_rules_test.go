@@ -34,3 +34,69 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateShouldSucceedWithExtractionRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Name: "regex", Type: ExtractRegex, Pattern: `level=(?P<level>\w+)`, Remap: map[string]string{"level": RemapStatus}},
+		{Name: "grok", Type: ExtractGrok, Pattern: `%{IP:client} %{WORD}`, Remap: map[string]string{"client": "tag:client_ip"}},
+		{Name: "kv", Type: ExtractKeyValue, Remap: map[string]string{"svc": RemapService}},
+		{Name: "json", Type: ExtractJSON, Remap: map[string]string{"time": RemapTimestamp, "user": RemapTag}},
+	}
+	assert.Nil(t, ValidateProcessingRules(rules))
+}
+
+func TestValidateShouldFailWithInvalidExtractionRules(t *testing.T) {
+	invalidRules := []*ProcessingRule{
+		{Name: "no_pattern", Type: ExtractRegex, Remap: map[string]string{"level": RemapStatus}},
+		{Name: "no_remap", Type: ExtractKeyValue},
+		{Name: "invalid_target", Type: ExtractJSON, Remap: map[string]string{"level": "message"}},
+		{Name: "empty_tag", Type: ExtractJSON, Remap: map[string]string{"level": "tag:"}},
+		{Name: "unknown_grok", Type: ExtractGrok, Pattern: `%{FOO:bar}`, Remap: map[string]string{"bar": RemapTag}},
+	}
+
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
+
+func TestCompileExtractionRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Type: ExtractGrok, Pattern: `%{WORD:Level} %{NUMBER}`, Remap: map[string]string{"Level": RemapStatus}},
+		{Type: ExtractKeyValue, Remap: map[string]string{"svc": RemapService}},
+	}
+	assert.Nil(t, CompileProcessingRules(rules))
+
+	assert.Equal(t, []string{"", "Level"}, rules[0].Regex.SubexpNames())
+	assert.True(t, rules[0].Regex.MatchString("error 12.5"))
+	assert.Equal(t, map[string]string{"level": RemapStatus}, rules[0].Remap)
+
+	assert.Nil(t, rules[1].Regex)
+}
+
+func TestExpandGrokPattern(t *testing.T) {
+	pattern, err := ExpandGrokPattern(`^%{INT:code} \[%{NOTSPACE}\]`)
+	assert.Nil(t, err)
+	assert.Equal(t, `^(?P<code>[+-]?\d+) \[(?:\S+)\]`, pattern)
+
+	_, err = ExpandGrokPattern(`%{UNKNOWN}`)
+	assert.NotNil(t, err)
+}
+
+func TestValidateThrottlingRules(t *testing.T) {
+	validRules := []*ProcessingRule{
+		{Name: "sample", Type: Sample, Pattern: "DEBUG", Keep: 1, Every: 10},
+		{Name: "sample_all", Type: Sample, Keep: 10, Every: 10},
+		{Name: "rate_limit", Type: RateLimit, MaxPerSecond: 100},
+	}
+	assert.Nil(t, ValidateProcessingRules(validRules))
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no_keep", Type: Sample, Every: 10},
+		{Name: "keep_too_many", Type: Sample, Keep: 11, Every: 10},
+		{Name: "no_rate", Type: RateLimit},
+		{Name: "negative_rate", Type: RateLimit, MaxPerSecond: -1},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
@@ -110,6 +110,19 @@ func (s *LogSource) RegisterInfo(i InfoProvider) {
 	s.info[i.InfoKey()] = i
 }
 
+// LoadOrRegisterInfo returns the InfoProvider registered with the key,
+// registering the one returned by newInfo if there is none yet.
+func (s *LogSource) LoadOrRegisterInfo(key string, newInfo func() InfoProvider) InfoProvider {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if i, exists := s.info[key]; exists {
+		return i
+	}
+	i := newInfo()
+	s.info[key] = i
+	return i
+}
+
 // GetInfo gets an InfoProvider instance by the key
 func (s *LogSource) GetInfo(key string) InfoProvider {
 	s.lock.Lock()
@@ -38,6 +38,7 @@ type Message struct {
 	Status             string
 	RawDataLen         int
 	Timestamp          string
+	Tags               []string
 	IngestionTimestamp int64
 }
 
@@ -38,6 +38,11 @@ const (
 	// headers are included in the log frame.  The size in those headers is not
 	// consulted.  The result does not include the trailing newlines.
 	DockerStream
+
+	// Syslog messages over a stream, either octet-counted (RFC 6587 section 3.4.1,
+	// as used by RFC 5425 over TLS) or newline-terminated (RFC 6587 section 3.4.2).
+	// The framing is detected for each message.
+	SyslogStream
 )
 
 // Framer gets chunks of bytes (via Process(..)) and uses an
@@ -95,6 +100,8 @@ func NewFramer(
 		matcher = &oneByteNewLineMatcher{contentLenLimit}
 	case DockerStream:
 		matcher = &dockerStreamMatcher{contentLenLimit}
+	case SyslogStream:
+		matcher = &syslogStreamMatcher{}
 	default:
 		panic(fmt.Sprintf("unknown framing %d", framing))
 	}
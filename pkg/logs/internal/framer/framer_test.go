@@ -362,3 +362,44 @@ func TestFramerInputNotDockerHeader(t *testing.T) {
 	assert.Equal(t, expected2, output.content)
 	assert.Equal(t, len(expected2)+1, output.rawDataLen)
 }
+
+func TestSyslogStreamFraming(t *testing.T) {
+	test := func(chunks [][]byte, lines []string, rawLens []int) func(*testing.T) {
+		return func(t *testing.T) {
+			gotContent := []string{}
+			gotLens := []int{}
+			outputFn := func(content []byte, rawDataLen int) {
+				gotContent = append(gotContent, string(content))
+				gotLens = append(gotLens, rawDataLen)
+			}
+			framer := NewFramer(outputFn, SyslogStream, contentLenLimit)
+			for _, chunk := range chunks {
+				framer.Process(chunk)
+			}
+			require.Equal(t, lines, gotContent)
+			require.Equal(t, rawLens, gotLens)
+		}
+	}
+	oneByteChunks := func(input []byte) [][]byte {
+		chunks := [][]byte{}
+		for i := range input {
+			chunks = append(chunks, input[i:i+1])
+		}
+		return chunks
+	}
+
+	octetCounted := []byte("11 <34>1 - a\nb13 <34>1 - hello13 <34>1 - world")
+	octetLines := []string{"<34>1 - a\nb", "<34>1 - hello", "<34>1 - world"}
+	octetLens := []int{14, 16, 16}
+	t.Run("octet-counted one chunk", test([][]byte{octetCounted}, octetLines, octetLens))
+	t.Run("octet-counted one-byte chunks", test(oneByteChunks(octetCounted), octetLines, octetLens))
+
+	newlines := []byte("<34>1 - hello\n<34>1 - world\n")
+	newlineLines := []string{"<34>1 - hello", "<34>1 - world"}
+	newlineLens := []int{14, 14}
+	t.Run("newline one chunk", test([][]byte{newlines}, newlineLines, newlineLens))
+	t.Run("newline one-byte chunks", test(oneByteChunks(newlines), newlineLines, newlineLens))
+
+	mixed := []byte("13 <34>1 - hello\n<34>1 - world\n1x not a count\n")
+	t.Run("mixed", test([][]byte{mixed}, []string{"<34>1 - hello", "", "<34>1 - world", "1x not a count"}, []int{16, 1, 14, 15}))
+}
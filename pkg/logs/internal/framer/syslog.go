@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+// maxOctetCountDigits is the maximum number of digits of the length of an octet-counted message.
+const maxOctetCountDigits = 10
+
+// syslogStreamMatcher matches the syslog messages of a stream. A message starting
+// with a digit is octet-counted, `MSG-LEN SP SYSLOG-MSG`, as syslog messages
+// always start with `<`, any other message is terminated by a newline.
+type syslogStreamMatcher struct{}
+
+// FindFrame implements EndLineMatcher#FindFrame.
+func (s *syslogStreamMatcher) FindFrame(buf []byte, seen int) ([]byte, int) {
+	if len(buf) > 0 && buf[0] >= '1' && buf[0] <= '9' {
+		length := 0
+		for i := 0; i < len(buf) && i <= maxOctetCountDigits; i++ {
+			switch c := buf[i]; {
+			case c >= '0' && c <= '9':
+				length = length*10 + int(c-'0')
+			case c == ' ':
+				end := i + 1 + length
+				if end > len(buf) {
+					// wait for the rest of the message
+					return nil, 0
+				}
+				return buf[i+1 : end], end
+			default:
+				// not an octet count, fall back to a newline-terminated message
+				return s.findNewline(buf, seen)
+			}
+		}
+		if len(buf) <= maxOctetCountDigits {
+			// wait for the rest of the octet count
+			return nil, 0
+		}
+	}
+	return s.findNewline(buf, seen)
+}
+
+func (s *syslogStreamMatcher) findNewline(buf []byte, seen int) ([]byte, int) {
+	for i := seen; i < len(buf); i++ {
+		if buf[i] == '\n' {
+			return buf[:i], i + 1
+		}
+	}
+	return nil, 0
+}
@@ -56,6 +56,9 @@ type Launcher struct {
 	// Feature flag defaulting to false, use `logs_config.validate_pod_container_id`.
 	validatePodContainerID bool
 	scanPeriod             time.Duration
+	// readArchives holds the identifiers of the compressed archives which have
+	// been read, indexed by scan key, they are not read again while they exist.
+	readArchives map[string]string
 }
 
 // NewLauncher returns a new launcher.
@@ -64,6 +67,7 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		tailingLimit:           tailingLimit,
 		fileProvider:           newFileProvider(tailingLimit),
 		tailers:                make(map[string]*tailer.Tailer),
+		readArchives:           make(map[string]string),
 		tailerSleepDuration:    tailerSleepDuration,
 		stop:                   make(chan struct{}),
 		validatePodContainerID: validatePodContainerID,
@@ -124,6 +128,7 @@ func (s *Launcher) cleanup() {
 func (s *Launcher) scan() {
 	files := s.fileProvider.filesToTail(s.activeSources)
 	filesTailed := make(map[string]bool)
+	filesScanned := make(map[string]bool)
 	tailersLen := len(s.tailers)
 
 	for _, file := range files {
@@ -137,8 +142,17 @@ func (s *Launcher) scan() {
 		// when a tailer for a dead container is still tailing the file, and another
 		// tailer is tailing the file for the new container).
 		tailerKey := file.GetScanKey()
+		filesScanned[tailerKey] = true
+		if identifier, isRead := s.readArchives[tailerKey]; isRead {
+			// prevent the completed entry of the archive from expiring while it exists
+			s.registry.KeepAlive(identifier)
+			continue
+		}
 		tailer, isTailed := s.tailers[tailerKey]
 		if isTailed && tailer.IsFinished() {
+			if tailer.IsArchiveRead() {
+				s.readArchives[tailerKey] = tailer.Identifier()
+			}
 			// skip this tailer as it must be stopped
 			continue
 		}
@@ -159,6 +173,12 @@ func (s *Launcher) scan() {
 			continue
 		}
 
+		if file.IsArchive() {
+			// archives are never rotated, they are read until their end
+			filesTailed[tailerKey] = true
+			continue
+		}
+
 		didRotate, err := tailer.DidRotate()
 		if err != nil {
 			continue
@@ -182,6 +202,13 @@ func (s *Launcher) scan() {
 			s.stopTailer(scanKey, tailer)
 		}
 	}
+
+	for scanKey := range s.readArchives {
+		// forget the archives that do not exist anymore
+		if !filesScanned[scanKey] {
+			delete(s.readArchives, scanKey)
+		}
+	}
 }
 
 // addSource keeps track of the new source and launch new tailers for this source.
@@ -259,9 +286,34 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 
 	var offset int64
 	var whence int
-	mode := s.handleTailingModeChange(tailer.Identifier(), m)
+	var mode config.TailingMode
+	var fingerprint string
+	if tailer.IsFingerprintEnabled() {
+		var err error
+		if fingerprint, err = tailer.ComputeFingerprint(); err != nil {
+			log.Warnf("Could not fingerprint file with path %v: %v", file.Path, err)
+			return false
+		}
+	}
+
+	if file.IsArchive() {
+		// archives are read once from their beginning, or from the offset
+		// collected before a restart, whatever the tailing mode
+		if identifier := s.completedArchiveIdentifier(tailer.Identifier(), fingerprint); identifier != "" {
+			s.readArchives[file.GetScanKey()] = identifier
+			return false
+		}
+		mode = config.Beginning
+	} else {
+		mode = s.handleTailingModeChange(tailer.Identifier(), m)
+	}
 
-	offset, whence, err := Position(s.registry, tailer.Identifier(), mode)
+	var err error
+	if tailer.IsFingerprintEnabled() {
+		offset, whence, err = PositionWithFingerprint(s.registry, tailer.Identifier(), fingerprint, mode)
+	} else {
+		offset, whence, err = Position(s.registry, tailer.Identifier(), mode)
+	}
 	if err != nil {
 		log.Warnf("Could not recover offset for file with path %v: %v", file.Path, err)
 	}
@@ -277,6 +329,19 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 	return true
 }
 
+// completedArchiveIdentifier returns the identifier of the registry entry marking an
+// archive as completed, which was registered under another path if the archive has
+// been renamed, returns an empty string if the archive has not been read yet.
+func (s *Launcher) completedArchiveIdentifier(identifier string, fingerprint string) string {
+	if s.registry.GetOffset(identifier) == auditor.CompletedOffset {
+		return identifier
+	}
+	if other := s.registry.GetIdentifierByFingerprint(fingerprint); other != "" && s.registry.GetOffset(other) == auditor.CompletedOffset {
+		return other
+	}
+	return ""
+}
+
 // shouldIgnore resolves symlinks in /var/log/containers in order to use that redirection
 // to validate that we will be reading a file for the correct container.
 func (s *Launcher) shouldIgnore(file *tailer.File) bool {
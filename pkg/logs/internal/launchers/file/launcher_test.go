@@ -9,6 +9,7 @@
 package file
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -18,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
+	logsauditor "github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	auditor "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/launchers"
@@ -399,6 +401,85 @@ func TestLauncherScanWithTooManyFiles(t *testing.T) {
 	assert.Equal(t, 2, len(launcher.tailers))
 }
 
+func createArchive(t *testing.T, path string, content string) {
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	defer file.Close()
+	writer := gzip.NewWriter(file)
+	_, err = writer.Write([]byte(content))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+}
+
+func TestLauncherScanReadsArchivesOnce(t *testing.T) {
+	testDir := t.TempDir()
+	createArchive(t, fmt.Sprintf("%s/app.log.1.gz", testDir), "archived\n")
+	file, err := os.Create(fmt.Sprintf("%s/app.log", testDir))
+	assert.Nil(t, err)
+	defer file.Close()
+
+	// create launcher
+	launcher := NewLauncher(2, 20*time.Millisecond, false, 10*time.Second)
+	launcher.pipelineProvider = mock.NewMockProvider()
+	launcher.registry = auditor.NewRegistry()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/app.log*", testDir)})
+	launcher.activeSources = append(launcher.activeSources, source)
+	status.Clear()
+	status.InitStatus(config.CreateSources([]*config.LogSource{source}))
+	defer status.Clear()
+
+	// the archive is read alongside the live file
+	launcher.scan()
+	assert.Equal(t, 2, len(launcher.tailers))
+	msg := <-outputChan
+	assert.Equal(t, "archived", string(msg.Content))
+	assert.Equal(t, logsauditor.CompletedOffset, msg.Origin.Offset)
+
+	archiveKey := getScanKey(fmt.Sprintf("%s/app.log.1.gz", testDir), source)
+	assert.Eventually(t, launcher.tailers[archiveKey].IsFinished, 5*time.Second, 10*time.Millisecond)
+
+	// the archive tailer is stopped once it has read the archive and is not started again
+	launcher.scan()
+	assert.Equal(t, 1, len(launcher.tailers))
+	assert.Contains(t, launcher.readArchives, archiveKey)
+	launcher.scan()
+	assert.Equal(t, 1, len(launcher.tailers))
+
+	// the live file is still tailed
+	_, err = file.WriteString("live\n")
+	assert.Nil(t, err)
+	msg = <-outputChan
+	assert.Equal(t, "live", string(msg.Content))
+
+	// the archive is forgotten once removed
+	assert.Nil(t, os.Remove(fmt.Sprintf("%s/app.log.1.gz", testDir)))
+	launcher.scan()
+	assert.NotContains(t, launcher.readArchives, archiveKey)
+}
+
+func TestLauncherSkipsCompletedArchives(t *testing.T) {
+	testDir := t.TempDir()
+	path := fmt.Sprintf("%s/app.log.1.gz", testDir)
+	createArchive(t, path, "archived\n")
+
+	// create launcher
+	launcher := NewLauncher(2, 20*time.Millisecond, false, 10*time.Second)
+	launcher.pipelineProvider = mock.NewMockProvider()
+	registry := auditor.NewRegistry()
+	registry.SetOffset(logsauditor.CompletedOffset)
+	launcher.registry = registry
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/*.gz", testDir)})
+	launcher.activeSources = append(launcher.activeSources, source)
+	status.Clear()
+	status.InitStatus(config.CreateSources([]*config.LogSource{source}))
+	defer status.Clear()
+
+	launcher.scan()
+	assert.Equal(t, 0, len(launcher.tailers))
+	assert.Contains(t, launcher.readArchives, getScanKey(path, source))
+}
+
 func TestContainerIDInContainerLogFile(t *testing.T) {
 	assert := assert.New(t)
 	//func (s *Launcher) shouldIgnore(file *File) bool {
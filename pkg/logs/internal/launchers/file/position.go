@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Position returns the position from where logs should be collected.
@@ -47,3 +48,36 @@ func Position(registry auditor.Registry, identifier string, mode config.TailingM
 	}
 	return offset, whence, err
 }
+
+// PositionWithFingerprint returns the position from where logs should be collected
+// when files are identified by a fingerprint of their content. The offset registered
+// for the file is only honored if it was registered for the same content, otherwise
+// the offset registered for the same content under another path is used, as it happens
+// when a file has been renamed.
+func PositionWithFingerprint(registry auditor.Registry, identifier string, fingerprint string, mode config.TailingMode) (int64, int, error) {
+	if mode == config.ForceBeginning || mode == config.ForceEnd {
+		return Position(registry, identifier, mode)
+	}
+
+	registered := registry.GetOffset(identifier) != ""
+	if registered {
+		// an offset registered before the file could be fingerprinted is honored
+		if registeredFingerprint := registry.GetFingerprint(identifier); registeredFingerprint == "" || registeredFingerprint == fingerprint {
+			return Position(registry, identifier, mode)
+		}
+	}
+	if other := registry.GetIdentifierByFingerprint(fingerprint); other != "" {
+		log.Infof("Resuming %s from the offset registered for %s", identifier, other)
+		return Position(registry, other, mode)
+	}
+
+	switch {
+	case registered:
+		// the file has been replaced since its offset was registered
+		return 0, io.SeekStart, nil
+	case mode == config.Beginning:
+		return 0, io.SeekStart, nil
+	default:
+		return 0, io.SeekEnd, nil
+	}
+}
@@ -68,3 +68,49 @@ func TestPosition(t *testing.T) {
 	assert.Equal(t, int64(0), offset)
 	assert.Equal(t, io.SeekEnd, whence)
 }
+
+func TestPositionWithFingerprint(t *testing.T) {
+	registry := mock.NewRegistry()
+
+	var err error
+	var offset int64
+	var whence int
+
+	// no offset registered
+	offset, whence, err = PositionWithFingerprint(registry, "file:/var/log/app.log", "abc", config.End)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), offset)
+	assert.Equal(t, io.SeekEnd, whence)
+
+	// the offset was registered for the same content
+	registry.SetEntry("file:/var/log/app.log", "42", "abc")
+	offset, whence, err = PositionWithFingerprint(registry, "file:/var/log/app.log", "abc", config.End)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), offset)
+	assert.Equal(t, io.SeekStart, whence)
+
+	// the file has been replaced, its whole content is new
+	offset, whence, err = PositionWithFingerprint(registry, "file:/var/log/app.log", "def", config.End)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), offset)
+	assert.Equal(t, io.SeekStart, whence)
+
+	// the file has been renamed
+	offset, whence, err = PositionWithFingerprint(registry, "file:/var/log/app.log.1", "abc", config.End)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), offset)
+	assert.Equal(t, io.SeekStart, whence)
+
+	// the offset was registered before the file could be fingerprinted
+	registry.SetEntry("file:/var/log/other.log", "12", "")
+	offset, whence, err = PositionWithFingerprint(registry, "file:/var/log/other.log", "ghi", config.End)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12), offset)
+	assert.Equal(t, io.SeekStart, whence)
+
+	// forced tailing modes are honored
+	offset, whence, err = PositionWithFingerprint(registry, "file:/var/log/app.log.1", "abc", config.ForceEnd)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), offset)
+	assert.Equal(t, io.SeekEnd, whence)
+}
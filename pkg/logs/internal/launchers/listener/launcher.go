@@ -19,6 +19,7 @@ type Launcher struct {
 	frameSize        int
 	tcpSources       chan *config.LogSource
 	udpSources       chan *config.LogSource
+	syslogSources    chan *config.LogSource
 	listeners        []startstop.StartStoppable
 	stop             chan struct{}
 }
@@ -36,6 +37,7 @@ func (l *Launcher) Start(sourceProvider launchers.SourceProvider, pipelineProvid
 	l.pipelineProvider = pipelineProvider
 	l.tcpSources = sourceProvider.GetAddedForType(config.TCPType)
 	l.udpSources = sourceProvider.GetAddedForType(config.UDPType)
+	l.syslogSources = sourceProvider.GetAddedForType(config.SyslogType)
 	go l.run()
 }
 
@@ -51,12 +53,29 @@ func (l *Launcher) run() {
 			listener := NewUDPListener(l.pipelineProvider, source, l.frameSize)
 			listener.Start()
 			l.listeners = append(l.listeners, listener)
+		case source := <-l.syslogSources:
+			l.startSyslogListener(source)
 		case <-l.stop:
 			return
 		}
 	}
 }
 
+// startSyslogListener starts a listener for a syslog source, over TCP unless UDP is specified.
+func (l *Launcher) startSyslogListener(source *config.LogSource) {
+	var listener startstop.StartStoppable
+	if source.Config.Protocol == config.UDPType {
+		udpListener := NewUDPListener(l.pipelineProvider, source, l.frameSize)
+		udpListener.Start()
+		listener = udpListener
+	} else {
+		tcpListener := NewTCPListener(l.pipelineProvider, source, l.frameSize)
+		tcpListener.Start()
+		listener = tcpListener
+	}
+	l.listeners = append(l.listeners, listener)
+}
+
 // Stop stops all listeners
 func (l *Launcher) Stop() {
 	l.stop <- struct{}{}
@@ -6,6 +6,7 @@
 package listener
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net"
 	"sync"
@@ -19,16 +20,22 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/startstop"
 )
 
+// tlsHandshakeTimeout is the maximum duration of the TLS handshake of a new connection.
+const tlsHandshakeTimeout = 10 * time.Second
+
 // A TCPListener listens and accepts TCP connections and delegates the read operations to a tailer.
+// The connections are TLS connections when the source has a certificate.
 type TCPListener struct {
 	pipelineProvider pipeline.Provider
 	source           *config.LogSource
 	idleTimeout      time.Duration
 	frameSize        int
 	listener         net.Listener
+	tlsConfig        *tls.Config
 	tailers          []*tailer.Tailer
 	mu               sync.Mutex
 	stop             chan struct{}
+	stopped          bool
 }
 
 // NewTCPListener returns an initialized TCPListener
@@ -56,7 +63,14 @@ func NewTCPListener(pipelineProvider pipeline.Provider, source *config.LogSource
 // Start starts the listener to accepts new incoming connections.
 func (l *TCPListener) Start() {
 	log.Infof("Starting TCP forwarder on port %d, with read buffer size: %d", l.source.Config.Port, l.frameSize)
-	err := l.startListener()
+	tlsConfig, err := buildTLSConfig(l.source.Config)
+	if err != nil {
+		log.Errorf("Can't start TCP forwarder on port %d: %v", l.source.Config.Port, err)
+		l.source.Status.Error(err)
+		return
+	}
+	l.tlsConfig = tlsConfig
+	err = l.startListener()
 	if err != nil {
 		log.Errorf("Can't start TCP forwarder on port %d: %v", l.source.Config.Port, err)
 		l.source.Status.Error(err)
@@ -71,6 +85,11 @@ func (l *TCPListener) Stop() {
 	log.Infof("Stopping TCP forwarder on port %d", l.source.Config.Port)
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if l.listener == nil {
+		// the listener failed to start
+		return
+	}
+	l.stopped = true
 	l.stop <- struct{}{}
 	l.listener.Close()
 	stopper := startstop.NewParallelStopper()
@@ -106,7 +125,11 @@ func (l *TCPListener) run() {
 				l.source.Status.Success()
 				continue
 			default:
-				l.startTailer(conn)
+				if tlsConn, ok := conn.(*tls.Conn); ok {
+					go l.handshake(tlsConn)
+				} else {
+					l.startTailer(conn, nil)
+				}
 				l.source.Status.Success()
 			}
 		}
@@ -119,6 +142,9 @@ func (l *TCPListener) startListener() error {
 	if err != nil {
 		return err
 	}
+	if l.tlsConfig != nil {
+		listener = tls.NewListener(listener, l.tlsConfig)
+	}
 	l.listener = listener
 	return nil
 }
@@ -138,11 +164,28 @@ func (l *TCPListener) read(tailer *tailer.Tailer) ([]byte, error) {
 	return frame[:n], nil
 }
 
+// handshake completes the TLS handshake of a new connection, so that its messages
+// can be tagged with the client certificate, and starts its tailer.
+func (l *TCPListener) handshake(conn *tls.Conn) {
+	conn.SetDeadline(time.Now().Add(tlsHandshakeTimeout)) //nolint:errcheck
+	if err := conn.Handshake(); err != nil {
+		log.Warnf("TLS handshake failed with %s on port %d: %v", conn.RemoteAddr(), l.source.Config.Port, err)
+		conn.Close()
+		return
+	}
+	conn.SetDeadline(time.Time{}) //nolint:errcheck
+	l.startTailer(conn, connectionTags(conn.ConnectionState()))
+}
+
 // startTailer creates and starts a new tailer that reads from the connection.
-func (l *TCPListener) startTailer(conn net.Conn) {
+func (l *TCPListener) startTailer(conn net.Conn, tags []string) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	tailer := tailer.NewTailer(l.source, conn, l.pipelineProvider.NextPipelineChan(), l.read)
+	if l.stopped {
+		conn.Close()
+		return
+	}
+	tailer := tailer.NewTailerWithTags(l.source, conn, l.pipelineProvider.NextPipelineChan(), l.read, tags)
 	l.tailers = append(l.tailers, tailer)
 	tailer.Start()
 }
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+)
+
+// clientCertSubjectTag is the tag holding the subject of the certificate of a client.
+const clientCertSubjectTag = "client_cert_subject"
+
+// attributeNames holds the short names of the usual attributes of a certificate subject.
+var attributeNames = map[string]string{
+	"2.5.4.3":  "CN",
+	"2.5.4.5":  "serialNumber",
+	"2.5.4.6":  "C",
+	"2.5.4.7":  "L",
+	"2.5.4.8":  "ST",
+	"2.5.4.9":  "street",
+	"2.5.4.10": "O",
+	"2.5.4.11": "OU",
+	"2.5.4.17": "postalCode",
+}
+
+// buildTLSConfig returns the TLS configuration of a source, or nil if TLS is not enabled.
+// Client certificates are required and verified when a client CA is configured.
+func buildTLSConfig(logsConfig *config.LogsConfig) (*tls.Config, error) {
+	if logsConfig.TLSCert == "" {
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(logsConfig.TLSCert, logsConfig.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not load TLS certificate: %v", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if logsConfig.TLSClientCA != "" {
+		pem, err := ioutil.ReadFile(logsConfig.TLSClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("could not read TLS client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("could not find any certificate in TLS client CA %s", logsConfig.TLSClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// connectionTags returns the tags of the messages received over a TLS connection.
+func connectionTags(state tls.ConnectionState) []string {
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+	return []string{clientCertSubjectTag + ":" + formatSubject(state.PeerCertificates[0].Subject)}
+}
+
+// formatSubject formats a certificate subject as `/C=US/O=Org/CN=name` rather than
+// with the RFC 2253 format, as commas are not supported in tags they are removed.
+func formatSubject(subject pkix.Name) string {
+	var formatted strings.Builder
+	for _, rdn := range subject.ToRDNSequence() {
+		for _, attribute := range rdn {
+			name, exists := attributeNames[attribute.Type.String()]
+			if !exists {
+				name = attribute.Type.String()
+			}
+			value := strings.ReplaceAll(fmt.Sprint(attribute.Value), ",", "")
+			fmt.Fprintf(&formatted, "/%s=%s", name, value)
+		}
+	}
+	return formatted.String()
+}
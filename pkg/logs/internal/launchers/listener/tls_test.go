@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listener
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/pipeline/mock"
+)
+
+// testCertificate is a certificate and its key, signed by a test CA.
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	tlsCert tls.Certificate
+}
+
+func newTestCertificate(t *testing.T, subject pkix.Name, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		tlsCert: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+// writePEM writes the certificate and its key to PEM files and returns their paths.
+func (c *testCertificate) writePEM(t *testing.T, dir string, name string) (string, string) {
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	require.NoError(t, ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certPath, keyPath
+}
+
+func TestTCPWithTLSShouldReceivesMessages(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, pkix.Name{CommonName: "ca"}, nil)
+	server := newTestCertificate(t, pkix.Name{CommonName: "server"}, ca)
+	certPath, keyPath := server.writePEM(t, dir, "server")
+
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	listener := NewTCPListener(pp, config.NewLogSource("", &config.LogsConfig{Port: tcpTestPort, TLSCert: certPath, TLSKey: keyPath}), 9000)
+	listener.Start()
+	defer listener.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	conn, err := tls.Dial("tcp", listener.listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "hello world\n")
+	msg := <-msgChan
+	assert.Equal(t, "hello world", string(msg.Content))
+	assert.Empty(t, msg.Origin.Tags())
+}
+
+func TestTCPWithMutualTLSShouldTagMessagesWithClientSubject(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, pkix.Name{CommonName: "ca"}, nil)
+	certPath, keyPath := newTestCertificate(t, pkix.Name{CommonName: "server"}, ca).writePEM(t, dir, "server")
+	caPath, _ := ca.writePEM(t, dir, "ca")
+	client := newTestCertificate(t, pkix.Name{Country: []string{"US"}, Organization: []string{"Acme, Inc."}, CommonName: "router-1"}, ca)
+
+	pp := mock.NewMockProvider()
+	msgChan := pp.NextPipelineChan()
+	source := config.NewLogSource("", &config.LogsConfig{Port: tcpTestPort, TLSCert: certPath, TLSKey: keyPath, TLSClientCA: caPath})
+	listener := NewTCPListener(pp, source, 9000)
+	listener.Start()
+	defer listener.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "127.0.0.1", Certificates: []tls.Certificate{client.tlsCert}}
+	conn, err := tls.Dial("tcp", listener.listener.Addr().String(), clientConfig)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "hello world\n")
+	msg := <-msgChan
+	assert.Equal(t, "hello world", string(msg.Content))
+	assert.Equal(t, []string{"client_cert_subject:/C=US/O=Acme Inc./CN=router-1"}, msg.Origin.Tags())
+
+	// a client without certificate is rejected
+	conn, err = tls.Dial("tcp", listener.listener.Addr().String(), &tls.Config{RootCAs: roots, ServerName: "127.0.0.1"})
+	if err == nil {
+		// with TLS 1.3 the client learns about the failure on its first read
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, err = conn.Read(make([]byte, 1))
+	}
+	assert.Error(t, err)
+}
+
+func TestTCPWithInvalidTLSConfigShouldFail(t *testing.T) {
+	pp := mock.NewMockProvider()
+	source := config.NewLogSource("", &config.LogsConfig{Port: tcpTestPort, TLSCert: "/does/not/exist.crt", TLSKey: "/does/not/exist.key"})
+	listener := NewTCPListener(pp, source, 9000)
+	listener.Start()
+	assert.True(t, source.Status.IsError())
+	listener.Stop()
+}
@@ -24,6 +24,16 @@ var (
 	// TlmLogsProcessed is the total number of processed logs.
 	TlmLogsProcessed = telemetry.NewCounter("logs", "processed",
 		nil, "Total number of processed logs")
+	// LogsThrottled is the total number of logs dropped by sample and rate_limit processing rules.
+	LogsThrottled = expvar.Int{}
+	// TlmLogsThrottled is the total number of logs dropped by sample and rate_limit processing rules.
+	TlmLogsThrottled = telemetry.NewCounter("logs", "throttled",
+		[]string{"rule_type"}, "Total number of logs dropped by sample and rate_limit processing rules")
+	// LogsDeduplicated is the total number of logs collapsed into an identical log.
+	LogsDeduplicated = expvar.Int{}
+	// TlmLogsDeduplicated is the total number of logs collapsed into an identical log.
+	TlmLogsDeduplicated = telemetry.NewCounter("logs", "deduplicated",
+		nil, "Total number of logs collapsed into an identical log")
 
 	// LogsSent is the total number of sent logs.
 	LogsSent = expvar.Int{}
@@ -66,6 +76,8 @@ func init() {
 	LogsExpvars = expvar.NewMap("logs-agent")
 	LogsExpvars.Set("LogsDecoded", &LogsDecoded)
 	LogsExpvars.Set("LogsProcessed", &LogsProcessed)
+	LogsExpvars.Set("LogsThrottled", &LogsThrottled)
+	LogsExpvars.Set("LogsDeduplicated", &LogsDeduplicated)
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
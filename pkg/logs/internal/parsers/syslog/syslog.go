@@ -0,0 +1,339 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog implements a Parser for the syslog messages of RFC 5424 and RFC 3164.
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const (
+	// HostnameTag is the tag holding the hostname of the sender of a message.
+	HostnameTag = "syslog_hostname"
+	// AppNameTag is the tag holding the application that sent a message.
+	AppNameTag = "syslog_appname"
+
+	nilValue = "-"
+	// rfc3164TimestampLen is the length of a `Mmm dd hh:mm:ss` timestamp.
+	rfc3164TimestampLen    = 15
+	rfc3164TimestampFormat = "Jan _2 15:04:05"
+)
+
+// severityStatuses maps the severities of the messages to statuses.
+var severityStatuses = [8]string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+// rfc3164TagPattern matches the `TAG[PID]:` prefix of the content of an RFC 3164 message.
+var rfc3164TagPattern = regexp.MustCompile(`^([^\s:\[\]]{1,48})(?:\[([^\]]*)\])?:`)
+
+// utf8BOM may prefix the message of an RFC 5424 message.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// New returns a new parser which will parse syslog messages.
+//
+// For example:
+//
+//	`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`
+//
+// returns:
+//
+//	parsers.Message {
+//	    Content: []byte(`{"message":"An application event","syslog":{"facility":20,"severity":5,...}}`),
+//	    Status: "notice",
+//	    Timestamp: "2003-10-11T22:14:15.003000000Z",
+//	    Tags: []string{"syslog_hostname:mymachine.example.com", "syslog_appname:evntslog"},
+//	}
+func New() parsers.Parser {
+	return &syslogFormat{now: time.Now}
+}
+
+type syslogFormat struct {
+	now func() time.Time
+}
+
+// attributes holds the header and the structured data of a message.
+type attributes struct {
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appname,omitempty"`
+	ProcID         string                       `json:"procid,omitempty"`
+	MsgID          string                       `json:"msgid,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+}
+
+// payload is the content of a parsed message, the syslog attributes are bundled in a `syslog` attribute.
+type payload struct {
+	Message string     `json:"message"`
+	Syslog  attributes `json:"syslog"`
+}
+
+// Parse implements Parser#Parse
+func (p *syslogFormat) Parse(data []byte) (parsers.Message, error) {
+	priority, rest, err := parsePriority(data)
+	if err != nil {
+		return parsers.Message{Content: data}, err
+	}
+	attrs := attributes{
+		Facility: priority / 8,
+		Severity: priority % 8,
+	}
+	status := severityStatuses[attrs.Severity]
+
+	var ts time.Time
+	var msg []byte
+	if isRFC5424(rest) {
+		ts, msg, err = parseRFC5424(rest, &attrs)
+	} else {
+		ts, msg = p.parseRFC3164(rest, &attrs)
+	}
+	if err != nil {
+		return parsers.Message{Content: data, Status: status}, err
+	}
+
+	content, err := json.Marshal(payload{Message: string(msg), Syslog: attrs})
+	if err != nil {
+		return parsers.Message{Content: data, Status: status}, err
+	}
+	var timestamp string
+	if !ts.IsZero() {
+		timestamp = ts.UTC().Format(config.DateFormat)
+	}
+	var tags []string
+	if attrs.Hostname != "" {
+		tags = append(tags, HostnameTag+":"+attrs.Hostname)
+	}
+	if attrs.AppName != "" {
+		tags = append(tags, AppNameTag+":"+attrs.AppName)
+	}
+	return parsers.Message{
+		Content:   content,
+		Status:    status,
+		Timestamp: timestamp,
+		Tags:      tags,
+	}, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *syslogFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// parsePriority parses the `<PRI>` prefix of a message.
+func parsePriority(data []byte) (int, []byte, error) {
+	end := bytes.IndexByte(data, '>')
+	if len(data) == 0 || data[0] != '<' || end < 2 || end > 4 {
+		return 0, nil, fmt.Errorf("cannot parse syslog message, missing priority")
+	}
+	priority, err := strconv.Atoi(string(data[1:end]))
+	if err != nil || priority < 0 || priority > 191 {
+		return 0, nil, fmt.Errorf("cannot parse syslog message, invalid priority %q", data[1:end])
+	}
+	return priority, data[end+1:], nil
+}
+
+// isRFC5424 returns true if the header starts with a version, `1 `.
+func isRFC5424(header []byte) bool {
+	i := 0
+	for i < len(header) && i < 3 && header[i] >= '0' && header[i] <= '9' {
+		i++
+	}
+	return i > 0 && header[0] != '0' && i < len(header) && header[i] == ' '
+}
+
+// parseRFC5424 parses `VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]`.
+func parseRFC5424(data []byte, attrs *attributes) (time.Time, []byte, error) {
+	r := &reader{data: data}
+	var ts time.Time
+	attrs.Version, _ = strconv.Atoi(r.field())
+	if field := r.field(); field != nilValue {
+		var err error
+		if ts, err = time.Parse(time.RFC3339Nano, field); err != nil {
+			return ts, nil, fmt.Errorf("cannot parse syslog message, invalid timestamp %q", field)
+		}
+	}
+	attrs.Hostname = nilToEmpty(r.field())
+	attrs.AppName = nilToEmpty(r.field())
+	attrs.ProcID = nilToEmpty(r.field())
+	attrs.MsgID = nilToEmpty(r.field())
+	if r.done() {
+		return ts, nil, fmt.Errorf("cannot parse syslog message, missing structured data")
+	}
+	if r.peek() == '-' {
+		r.pos++
+	} else {
+		sd, err := r.structuredData()
+		if err != nil {
+			return ts, nil, err
+		}
+		attrs.StructuredData = sd
+	}
+	if !r.done() && r.peek() == ' ' {
+		r.pos++
+	}
+	return ts, bytes.TrimPrefix(r.rest(), utf8BOM), nil
+}
+
+// parseRFC3164 parses `TIMESTAMP SP HOSTNAME SP TAG[PID]: MSG` leniently, as the
+// format is not strictly followed by the senders, the parts that cannot be parsed
+// are left in the message.
+func (p *syslogFormat) parseRFC3164(data []byte, attrs *attributes) (time.Time, []byte) {
+	var ts time.Time
+	rest := data
+	if len(rest) >= rfc3164TimestampLen {
+		if parsed, err := time.ParseInLocation(rfc3164TimestampFormat, string(rest[:rfc3164TimestampLen]), time.Local); err == nil {
+			// the timestamp has no year, pick the one that does not put the message in the future
+			now := p.now()
+			ts = parsed.AddDate(now.Year(), 0, 0)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			rest = rest[rfc3164TimestampLen:]
+		}
+	}
+	if ts.IsZero() {
+		// some senders use an RFC 3339 timestamp instead
+		if end := bytes.IndexByte(rest, ' '); end > 0 {
+			if parsed, err := time.Parse(time.RFC3339Nano, string(rest[:end])); err == nil {
+				ts = parsed
+				rest = rest[end:]
+			}
+		}
+	}
+	rest = bytes.TrimLeft(rest, " ")
+
+	if !ts.IsZero() && !rfc3164TagPattern.Match(rest) {
+		if end := bytes.IndexByte(rest, ' '); end > 0 {
+			attrs.Hostname = string(rest[:end])
+			rest = bytes.TrimLeft(rest[end:], " ")
+		}
+	}
+	if match := rfc3164TagPattern.FindSubmatch(rest); match != nil {
+		attrs.AppName = string(match[1])
+		attrs.ProcID = string(match[2])
+		rest = bytes.TrimLeft(rest[len(match[0]):], " ")
+	}
+	return ts, rest
+}
+
+func nilToEmpty(field string) string {
+	if field == nilValue {
+		return ""
+	}
+	return field
+}
+
+// reader reads the fields of an RFC 5424 message.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) done() bool {
+	return r.pos >= len(r.data)
+}
+
+func (r *reader) peek() byte {
+	return r.data[r.pos]
+}
+
+func (r *reader) rest() []byte {
+	return r.data[r.pos:]
+}
+
+// field reads a space separated header field and the following space.
+func (r *reader) field() string {
+	start := r.pos
+	for !r.done() && r.peek() != ' ' {
+		r.pos++
+	}
+	field := string(r.data[start:r.pos])
+	if !r.done() {
+		r.pos++
+	}
+	return field
+}
+
+// structuredData reads the `[SD-ID SD-PARAM...]` elements.
+func (r *reader) structuredData() (map[string]map[string]string, error) {
+	sd := make(map[string]map[string]string)
+	for !r.done() && r.peek() == '[' {
+		r.pos++
+		start := r.pos
+		for !r.done() && r.peek() != ' ' && r.peek() != ']' {
+			r.pos++
+		}
+		if r.done() || r.pos == start {
+			return nil, fmt.Errorf("cannot parse syslog message, invalid structured data")
+		}
+		params := make(map[string]string)
+		sd[string(r.data[start:r.pos])] = params
+		for {
+			for !r.done() && r.peek() == ' ' {
+				r.pos++
+			}
+			if r.done() {
+				return nil, fmt.Errorf("cannot parse syslog message, unterminated structured data")
+			}
+			if r.peek() == ']' {
+				r.pos++
+				break
+			}
+			name, value, err := r.param()
+			if err != nil {
+				return nil, err
+			}
+			params[name] = value
+		}
+	}
+	return sd, nil
+}
+
+// param reads a `PARAM-NAME="PARAM-VALUE"` pair, where `"`, `\` and `]` are escaped in the value.
+func (r *reader) param() (string, string, error) {
+	start := r.pos
+	for !r.done() && r.peek() != '=' && r.peek() != ' ' && r.peek() != ']' {
+		r.pos++
+	}
+	if r.pos == start || r.pos+1 >= len(r.data) || r.peek() != '=' || r.data[r.pos+1] != '"' {
+		return "", "", fmt.Errorf("cannot parse syslog message, invalid structured data parameter")
+	}
+	name := string(r.data[start:r.pos])
+	r.pos += 2
+	var value []byte
+	for !r.done() {
+		c := r.peek()
+		r.pos++
+		switch {
+		case c == '\\' && !r.done() && (r.peek() == '"' || r.peek() == '\\' || r.peek() == ']'):
+			value = append(value, r.peek())
+			r.pos++
+		case c == '"':
+			return name, string(value), nil
+		default:
+			value = append(value, c)
+		}
+	}
+	return "", "", fmt.Errorf("cannot parse syslog message, unterminated structured data parameter")
+}
@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newTestParser(now time.Time) *syslogFormat {
+	return &syslogFormat{now: func() time.Time { return now }}
+}
+
+func decodePayload(t *testing.T, content []byte) payload {
+	var p payload
+	require.NoError(t, json.Unmarshal(content, &p))
+	return p
+}
+
+func TestParseRFC5424(t *testing.T) {
+	parser := New()
+	msg, err := parser.Parse([]byte(`<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="Application" eventID="1011"][examplePriority@32473 class="high"] An application event`))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "2003-10-11T22:14:15.003000000Z", msg.Timestamp)
+	assert.Equal(t, []string{"syslog_hostname:mymachine.example.com", "syslog_appname:evntslog"}, msg.Tags)
+	assert.False(t, msg.IsPartial)
+	assert.Equal(t, payload{
+		Message: "An application event",
+		Syslog: attributes{
+			Facility: 20,
+			Severity: 5,
+			Version:  1,
+			Hostname: "mymachine.example.com",
+			AppName:  "evntslog",
+			ProcID:   "1234",
+			MsgID:    "ID47",
+			StructuredData: map[string]map[string]string{
+				"exampleSDID@32473":     {"iut": "3", "eventSource": "Application", "eventID": "1011"},
+				"examplePriority@32473": {"class": "high"},
+			},
+		},
+	}, decodePayload(t, msg.Content))
+}
+
+func TestParseRFC5424NilValues(t *testing.T) {
+	parser := New()
+	msg, err := parser.Parse([]byte("<34>1 - - - - - -"))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Nil(t, msg.Tags)
+	assert.Equal(t, payload{Syslog: attributes{Facility: 4, Severity: 2, Version: 1}}, decodePayload(t, msg.Content))
+
+	msg, err = parser.Parse([]byte("<14>1 2021-01-01T00:00:00+01:00 host app - - - \xef\xbb\xbfmessage with a BOM"))
+	require.NoError(t, err)
+	assert.Equal(t, "2020-12-31T23:00:00.000000000Z", msg.Timestamp)
+	assert.Equal(t, "message with a BOM", decodePayload(t, msg.Content).Message)
+}
+
+func TestParseRFC5424EscapedStructuredData(t *testing.T) {
+	parser := New()
+	msg, err := parser.Parse([]byte(`<14>1 - host app - - [id@1 a="quote \" bracket \] backslash \\ other \n"] hello`))
+	require.NoError(t, err)
+	p := decodePayload(t, msg.Content)
+	assert.Equal(t, "hello", p.Message)
+	assert.Equal(t, map[string]map[string]string{"id@1": {"a": `quote " bracket ] backslash \ other \n`}}, p.Syslog.StructuredData)
+}
+
+func TestParseRFC5424Invalid(t *testing.T) {
+	parser := New()
+	for _, data := range []string{
+		`<14>1 yesterday host app - - - hello`,
+		`<14>1 - host app - -`,
+		`<14>1 - host app - - [id@1 a="unterminated] hello`,
+		`<14>1 - host app - - [id@1 a=3] hello`,
+	} {
+		msg, err := parser.Parse([]byte(data))
+		assert.Error(t, err, data)
+		assert.Equal(t, data, string(msg.Content))
+		assert.Equal(t, message.StatusInfo, msg.Status)
+	}
+}
+
+func TestParseRFC3164(t *testing.T) {
+	now := time.Date(2021, time.October, 12, 10, 0, 0, 0, time.Local)
+	parser := newTestParser(now)
+
+	msg, err := parser.Parse([]byte("<34>Oct 11 22:14:15 mymachine su[42]: 'su root' failed for lonvick on /dev/pts/8"))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	assert.Equal(t, time.Date(2021, time.October, 11, 22, 14, 15, 0, time.Local).UTC().Format("2006-01-02T15:04:05.000000000Z"), msg.Timestamp)
+	assert.Equal(t, []string{"syslog_hostname:mymachine", "syslog_appname:su"}, msg.Tags)
+	assert.Equal(t, payload{
+		Message: "'su root' failed for lonvick on /dev/pts/8",
+		Syslog: attributes{
+			Facility: 4,
+			Severity: 2,
+			Hostname: "mymachine",
+			AppName:  "su",
+			ProcID:   "42",
+		},
+	}, decodePayload(t, msg.Content))
+}
+
+func TestParseRFC3164PreviousYear(t *testing.T) {
+	now := time.Date(2022, time.January, 1, 0, 5, 0, 0, time.Local)
+	parser := newTestParser(now)
+
+	msg, err := parser.Parse([]byte("<13>Dec 31 23:59:59 host app: hello"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, time.December, 31, 23, 59, 59, 0, time.Local).UTC().Format("2006-01-02T15:04:05.000000000Z"), msg.Timestamp)
+}
+
+func TestParseRFC3164Lenient(t *testing.T) {
+	parser := newTestParser(time.Now())
+
+	// no hostname
+	msg, err := parser.Parse([]byte("<13>Feb  5 17:32:18 sshd[123]: session opened"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"syslog_appname:sshd"}, msg.Tags)
+	assert.Equal(t, "session opened", decodePayload(t, msg.Content).Message)
+
+	// RFC 3339 timestamp
+	msg, err = parser.Parse([]byte("<13>2021-03-04T05:06:07Z host app: hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "2021-03-04T05:06:07.000000000Z", msg.Timestamp)
+	assert.Equal(t, []string{"syslog_hostname:host", "syslog_appname:app"}, msg.Tags)
+
+	// no header
+	msg, err = parser.Parse([]byte("<190>just a message"))
+	require.NoError(t, err)
+	assert.Equal(t, message.StatusInfo, msg.Status)
+	assert.Equal(t, "", msg.Timestamp)
+	assert.Nil(t, msg.Tags)
+	assert.Equal(t, payload{Message: "just a message", Syslog: attributes{Facility: 23, Severity: 6}}, decodePayload(t, msg.Content))
+}
+
+func TestParseInvalidPriority(t *testing.T) {
+	parser := New()
+	for _, data := range []string{"", "no priority", "<>1 - - - - - -", "<192>hello", "<1a>hello", "<12345>hello"} {
+		msg, err := parser.Parse([]byte(data))
+		assert.Error(t, err, data)
+		assert.Equal(t, data, string(msg.Content))
+		assert.Equal(t, "", msg.Status)
+	}
+}
@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const (
+	// dedupMaxPending bounds the number of messages held by a deduplicator,
+	// the oldest messages are released early when it is reached.
+	dedupMaxPending = 10000
+	// dedupFlushInterval is the interval at which the messages held for a complete window are released.
+	dedupFlushInterval = 200 * time.Millisecond
+	// repeatCountTag is the tag added to the messages that collapsed identical messages.
+	repeatCountTag = "repeat_count"
+)
+
+// inputKey identifies an input of a source, whose offsets are committed by the auditor.
+type inputKey struct {
+	source     *config.LogSource
+	identifier string
+}
+
+// dedupKey identifies the identical messages of an input of a source.
+type dedupKey struct {
+	inputKey
+	content string
+}
+
+// pendingMessage is a message held by a deduplicator.
+type pendingMessage struct {
+	key         dedupKey
+	msg         *message.Message
+	redactedMsg []byte
+	count       int
+	expiresAt   time.Time
+	// prevOffset is the offset of the message of the input preceding msg.
+	prevOffset string
+}
+
+// dedupInput tracks the messages of an input held by a deduplicator, so that the
+// offsets of the released messages never move past a message still held.
+type dedupInput struct {
+	// held lists the held messages of the input, in order of arrival.
+	held []*pendingMessage
+	// lastOffset is the offset of the last message of the input, collapsed or not.
+	lastOffset string
+}
+
+// deduplicator holds messages for a time window, during which the identical
+// messages of the same source are collapsed into the held one.
+type deduplicator struct {
+	window       time.Duration
+	afterMasking bool
+	pending      map[dedupKey]*pendingMessage
+	queue        []*pendingMessage
+	inputs       map[inputKey]*dedupInput
+	mu           sync.Mutex
+}
+
+// newDeduplicator returns a new deduplicator, or nil if deduplication is disabled.
+func newDeduplicator(dedupConfig config.DedupConfig) *deduplicator {
+	if dedupConfig.Window <= 0 {
+		return nil
+	}
+	return &deduplicator{
+		window:       dedupConfig.Window,
+		afterMasking: dedupConfig.AfterMasking,
+		pending:      make(map[dedupKey]*pendingMessage),
+		inputs:       make(map[inputKey]*dedupInput),
+	}
+}
+
+// add holds the message, or collapses it if an identical message is already held,
+// and returns the messages released to stay within bounds.
+func (d *deduplicator) add(msg *message.Message, redactedMsg []byte, now time.Time) []*pendingMessage {
+	content := msg.Content
+	if d.afterMasking {
+		content = redactedMsg
+	}
+	key := dedupKey{
+		inputKey: inputKey{
+			source:     msg.Origin.LogSource,
+			identifier: msg.Origin.Identifier,
+		},
+		content: string(content),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	input, ok := d.inputs[key.inputKey]
+	if !ok {
+		input = &dedupInput{}
+		d.inputs[key.inputKey] = input
+	}
+	prevOffset := input.lastOffset
+	input.lastOffset = msg.Origin.Offset
+	if p, exists := d.pending[key]; exists {
+		p.count++
+		p.msg.IngestionTimestamp = msg.IngestionTimestamp
+		metrics.LogsDeduplicated.Add(1)
+		metrics.TlmLogsDeduplicated.Inc()
+		return nil
+	}
+
+	var released []*pendingMessage
+	if len(d.queue) >= dedupMaxPending {
+		released = d.release(1)
+	}
+	p := &pendingMessage{
+		key:         key,
+		msg:         msg,
+		redactedMsg: redactedMsg,
+		count:       1,
+		expiresAt:   now.Add(d.window),
+		prevOffset:  prevOffset,
+	}
+	d.pending[key] = p
+	d.queue = append(d.queue, p)
+	input.held = append(input.held, p)
+	return released
+}
+
+// expired releases the messages held for a complete window.
+func (d *deduplicator) expired(now time.Time) []*pendingMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	n := 0
+	for n < len(d.queue) && !d.queue[n].expiresAt.After(now) {
+		n++
+	}
+	return d.release(n)
+}
+
+// flush releases all the held messages.
+func (d *deduplicator) flush() []*pendingMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.release(len(d.queue))
+}
+
+// release removes the n oldest messages and annotates them with their repeat count,
+// it must be called with the lock held.
+// The offset of a released message is moved past the messages collapsed into it, but
+// only up to the message preceding the oldest message of its input still held, so
+// that the auditor commits the offsets of an input in order.
+func (d *deduplicator) release(n int) []*pendingMessage {
+	if n == 0 {
+		return nil
+	}
+	released := d.queue[:n:n]
+	d.queue = d.queue[n:]
+	for _, p := range released {
+		delete(d.pending, p.key)
+		if p.count > 1 {
+			p.msg.Origin.AddTags(repeatCountTag + ":" + strconv.Itoa(p.count))
+		}
+		// the messages are released in order of arrival, p is the oldest held of its input
+		input := d.inputs[p.key.inputKey]
+		input.held = input.held[1:]
+		if len(input.held) > 0 {
+			p.msg.Origin.Offset = input.held[0].prevOffset
+		} else {
+			p.msg.Origin.Offset = input.lastOffset
+			delete(d.inputs, p.key.inputKey)
+		}
+	}
+	return released
+}
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestDeduplicatorDisabled(t *testing.T) {
+	assert.Nil(t, newDeduplicator(config.DedupConfig{}))
+}
+
+func TestDeduplicatorCollapsesWithinWindow(t *testing.T) {
+	d := newDeduplicator(config.DedupConfig{Window: time.Second})
+	source := config.NewLogSource("", &config.LogsConfig{})
+	now := time.Now()
+
+	first := newOffsetMessage("crash", source, "1")
+	assert.Nil(t, d.add(first, first.Content, now))
+	msg := newOffsetMessage("other", source, "2")
+	assert.Nil(t, d.add(msg, msg.Content, now))
+	msg = newOffsetMessage("crash", source, "3")
+	assert.Nil(t, d.add(msg, msg.Content, now.Add(100*time.Millisecond)))
+
+	assert.Nil(t, d.expired(now.Add(999*time.Millisecond)))
+
+	released := d.expired(now.Add(time.Second))
+	assert.Len(t, released, 2)
+	assert.Equal(t, first, released[0].msg)
+	assert.Equal(t, 2, released[0].count)
+	// "other" is released after the collapsed messages, the offsets are committed in order
+	assert.Equal(t, "1", released[0].msg.Origin.Offset)
+	assert.Equal(t, []string{"repeat_count:2"}, released[0].msg.Origin.Tags())
+	assert.Equal(t, 1, released[1].count)
+	assert.Equal(t, "3", released[1].msg.Origin.Offset)
+	assert.Empty(t, released[1].msg.Origin.Tags())
+
+	// the window is over, identical messages are not collapsed anymore
+	msg = newOffsetMessage("crash", source, "4")
+	assert.Nil(t, d.add(msg, msg.Content, now.Add(time.Second)))
+	assert.Len(t, d.flush(), 1)
+	assert.Empty(t, d.pending)
+}
+
+func TestDeduplicatorCommitsOffsetsInOrder(t *testing.T) {
+	d := newDeduplicator(config.DedupConfig{Window: time.Second})
+	source := config.NewLogSource("", &config.LogsConfig{})
+	now := time.Now()
+
+	for i, content := range []string{"a", "b", "a", "c", "a"} {
+		msg := newOffsetMessage(content, source, strconv.Itoa(i+1))
+		d.add(msg, msg.Content, now.Add(time.Duration(i)*300*time.Millisecond))
+	}
+	// "a" expires while "b" and "c" are still held
+	released := d.expired(now.Add(time.Second))
+	assert.Len(t, released, 1)
+	assert.Equal(t, 3, released[0].count)
+	assert.Equal(t, "1", released[0].msg.Origin.Offset)
+
+	// "b" expires while "c" is still held
+	released = d.expired(now.Add(1300 * time.Millisecond))
+	assert.Len(t, released, 1)
+	assert.Equal(t, "3", released[0].msg.Origin.Offset)
+
+	// "c" is the last held message, the offset moves past all the messages
+	released = d.flush()
+	assert.Len(t, released, 1)
+	assert.Equal(t, "5", released[0].msg.Origin.Offset)
+	assert.Empty(t, d.inputs)
+}
+
+func TestDeduplicatorSeparatesSources(t *testing.T) {
+	d := newDeduplicator(config.DedupConfig{Window: time.Second})
+	now := time.Now()
+
+	msg := newOffsetMessage("crash", config.NewLogSource("", &config.LogsConfig{}), "1")
+	d.add(msg, msg.Content, now)
+	msg = newOffsetMessage("crash", config.NewLogSource("", &config.LogsConfig{}), "1")
+	d.add(msg, msg.Content, now)
+
+	assert.Len(t, d.flush(), 2)
+}
+
+func TestDeduplicatorAfterMasking(t *testing.T) {
+	source := config.NewLogSource("", &config.LogsConfig{})
+	now := time.Now()
+
+	for _, tc := range []struct {
+		afterMasking bool
+		released     int
+	}{
+		{afterMasking: true, released: 1},
+		{afterMasking: false, released: 2},
+	} {
+		d := newDeduplicator(config.DedupConfig{Window: time.Second, AfterMasking: tc.afterMasking})
+		msg := newOffsetMessage("request id=1", source, "1")
+		d.add(msg, []byte("request id=[masked]"), now)
+		msg = newOffsetMessage("request id=2", source, "2")
+		d.add(msg, []byte("request id=[masked]"), now)
+		assert.Len(t, d.flush(), tc.released)
+	}
+}
+
+func TestDeduplicatorBound(t *testing.T) {
+	d := newDeduplicator(config.DedupConfig{Window: time.Hour})
+	source := config.NewLogSource("", &config.LogsConfig{})
+	now := time.Now()
+
+	for i := 0; i < dedupMaxPending; i++ {
+		msg := newOffsetMessage(string(rune(i)), source, "")
+		assert.Nil(t, d.add(msg, msg.Content, now))
+	}
+	msg := newOffsetMessage("overflow", source, "")
+	released := d.add(msg, msg.Content, now)
+	assert.Len(t, released, 1)
+	assert.Equal(t, []byte{0}, released[0].msg.Content)
+	assert.Len(t, d.queue, dedupMaxPending)
+}
+
+func TestProcessorDeduplication(t *testing.T) {
+	inputChan := make(chan *message.Message, 10)
+	outputChan := make(chan *message.Message, 10)
+	p := New(inputChan, outputChan, nil, &identityEncoder{}, diagnostic.NewBufferedMessageReceiver(), config.DedupConfig{Window: time.Hour})
+	p.Start()
+
+	source := config.NewLogSource("", &config.LogsConfig{})
+	for i := 0; i < 3; i++ {
+		inputChan <- newMessage([]byte("crash"), source, "")
+	}
+	p.Stop()
+
+	assert.Len(t, outputChan, 1)
+	msg := <-outputChan
+	assert.Equal(t, []byte("crash"), msg.Content)
+	assert.Equal(t, []string{"repeat_count:3"}, msg.Origin.Tags())
+}
+
+type identityEncoder struct{}
+
+func (e *identityEncoder) Encode(msg *message.Message, redactedMsg []byte) ([]byte, error) {
+	return redactedMsg, nil
+}
+
+func newOffsetMessage(content string, source *config.LogSource, offset string) *message.Message {
+	msg := message.NewMessageWithSource([]byte(content), "", source, time.Now().UnixNano())
+	msg.Origin.Offset = offset
+	return msg
+}
@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// keyValuePattern matches the `key=value` and `key="quoted value"` pairs of a log line.
+var keyValuePattern = regexp.MustCompile(`([\w.\-]+)=("(?:[^"\\]|\\.)*"|[^\s,;]*)`)
+
+// statusAliases maps the commonly used level names to message statuses.
+var statusAliases = map[string]string{
+	"emerg":         message.StatusEmergency,
+	"emergency":     message.StatusEmergency,
+	"panic":         message.StatusEmergency,
+	"alert":         message.StatusAlert,
+	"crit":          message.StatusCritical,
+	"critical":      message.StatusCritical,
+	"fatal":         message.StatusCritical,
+	"severe":        message.StatusCritical,
+	"err":           message.StatusError,
+	"error":         message.StatusError,
+	"warn":          message.StatusWarning,
+	"warning":       message.StatusWarning,
+	"notice":        message.StatusNotice,
+	"info":          message.StatusInfo,
+	"information":   message.StatusInfo,
+	"informational": message.StatusInfo,
+	"debug":         message.StatusDebug,
+	"trace":         message.StatusDebug,
+}
+
+// applyExtractionRule extracts fields from the content of a message and promotes
+// them to the attributes of the message as defined by the remapping of the rule.
+func applyExtractionRule(rule *config.ProcessingRule, msg *message.Message, content []byte) {
+	fields := extractFields(rule, content)
+	if len(fields) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(rule.Remap))
+	for name := range rule.Remap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tags []string
+	for _, name := range names {
+		value, exists := fields[name]
+		if !exists || value == "" {
+			continue
+		}
+		switch target := rule.Remap[name]; target {
+		case config.RemapService:
+			msg.Origin.SetService(value)
+		case config.RemapStatus:
+			if status, exists := statusAliases[strings.ToLower(value)]; exists {
+				msg.SetStatus(status)
+			}
+		case config.RemapTimestamp:
+			if ts, err := parseTimestamp(value, rule.TimestampFormat); err == nil {
+				msg.Timestamp = ts
+			}
+		case config.RemapTag:
+			tags = append(tags, name+":"+value)
+		default:
+			tags = append(tags, strings.TrimPrefix(target, config.RemapTag+":")+":"+value)
+		}
+	}
+	if len(tags) > 0 {
+		msg.Origin.AddTags(tags...)
+	}
+}
+
+// extractFields returns the fields extracted by the rule from the content, indexed by lowercased name,
+// or nil if the content does not match the rule.
+func extractFields(rule *config.ProcessingRule, content []byte) map[string]string {
+	switch rule.Type {
+	case config.ExtractRegex, config.ExtractGrok:
+		match := rule.Regex.FindSubmatch(content)
+		if match == nil {
+			return nil
+		}
+		fields := make(map[string]string)
+		for i, name := range rule.Regex.SubexpNames() {
+			if name != "" && match[i] != nil {
+				fields[strings.ToLower(name)] = string(match[i])
+			}
+		}
+		return fields
+	case config.ExtractKeyValue:
+		if rule.Regex != nil && !rule.Regex.Match(content) {
+			return nil
+		}
+		fields := make(map[string]string)
+		for _, match := range keyValuePattern.FindAllSubmatch(content, -1) {
+			value := string(match[2])
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+			fields[strings.ToLower(string(match[1]))] = value
+		}
+		return fields
+	case config.ExtractJSON:
+		if rule.Regex != nil && !rule.Regex.Match(content) {
+			return nil
+		}
+		content = bytes.TrimSpace(content)
+		if len(content) == 0 || content[0] != '{' {
+			return nil
+		}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			return nil
+		}
+		fields := make(map[string]string)
+		flattenJSON("", object, fields)
+		return fields
+	}
+	return nil
+}
+
+// flattenJSON stores the scalar values of a JSON object in fields,
+// nested objects are flattened using dot-separated names.
+func flattenJSON(prefix string, object map[string]interface{}, fields map[string]string) {
+	for key, value := range object {
+		name := strings.ToLower(prefix + key)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenJSON(name+".", v, fields)
+		case string:
+			fields[name] = v
+		case json.Number:
+			fields[name] = v.String()
+		case bool:
+			fields[name] = strconv.FormatBool(v)
+		case nil:
+		default:
+			if encoded, err := json.Marshal(v); err == nil {
+				fields[name] = string(encoded)
+			}
+		}
+	}
+}
+
+// parseTimestamp parses an extracted timestamp with the given format and returns it in UTC.
+func parseTimestamp(value string, format string) (time.Time, error) {
+	switch format {
+	case "", "rfc3339":
+		ts, err := time.Parse(time.RFC3339Nano, value)
+		return ts.UTC(), err
+	case "unix", "unix_ms":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == "unix_ms" {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))).UTC(), nil
+	default:
+		ts, err := time.Parse(format, value)
+		return ts.UTC(), err
+	}
+}
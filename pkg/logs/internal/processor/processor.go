@@ -8,6 +8,7 @@ package processor
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -26,11 +27,12 @@ type Processor struct {
 	encoder                   Encoder
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
+	deduplicator              *deduplicator
 	mu                        sync.Mutex
 }
 
 // New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver, dedupConfig config.DedupConfig) *Processor {
 	return &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
@@ -38,6 +40,7 @@ func New(inputChan, outputChan chan *message.Message, processingRules []*config.
 		encoder:                   encoder,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		deduplicator:              newDeduplicator(dedupConfig),
 	}
 }
 
@@ -63,6 +66,7 @@ func (p *Processor) Flush(ctx context.Context) {
 			return
 		default:
 			if len(p.inputChan) == 0 {
+				p.flushDeduplicator()
 				return
 			}
 			msg := <-p.inputChan
@@ -76,10 +80,29 @@ func (p *Processor) run() {
 	defer func() {
 		p.done <- struct{}{}
 	}()
-	for msg := range p.inputChan {
-		p.processMessage(msg)
-		p.mu.Lock() // block here if we're trying to flush synchronously
-		p.mu.Unlock()
+
+	var flushTicker <-chan time.Time
+	if p.deduplicator != nil {
+		ticker := time.NewTicker(dedupFlushInterval)
+		defer ticker.Stop()
+		flushTicker = ticker.C
+	}
+
+	for {
+		select {
+		case msg, ok := <-p.inputChan:
+			if !ok {
+				p.flushDeduplicator()
+				return
+			}
+			p.processMessage(msg)
+			p.mu.Lock() // block here if we're trying to flush synchronously
+			p.mu.Unlock()
+		case now := <-flushTicker:
+			p.mu.Lock()
+			p.sendPendingMessages(p.deduplicator.expired(now))
+			p.mu.Unlock()
+		}
 	}
 }
 
@@ -90,21 +113,44 @@ func (p *Processor) processMessage(msg *message.Message) {
 		metrics.LogsProcessed.Add(1)
 		metrics.TlmLogsProcessed.Inc()
 
-		p.diagnosticMessageReceiver.HandleMessage(*msg, redactedMsg)
-
-		// Encode the message to its final format
-		content, err := p.encoder.Encode(msg, redactedMsg)
-		if err != nil {
-			log.Error("unable to encode msg ", err)
+		if p.deduplicator != nil {
+			p.sendPendingMessages(p.deduplicator.add(msg, redactedMsg, time.Now()))
 			return
 		}
-		msg.Content = content
-		p.outputChan <- msg
+		p.sendMessage(msg, redactedMsg)
+	}
+}
+
+// sendMessage encodes the message and pushes it to the outputChan.
+func (p *Processor) sendMessage(msg *message.Message, redactedMsg []byte) {
+	p.diagnosticMessageReceiver.HandleMessage(*msg, redactedMsg)
+
+	// Encode the message to its final format
+	content, err := p.encoder.Encode(msg, redactedMsg)
+	if err != nil {
+		log.Error("unable to encode msg ", err)
+		return
+	}
+	msg.Content = content
+	p.outputChan <- msg
+}
+
+func (p *Processor) sendPendingMessages(pending []*pendingMessage) {
+	for _, pm := range pending {
+		p.sendMessage(pm.msg, pm.redactedMsg)
+	}
+}
+
+// flushDeduplicator sends all the messages held by the deduplicator.
+func (p *Processor) flushDeduplicator() {
+	if p.deduplicator != nil {
+		p.sendPendingMessages(p.deduplicator.flush())
 	}
 }
 
 // applyRedactingRules returns given a message if we should process it or not,
-// and a copy of the message with some fields redacted, depending on config
+// and a copy of the message with some fields redacted, depending on config.
+// Extraction rules update the attributes of the message in place.
 func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
 	content := msg.Content
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
@@ -120,6 +166,12 @@ func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
 			}
 		case config.MaskSequences:
 			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+		case config.ExtractRegex, config.ExtractGrok, config.ExtractKeyValue, config.ExtractJSON:
+			applyExtractionRule(rule, msg, content)
+		case config.Sample, config.RateLimit:
+			if !applyThrottlingRule(rule, msg, content) {
+				return false, nil
+			}
 		}
 	}
 	return true, content
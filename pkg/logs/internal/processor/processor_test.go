@@ -8,6 +8,7 @@ package processor
 import (
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -156,3 +157,159 @@ func newSource(ruleType, replacePlaceholder, pattern string) config.LogSource {
 func newMessage(content []byte, source *config.LogSource, status string) *message.Message {
 	return message.NewMessageWithSource(content, status, source, 0)
 }
+
+func TestExtractRegex(t *testing.T) {
+	p := &Processor{}
+
+	rule := newExtractionRule(config.ExtractRegex, `^(?P<ts>\S+) (?P<level>\w+) \[(?P<svc>[^\]]+)\] user=(?P<user>\w+)`, map[string]string{
+		"ts":    config.RemapTimestamp,
+		"level": config.RemapStatus,
+		"svc":   config.RemapService,
+		"user":  "tag:username",
+	})
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	msg := newMessage([]byte("2021-01-02T03:04:05.123+01:00 WARNING [billing] user=bob payment failed"), &source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("2021-01-02T03:04:05.123+01:00 WARNING [billing] user=bob payment failed"), redactedMessage)
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, "billing", msg.Origin.Service())
+	assert.Equal(t, []string{"username:bob"}, msg.Origin.Tags())
+	assert.Equal(t, time.Date(2021, 1, 2, 2, 4, 5, 123000000, time.UTC), msg.Timestamp)
+
+	msg = newMessage([]byte("no match"), &source, "")
+	shouldProcess, _ = p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+	assert.Equal(t, "", msg.Origin.Service())
+	assert.Empty(t, msg.Origin.Tags())
+	assert.True(t, msg.Timestamp.IsZero())
+}
+
+func TestExtractGrokAfterMask(t *testing.T) {
+	mask := newProcessingRule(config.MaskSequences, "[masked]", `secret=\S+`)
+	rule := newExtractionRule(config.ExtractGrok, `^%{IP:client} %{WORD:method} %{URIPATH:path}`, map[string]string{
+		"client": config.RemapTag,
+		"path":   config.RemapTag,
+	})
+	p := &Processor{processingRules: []*config.ProcessingRule{mask}}
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	msg := newMessage([]byte("10.0.0.1 GET /api/v1/users secret=foo"), &source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, []byte("10.0.0.1 GET /api/v1/users [masked]"), redactedMessage)
+	assert.Equal(t, []string{"client:10.0.0.1", "path:/api/v1/users"}, msg.Origin.Tags())
+}
+
+func TestExtractKeyValue(t *testing.T) {
+	p := &Processor{}
+
+	rule := newExtractionRule(config.ExtractKeyValue, "", map[string]string{
+		"lvl":     config.RemapStatus,
+		"service": config.RemapService,
+		"time":    config.RemapTimestamp,
+		"region":  config.RemapTag,
+	})
+	rule.TimestampFormat = "unix_ms"
+	source := config.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	msg := newMessage([]byte(`time=1609459200500 lvl=ERR service="checkout api" region=us-east-1 msg="boom"`), &source, "")
+	shouldProcess, _ := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, "checkout api", msg.Origin.Service())
+	assert.Equal(t, []string{"region:us-east-1"}, msg.Origin.Tags())
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 500000000, time.UTC), msg.Timestamp)
+}
+
+func TestExtractJSON(t *testing.T) {
+	p := &Processor{}
+
+	rule := newExtractionRule(config.ExtractJSON, "", map[string]string{
+		"level":        config.RemapStatus,
+		"http.status":  "tag:status_code",
+		"ts":           config.RemapTimestamp,
+		"missingfield": config.RemapService,
+	})
+	rule.TimestampFormat = "unix"
+	source := config.LogSource{Config: &config.LogsConfig{Tags: []string{"env:prod"}, ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	msg := newMessage([]byte(`{"level":"debug","ts":1609459200,"http":{"status":404}}`), &source, "")
+	shouldProcess, _ := p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusDebug, msg.GetStatus())
+	assert.Equal(t, "", msg.Origin.Service())
+	assert.Equal(t, []string{"status_code:404", "env:prod"}, msg.Origin.Tags())
+	assert.Equal(t, time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), msg.Timestamp)
+
+	msg = newMessage([]byte(`level=error not json`), &source, "")
+	shouldProcess, _ = p.applyRedactingRules(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+}
+
+func newExtractionRule(ruleType, pattern string, remap map[string]string) *config.ProcessingRule {
+	rule := &config.ProcessingRule{
+		Type:    ruleType,
+		Name:    "test",
+		Pattern: pattern,
+		Remap:   remap,
+	}
+	if err := config.CompileProcessingRules([]*config.ProcessingRule{rule}); err != nil {
+		panic(err)
+	}
+	return rule
+}
+
+func TestSample(t *testing.T) {
+	rule := &config.ProcessingRule{Type: config.Sample, Name: "sample_debug", Pattern: "DEBUG", Keep: 2, Every: 5}
+	assert.Nil(t, config.CompileProcessingRules([]*config.ProcessingRule{rule}))
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	source := config.NewLogSource("", &config.LogsConfig{})
+
+	var kept int
+	for i := 0; i < 20; i++ {
+		if shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("DEBUG line"), source, "")); shouldProcess {
+			kept++
+		}
+		shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("INFO line"), source, ""))
+		assert.True(t, shouldProcess)
+	}
+	assert.Equal(t, 8, kept)
+	assert.Equal(t, []string{"12"}, source.GetInfo("Dropped by sample rule sample_debug").Info())
+
+	// the sampling state is tracked per source
+	otherSource := config.NewLogSource("", &config.LogsConfig{})
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("DEBUG line"), otherSource, ""))
+	assert.True(t, shouldProcess)
+}
+
+func TestRateLimit(t *testing.T) {
+	rule := &config.ProcessingRule{Type: config.RateLimit, Name: "cap", MaxPerSecond: 3}
+	source := config.NewLogSource("", &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}})
+	p := &Processor{}
+
+	var kept int
+	for i := 0; i < 10; i++ {
+		if shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("hello"), source, "")); shouldProcess {
+			kept++
+		}
+	}
+	assert.Equal(t, 3, kept)
+	assert.Equal(t, []string{"7"}, source.GetInfo("Dropped by rate_limit rule cap").Info())
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	limiter := newThrottle(&config.ProcessingRule{Type: config.RateLimit, Name: "cap", MaxPerSecond: 2})
+	now := time.Now()
+
+	assert.True(t, limiter.allow(now))
+	assert.True(t, limiter.allow(now.Add(100*time.Millisecond)))
+	assert.False(t, limiter.allow(now.Add(999*time.Millisecond)))
+	assert.True(t, limiter.allow(now.Add(time.Second)))
+	assert.True(t, limiter.allow(now.Add(1500*time.Millisecond)))
+	assert.False(t, limiter.allow(now.Add(1999*time.Millisecond)))
+	assert.Equal(t, []string{"2"}, limiter.Info())
+}
@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// throttle decides which of the lines of a source matched by a sample or a rate_limit rule are kept.
+// A throttle is registered as an info provider of the source so that it is shared by all the
+// pipelines processing the source, and so that the number of dropped lines shows up on the status page.
+type throttle interface {
+	config.InfoProvider
+	allow(now time.Time) bool
+}
+
+// applyThrottlingRule returns true if the message is kept by the sample or rate_limit rule.
+func applyThrottlingRule(rule *config.ProcessingRule, msg *message.Message, content []byte) bool {
+	if rule.Regex != nil && !rule.Regex.Match(content) {
+		return true
+	}
+	source := msg.Origin.LogSource
+	t, ok := source.LoadOrRegisterInfo(throttleInfoKey(rule), func() config.InfoProvider {
+		return newThrottle(rule)
+	}).(throttle)
+	if !ok {
+		return true
+	}
+	if t.allow(time.Now()) {
+		return true
+	}
+	metrics.LogsThrottled.Add(1)
+	metrics.TlmLogsThrottled.Inc(rule.Type)
+	return false
+}
+
+// throttleInfoKey returns the key of the throttle of the rule on the status page.
+func throttleInfoKey(rule *config.ProcessingRule) string {
+	return fmt.Sprintf("Dropped by %s rule %s", rule.Type, rule.Name)
+}
+
+func newThrottle(rule *config.ProcessingRule) throttle {
+	if rule.Type == config.Sample {
+		return &sampler{
+			key:     throttleInfoKey(rule),
+			keep:    int64(rule.Keep),
+			every:   int64(rule.Every),
+			seen:    atomic.NewInt64(0),
+			dropped: atomic.NewInt64(0),
+		}
+	}
+	return &rateLimiter{
+		key:          throttleInfoKey(rule),
+		maxPerSecond: rule.MaxPerSecond,
+		dropped:      atomic.NewInt64(0),
+	}
+}
+
+// sampler keeps the first `keep` lines out of every `every` lines.
+type sampler struct {
+	key     string
+	keep    int64
+	every   int64
+	seen    *atomic.Int64
+	dropped *atomic.Int64
+}
+
+func (s *sampler) allow(now time.Time) bool {
+	if (s.seen.Inc()-1)%s.every < s.keep {
+		return true
+	}
+	s.dropped.Inc()
+	return false
+}
+
+// InfoKey returns the key
+func (s *sampler) InfoKey() string {
+	return s.key
+}
+
+// Info returns the info
+func (s *sampler) Info() []string {
+	return []string{fmt.Sprintf("%d", s.dropped.Load())}
+}
+
+// rateLimiter keeps at most `maxPerSecond` lines per one second window.
+type rateLimiter struct {
+	key          string
+	maxPerSecond int
+	dropped      *atomic.Int64
+
+	mu          sync.Mutex
+	windowStart time.Time
+	count       int
+}
+
+func (r *rateLimiter) allow(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Sub(r.windowStart) >= time.Second {
+		r.windowStart = now
+		r.count = 0
+	}
+	if r.count < r.maxPerSecond {
+		r.count++
+		return true
+	}
+	r.dropped.Inc()
+	return false
+}
+
+// InfoKey returns the key
+func (r *rateLimiter) InfoKey() string {
+	return r.key
+}
+
+// Info returns the info
+func (r *rateLimiter) Info() []string {
+	return []string{fmt.Sprintf("%d", r.dropped.Load())}
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/DataDog/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// archiveReaders maps the extensions of the supported compressed archives to
+// the functions returning their decompressing readers.
+var archiveReaders = map[string]func(io.Reader) (io.ReadCloser, error){
+	".gz": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	".zst": func(r io.Reader) (io.ReadCloser, error) {
+		return zstd.NewReader(r), nil
+	},
+}
+
+// isArchivePath returns true if the path is the one of a compressed archive.
+func isArchivePath(path string) bool {
+	_, exists := archiveReaders[filepath.Ext(path)]
+	return exists
+}
+
+// setupArchive sets up the tailer of a compressed archive, the offset is the
+// number of decompressed bytes that have already been collected.
+func (t *Tailer) setupArchive(offset int64) error {
+	fullpath, err := filepath.Abs(t.file.Path)
+	if err != nil {
+		return err
+	}
+	t.fullpath = fullpath
+
+	// adds metadata to enable users to filter logs by filename
+	t.tags = t.buildTailerTags()
+
+	log.Info("Opening archive", t.file.Path, "for tailer key", t.file.GetScanKey())
+	f, err := openFile(fullpath)
+	if err != nil {
+		return err
+	}
+	t.updateFingerprint(f)
+	reader, err := archiveReaders[filepath.Ext(fullpath)](f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	skipped, err := io.CopyN(ioutil.Discard, reader, offset)
+	if err != nil && err != io.EOF {
+		reader.Close()
+		f.Close()
+		return err
+	}
+
+	t.osFile = f
+	t.archiveReader = reader
+	t.lastReadOffset.Store(skipped)
+	t.decodedOffset.Store(skipped)
+
+	return nil
+}
+
+// readArchive reads the decompressed content of an archive, once the end of
+// the archive is reached there is nothing more to read.
+func (t *Tailer) readArchive() (int, error) {
+	inBuf := make([]byte, 4096)
+	n, err := t.archiveReader.Read(inBuf)
+	if err == io.EOF {
+		t.archiveRead.Store(true)
+	} else if err != nil {
+		// an unexpected error occurred, stop the tailor
+		t.file.Source.Status.Error(err)
+		return 0, log.Error("Unexpected error occurred while reading archive: ", err)
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+	t.lastReadOffset.Add(int64(n))
+	return n, nil
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DataDog/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+const archiveContent = "hello world\nhello again\ngood bye\n"
+
+func writeArchive(t *testing.T, path string) {
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	if filepath.Ext(path) == ".gz" {
+		writer = gzip.NewWriter(&buf)
+	} else {
+		writer = zstd.NewWriter(&buf)
+	}
+	_, err := writer.Write([]byte(archiveContent))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.NoError(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+}
+
+func newArchiveTailer(path string, outputChan chan *message.Message) *Tailer {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	return NewTailer(outputChan, NewFile(path, source, false), 10*time.Millisecond, decoder.NewDecoderFromSource(source))
+}
+
+func TestIsArchive(t *testing.T) {
+	assert.True(t, NewFile("/var/log/app.log.1.gz", nil, false).IsArchive())
+	assert.True(t, NewFile("/var/log/app.log.1.zst", nil, false).IsArchive())
+	assert.False(t, NewFile("/var/log/app.log", nil, false).IsArchive())
+	assert.False(t, NewFile("/var/log/app.log.1", nil, false).IsArchive())
+}
+
+func TestTailArchive(t *testing.T) {
+	for _, name := range []string{"app.log.1.gz", "app.log.1.zst"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeArchive(t, path)
+
+			outputChan := make(chan *message.Message, 10)
+			tailer := newArchiveTailer(path, outputChan)
+			require.NoError(t, tailer.Start(0, io.SeekEnd))
+
+			msg := <-outputChan
+			assert.Equal(t, "hello world", string(msg.Content))
+			assert.Equal(t, "12", msg.Origin.Offset)
+			msg = <-outputChan
+			assert.Equal(t, "hello again", string(msg.Content))
+			assert.Equal(t, "24", msg.Origin.Offset)
+			msg = <-outputChan
+			assert.Equal(t, "good bye", string(msg.Content))
+			assert.Equal(t, auditor.CompletedOffset, msg.Origin.Offset)
+
+			<-tailer.done
+			assert.True(t, tailer.IsFinished())
+			assert.True(t, tailer.IsArchiveRead())
+		})
+	}
+}
+
+func TestTailArchiveFromOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.gz")
+	writeArchive(t, path)
+
+	outputChan := make(chan *message.Message, 10)
+	tailer := newArchiveTailer(path, outputChan)
+	require.NoError(t, tailer.Start(12, io.SeekStart))
+
+	msg := <-outputChan
+	assert.Equal(t, "hello again", string(msg.Content))
+	assert.Equal(t, "24", msg.Origin.Offset)
+	msg = <-outputChan
+	assert.Equal(t, "good bye", string(msg.Content))
+	assert.Equal(t, auditor.CompletedOffset, msg.Origin.Offset)
+	<-tailer.done
+}
+
+func TestTailInvalidArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.gz")
+	require.NoError(t, ioutil.WriteFile(path, []byte("not compressed\n"), 0644))
+
+	tailer := newArchiveTailer(path, make(chan *message.Message, 10))
+	assert.Error(t, tailer.Start(0, io.SeekStart))
+	assert.True(t, tailer.file.Source.Status.IsError())
+}
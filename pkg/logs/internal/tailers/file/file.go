@@ -44,3 +44,9 @@ func (t *File) GetScanKey() string {
 	}
 	return t.Path
 }
+
+// IsArchive returns true if the file is a compressed archive, archives are
+// decompressed and read once rather than tailed.
+func (t *File) IsArchive() bool {
+	return isArchivePath(t.Path)
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"hash/crc64"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// fingerprintTable is the table used to compute the checksums of the files.
+var fingerprintTable = crc64.MakeTable(crc64.ECMA)
+
+// computeFingerprint returns a checksum of the first size bytes of a file,
+// or an empty string if the file is shorter than size bytes.
+func computeFingerprint(f *os.File, size int) (string, error) {
+	buf := make([]byte, size)
+	n, err := f.ReadAt(buf, 0)
+	if n < size {
+		if err == io.EOF {
+			err = nil
+		}
+		return "", err
+	}
+	return strconv.FormatUint(crc64.Checksum(buf, fingerprintTable), 16), nil
+}
+
+// IsFingerprintEnabled returns true if the file is identified by a checksum
+// of its first bytes, rather than only by its path.
+func (t *Tailer) IsFingerprintEnabled() bool {
+	return t.fingerprintSize > 0
+}
+
+// ComputeFingerprint returns the fingerprint of the file currently found at the
+// path of the tailer, it is empty if the file is too short to be fingerprinted.
+func (t *Tailer) ComputeFingerprint() (string, error) {
+	f, err := openFile(t.file.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return computeFingerprint(f, t.fingerprintSize)
+}
+
+// Fingerprint returns the fingerprint of the file read by the tailer, it is
+// empty until the file is large enough to be fingerprinted.
+func (t *Tailer) Fingerprint() string {
+	return t.fingerprint.Load()
+}
+
+// updateFingerprint fingerprints the file read by the tailer if it has not been
+// done yet, as it was too short.
+func (t *Tailer) updateFingerprint(f *os.File) {
+	if !t.IsFingerprintEnabled() || t.fingerprint.Load() != "" {
+		return
+	}
+	fingerprint, err := computeFingerprint(f, t.fingerprintSize)
+	if err != nil {
+		log.Debugf("Could not fingerprint %s: %v", t.file.Path, err)
+		return
+	}
+	t.fingerprint.Store(fingerprint)
+}
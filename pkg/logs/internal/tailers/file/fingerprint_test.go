@@ -0,0 +1,105 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newFingerprintTailer(path string, fingerprintSize int) *Tailer {
+	source := config.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	tailer := NewTailer(make(chan *message.Message, 10), NewFile(path, source, false), 10*time.Millisecond, decoder.NewDecoderFromSource(source))
+	tailer.fingerprintSize = fingerprintSize
+	return tailer
+}
+
+func TestComputeFingerprint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello world\n"), 0644))
+	tailer := newFingerprintTailer(path, 8)
+
+	fingerprint, err := tailer.ComputeFingerprint()
+	require.NoError(t, err)
+	assert.NotEmpty(t, fingerprint)
+
+	// only the first bytes are used
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello wo, bye\n"), 0644))
+	other, err := tailer.ComputeFingerprint()
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint, other)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("bye world\n"), 0644))
+	other, err = tailer.ComputeFingerprint()
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+
+	// a file which is too short has no fingerprint
+	require.NoError(t, ioutil.WriteFile(path, []byte("hello\n"), 0644))
+	other, err = tailer.ComputeFingerprint()
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestDidRotateWithFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("first line\n"), 0644))
+	tailer := newFingerprintTailer(path, 8)
+	require.NoError(t, tailer.StartFromBeginning())
+	defer tailer.Stop()
+	<-tailer.outputChan
+	assert.NotEmpty(t, tailer.Fingerprint())
+
+	// the file is replaced by a copy, as it may look like on network filesystems
+	copyPath := filepath.Join(dir, "copy.log")
+	require.NoError(t, ioutil.WriteFile(copyPath, []byte("first line\nsecond line\n"), 0644))
+	require.NoError(t, os.Rename(copyPath, path))
+	didRotate, err := tailer.DidRotate()
+	require.NoError(t, err)
+	assert.False(t, didRotate)
+
+	// the file is truncated and rewritten with new content of the same size
+	require.NoError(t, ioutil.WriteFile(path, []byte("other line\nsecond line\n"), 0644))
+	didRotate, err = tailer.DidRotate()
+	require.NoError(t, err)
+	assert.True(t, didRotate)
+}
+
+func TestFingerprintOfShortFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("hi\n"), 0644))
+	tailer := newFingerprintTailer(path, 8)
+	require.NoError(t, tailer.StartFromBeginning())
+	defer tailer.Stop()
+	msg := <-tailer.outputChan
+	assert.Empty(t, msg.Origin.Fingerprint)
+
+	// the file is fingerprinted once it is large enough
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString("hello world\n")
+	require.NoError(t, err)
+	<-tailer.outputChan
+	didRotate, err := tailer.DidRotate()
+	require.NoError(t, err)
+	assert.False(t, didRotate)
+	assert.NotEmpty(t, tailer.Fingerprint())
+}
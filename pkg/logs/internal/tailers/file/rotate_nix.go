@@ -18,6 +18,11 @@ import (
 // - renamed and recreated
 // - removed and recreated
 // - truncated
+//
+// When fingerprinting is enabled, the file has been rotated when the content at
+// the beginning of the file has changed, which does not depend on the inode and
+// on the size, as those are not reliable on network filesystems and with
+// copytruncate.
 func (t *Tailer) DidRotate() (bool, error) {
 	f, err := openFile(t.osFile.Name())
 	if err != nil {
@@ -25,6 +30,15 @@ func (t *Tailer) DidRotate() (bool, error) {
 	}
 	defer f.Close()
 
+	t.updateFingerprint(t.osFile)
+	if fingerprint := t.fingerprint.Load(); fingerprint != "" {
+		current, err := computeFingerprint(f, t.fingerprintSize)
+		if err != nil {
+			return false, err
+		}
+		return current != fingerprint, nil
+	}
+
 	fi1, err := f.Stat()
 	if err != nil {
 		return false, err
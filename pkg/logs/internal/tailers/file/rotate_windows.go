@@ -13,7 +13,8 @@ import "github.com/DataDog/datadog-agent/pkg/util/log"
 // DidRotate returns true if the file has been log-rotated.
 //
 // On Windows, log rotation is identified by the file size being smaller
-// than the last offset read.
+// than the last offset read, or by the content at the beginning of the file
+// having changed when fingerprinting is enabled.
 func (t *Tailer) DidRotate() (bool, error) {
 	f, err := openFile(t.fullpath)
 	if err != nil {
@@ -21,6 +22,16 @@ func (t *Tailer) DidRotate() (bool, error) {
 	}
 	defer f.Close()
 
+	if fingerprint := t.fingerprint.Load(); fingerprint != "" {
+		current, err := computeFingerprint(f, t.fingerprintSize)
+		if err != nil {
+			return false, err
+		}
+		return current != fingerprint, nil
+	}
+	// the file is opened for each read, it can only be fingerprinted here
+	t.updateFingerprint(f)
+
 	st, err := f.Stat()
 	if err != nil {
 		log.Debugf("Error calling stat() on file %v", err)
@@ -20,6 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"go.uber.org/atomic"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/tag"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -60,6 +61,21 @@ type Tailer struct {
 	// is platform-specific.
 	osFile *os.File
 
+	// archiveReader decompresses the content of osFile when the file is a
+	// compressed archive, it is nil otherwise.
+	archiveReader io.ReadCloser
+
+	// archiveRead is true when the whole content of an archive has been read.
+	archiveRead *atomic.Bool
+
+	// fingerprintSize is the number of bytes at the beginning of the file used
+	// to fingerprint it, fingerprinting is disabled when it is 0.
+	fingerprintSize int
+
+	// fingerprint is the checksum of the first fingerprintSize bytes of the
+	// file, it is empty until the file is large enough.
+	fingerprint *atomic.String
+
 	// tags are the tags to be attached to each log message, excluding tags provided
 	// by the tag provider.
 	tags []string
@@ -126,22 +142,26 @@ func NewTailer(outputChan chan *message.Message, file *File, sleepDuration time.
 
 	forwardContext, stopForward := context.WithCancel(context.Background())
 	closeTimeout := coreConfig.Datadog.GetDuration("logs_config.close_timeout") * time.Second
+	fingerprintSize := coreConfig.Datadog.GetInt("logs_config.file_fingerprint_size")
 
 	return &Tailer{
-		file:           file,
-		outputChan:     outputChan,
-		decoder:        decoder,
-		tagProvider:    tagProvider,
-		lastReadOffset: atomic.NewInt64(0),
-		decodedOffset:  atomic.NewInt64(0),
-		sleepDuration:  sleepDuration,
-		closeTimeout:   closeTimeout,
-		stop:           make(chan struct{}, 1),
-		done:           make(chan struct{}, 1),
-		forwardContext: forwardContext,
-		stopForward:    stopForward,
-		isFinished:     atomic.NewBool(false),
-		didFileRotate:  atomic.NewBool(false),
+		file:            file,
+		outputChan:      outputChan,
+		decoder:         decoder,
+		tagProvider:     tagProvider,
+		lastReadOffset:  atomic.NewInt64(0),
+		decodedOffset:   atomic.NewInt64(0),
+		sleepDuration:   sleepDuration,
+		closeTimeout:    closeTimeout,
+		fingerprintSize: fingerprintSize,
+		fingerprint:     atomic.NewString(""),
+		stop:            make(chan struct{}, 1),
+		done:            make(chan struct{}, 1),
+		forwardContext:  forwardContext,
+		stopForward:     stopForward,
+		isFinished:      atomic.NewBool(false),
+		didFileRotate:   atomic.NewBool(false),
+		archiveRead:     atomic.NewBool(false),
 	}
 }
 
@@ -164,8 +184,15 @@ func (t *Tailer) Identifier() string {
 }
 
 // Start begins the tailer's operation in a dedicated goroutine.
+// Compressed archives are always read from the given offset in their
+// decompressed content, whence is ignored.
 func (t *Tailer) Start(offset int64, whence int) error {
-	err := t.setup(offset, whence)
+	var err error
+	if t.file.IsArchive() {
+		err = t.setupArchive(offset)
+	} else {
+		err = t.setup(offset, whence)
+	}
 	if err != nil {
 		t.file.Source.Status.Error(err)
 		return err
@@ -211,17 +238,30 @@ func (t *Tailer) StopAfterFileRotation() {
 // until it is closed or the tailer is stopped.
 func (t *Tailer) readForever() {
 	defer func() {
+		if t.archiveReader != nil {
+			t.archiveReader.Close()
+		}
 		t.osFile.Close()
 		t.decoder.Stop()
 		log.Info("Closed", t.file.Path, "for tailer key", t.file.GetScanKey(), "read", t.bytesRead, "bytes and", t.decoder.GetLineCount(), "lines")
 	}()
 
 	for {
-		n, err := t.read()
+		var n int
+		var err error
+		if t.archiveReader != nil {
+			n, err = t.readArchive()
+		} else {
+			n, err = t.read()
+		}
 		if err != nil {
 			return
 		}
 		t.recordBytes(int64(n))
+		if t.archiveRead.Load() {
+			// archives are read once, there is no new data to wait for
+			return
+		}
 
 		select {
 		case <-t.stop:
@@ -255,9 +295,24 @@ func (t *Tailer) IsFinished() bool {
 	return t.isFinished.Load()
 }
 
+// IsArchiveRead returns true if the tailer has read the whole content of
+// a compressed archive.
+func (t *Tailer) IsArchiveRead() bool {
+	return t.archiveRead.Load()
+}
+
 // forwardMessages lets the Tailer forward log messages to the output channel
 func (t *Tailer) forwardMessages() {
+	// the messages of an archive are held until the next one is decoded, so that
+	// the last one can mark the archive as completed in the registry.
+	var pending *message.Message
 	defer func() {
+		if pending != nil {
+			if t.archiveRead.Load() {
+				pending.Origin.Offset = auditor.CompletedOffset
+			}
+			t.forward(pending)
+		}
 		// the decoder has successfully been flushed
 		t.isFinished.Store(true)
 		close(t.done)
@@ -273,19 +328,32 @@ func (t *Tailer) forwardMessages() {
 		origin := message.NewOrigin(t.file.Source)
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
+		origin.Fingerprint = t.fingerprint.Load()
 		origin.SetTags(append(t.tags, t.tagProvider.GetTags()...))
 		// Ignore empty lines once the registry offset is updated
 		if len(output.Content) == 0 {
 			continue
 		}
-		// Make the write to the output chan cancellable to be able to stop the tailer
-		// after a file rotation when it is stuck on it.
-		// We don't return directly to keep the same shutdown sequence that in the
-		// normal case.
-		select {
-		case t.outputChan <- message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp):
-		case <-t.forwardContext.Done():
+		msg := message.NewMessage(output.Content, origin, output.Status, output.IngestionTimestamp)
+		if t.archiveReader != nil {
+			msg, pending = pending, msg
+			if msg == nil {
+				continue
+			}
 		}
+		t.forward(msg)
+	}
+}
+
+// forward sends a message to the output channel.
+func (t *Tailer) forward(msg *message.Message) {
+	// Make the write to the output chan cancellable to be able to stop the tailer
+	// after a file rotation when it is stuck on it.
+	// We don't return directly to keep the same shutdown sequence that in the
+	// normal case.
+	select {
+	case t.outputChan <- msg:
+	case <-t.forwardContext.Done():
 	}
 }
 
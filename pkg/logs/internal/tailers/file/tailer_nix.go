@@ -34,6 +34,7 @@ func (t *Tailer) setup(offset int64, whence int) error {
 	}
 
 	t.osFile = f
+	t.updateFingerprint(f)
 	ret, _ := f.Seek(offset, whence)
 	t.lastReadOffset.Store(ret)
 	t.decodedOffset.Store(ret)
@@ -8,12 +8,15 @@ package socket
 import (
 	"io"
 	"net"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/noop"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
 
@@ -24,6 +27,7 @@ type Tailer struct {
 	Conn       net.Conn
 	outputChan chan *message.Message
 	read       func(*Tailer) ([]byte, error)
+	tags       []string
 	decoder    *decoder.Decoder
 	stop       chan struct{}
 	done       chan struct{}
@@ -31,17 +35,32 @@ type Tailer struct {
 
 // NewTailer returns a new Tailer
 func NewTailer(source *config.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, error)) *Tailer {
+	return NewTailerWithTags(source, conn, outputChan, read, nil)
+}
+
+// NewTailerWithTags returns a new Tailer adding the tags to all the messages read from the connection
+func NewTailerWithTags(source *config.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, error), tags []string) *Tailer {
 	return &Tailer{
 		source:     source,
 		Conn:       conn,
 		outputChan: outputChan,
 		read:       read,
-		decoder:    decoder.InitializeDecoder(source, noop.New()),
+		tags:       tags,
+		decoder:    buildDecoder(source),
 		stop:       make(chan struct{}, 1),
 		done:       make(chan struct{}, 1),
 	}
 }
 
+// buildDecoder returns a decoder parsing the syslog messages of syslog sources,
+// and the raw lines of the other sources.
+func buildDecoder(source *config.LogSource) *decoder.Decoder {
+	if source.Config.Type == config.SyslogType {
+		return decoder.NewDecoderWithFraming(source, syslog.New(), framer.SyslogStream, nil)
+	}
+	return decoder.InitializeDecoder(source, noop.New())
+}
+
 // Start prepares the tailer to read and decode data from the connection
 func (t *Tailer) Start() {
 	go t.forwardMessages()
@@ -64,7 +83,22 @@ func (t *Tailer) forwardMessages() {
 	}()
 	for output := range t.decoder.OutputChan {
 		if len(output.Content) > 0 {
-			t.outputChan <- message.NewMessageWithSource(output.Content, message.StatusInfo, t.source, output.IngestionTimestamp)
+			status := output.Status
+			if status == "" {
+				status = message.StatusInfo
+			}
+			msg := message.NewMessageWithSource(output.Content, status, t.source, output.IngestionTimestamp)
+			if output.Timestamp != "" {
+				if ts, err := time.Parse(config.DateFormat, output.Timestamp); err == nil {
+					msg.Timestamp = ts
+				}
+			}
+			if len(t.tags) > 0 || len(output.Tags) > 0 {
+				tags := make([]string, 0, len(t.tags)+len(output.Tags))
+				tags = append(tags, t.tags...)
+				msg.Origin.SetTags(append(tags, output.Tags...))
+			}
+			t.outputChan <- msg
 		}
 	}
 }
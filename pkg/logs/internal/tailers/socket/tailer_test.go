@@ -7,8 +7,10 @@ package socket
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -39,6 +41,28 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardShouldParseSyslogMessages(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	tailer := NewTailer(config.NewLogSource("", &config.LogsConfig{Type: config.SyslogType}), r, msgChan, read)
+	tailer.Start()
+
+	syslogMsg := `<11>1 2021-03-04T05:06:07Z host app - - [meta@1 key="value"] octet counted`
+	go w.Write([]byte(fmt.Sprintf("%d %s<14>Mar  4 05:06:08 host app: newline terminated\n", len(syslogMsg), syslogMsg)))
+
+	msg := <-msgChan
+	assert.Equal(t, `{"message":"octet counted","syslog":{"facility":1,"severity":3,"version":1,"hostname":"host","appname":"app","structured_data":{"meta@1":{"key":"value"}}}}`, string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, time.Date(2021, time.March, 4, 5, 6, 7, 0, time.UTC), msg.Timestamp)
+	assert.Equal(t, []string{"syslog_hostname:host", "syslog_appname:app"}, msg.Origin.Tags())
+
+	msg = <-msgChan
+	assert.Equal(t, `{"message":"newline terminated","syslog":{"facility":1,"severity":6,"hostname":"host","appname":"app"}}`, string(msg.Content))
+	assert.Equal(t, message.StatusInfo, msg.GetStatus())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
@@ -32,7 +32,7 @@ type Message struct {
 	status             string
 	IngestionTimestamp int64
 	// Optional. Must be UTC. If not provided, time.Now().UTC() will be used
-	// Used in the Serverless Agent
+	// Used in the Serverless Agent and by the extraction processing rules
 	Timestamp time.Time
 	// Optional.
 	// Used in the Serverless Agent
@@ -84,6 +84,11 @@ func (m *Message) GetStatus() string {
 	return m.status
 }
 
+// SetStatus sets the status of the message.
+func (m *Message) SetStatus(status string) {
+	m.status = status
+}
+
 // GetLatency returns the latency delta from ingestion time until now
 func (m *Message) GetLatency() int64 {
 	return time.Now().UnixNano() - m.IngestionTimestamp
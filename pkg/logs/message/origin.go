@@ -16,9 +16,12 @@ type Origin struct {
 	Identifier string
 	LogSource  *config.LogSource
 	Offset     string
-	service    string
-	source     string
-	tags       []string
+	// Fingerprint identifies the content of the input when the offset is only
+	// valid for this content.
+	Fingerprint string
+	service     string
+	source      string
+	tags        []string
 }
 
 // NewOrigin returns a new Origin
@@ -90,6 +93,14 @@ func (o *Origin) SetTags(tags []string) {
 	o.tags = tags
 }
 
+// AddTags appends tags to the tags of the origin,
+// the slice previously given to SetTags is left untouched.
+func (o *Origin) AddTags(tags ...string) {
+	merged := make([]string, 0, len(o.tags)+len(tags))
+	merged = append(merged, o.tags...)
+	o.tags = append(merged, tags...)
+}
+
 // SetSource sets the source of the origin.
 func (o *Origin) SetSource(source string) {
 	o.source = source
@@ -8,6 +8,8 @@ package pipeline
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
@@ -17,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // Pipeline processes and sends messages to the backend
@@ -41,10 +44,8 @@ func NewPipeline(outputChan chan *message.Payload,
 	strategyInput := make(chan *message.Message, config.ChanSize)
 	senderInput := make(chan *message.Payload, 1) // Only buffer 1 message since payloads can be large
 
-	var logsSender *sender.Sender
-
 	strategy := getStrategy(strategyInput, senderInput, endpoints, serverless, pipelineID)
-	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	logsSender := getSender(senderInput, outputChan, mainDestinations, serverless, pipelineID)
 
 	var encoder processor.Encoder
 	if serverless {
@@ -58,7 +59,7 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver)
+	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver, config.GlobalDedupConfig())
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -100,6 +101,10 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 			telemetryName := fmt.Sprintf("logs_%d_unreliable_%d", pipelineID, i)
 			additionals = append(additionals, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, false, telemetryName))
 		}
+		for i, webhook := range endpoints.Webhooks {
+			telemetryName := fmt.Sprintf("logs_%d_webhook_%d", pipelineID, i)
+			additionals = append(additionals, http.NewWebhookDestination(webhook, destinationsContext, endpoints.BatchMaxConcurrentSend, telemetryName))
+		}
 		return client.NewDestinations(reliable, additionals)
 	}
 	for _, endpoint := range endpoints.GetReliableEndpoints() {
@@ -111,12 +116,23 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	return client.NewDestinations(reliable, additionals)
 }
 
+func getSender(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, serverless bool, pipelineID int) *sender.Sender {
+	bufferConfig := config.GlobalDiskBufferConfig()
+	if serverless || bufferConfig.MaxSizeInBytes == 0 {
+		return sender.NewSender(inputChan, outputChan, destinations, config.DestinationPayloadChanSize)
+	}
+	path := filepath.Join(bufferConfig.Path, strconv.Itoa(pipelineID))
+	buffer, err := sender.NewDiskBuffer(path, bufferConfig.MaxSizeInBytes, bufferConfig.MaxDiskRatio)
+	if err != nil {
+		log.Errorf("Could not create the logs disk buffer in %s, payloads are kept in memory: %v", path, err)
+		return sender.NewSender(inputChan, outputChan, destinations, config.DestinationPayloadChanSize)
+	}
+	return sender.NewSenderWithDiskBuffer(inputChan, outputChan, destinations, config.DestinationPayloadChanSize, buffer)
+}
+
 func getStrategy(inputChan chan *message.Message, outputChan chan *message.Payload, endpoints *config.Endpoints, serverless bool, pipelineID int) sender.Strategy {
 	if endpoints.UseHTTP || serverless {
-		encoder := sender.IdentityContentType
-		if endpoints.Main.UseCompression {
-			encoder = sender.NewGzipContentEncoding(endpoints.Main.CompressionLevel)
-		}
+		encoder := sender.NewContentEncodingForEndpoint(endpoints.Main)
 		return sender.NewBatchStrategy(inputChan, outputChan, sender.ArraySerializer, endpoints.BatchWait, endpoints.BatchMaxSize, endpoints.BatchMaxContentSize, "logs", encoder)
 	}
 	return sender.NewStreamStrategy(inputChan, outputChan)
@@ -8,6 +8,10 @@ package sender
 import (
 	"bytes"
 	"compress/gzip"
+
+	"github.com/DataDog/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
 )
 
 // ContentEncoding encodes the payload
@@ -71,3 +75,40 @@ func (c *GzipContentEncoding) encode(payload []byte) ([]byte, error) {
 	}
 	return compressedPayload.Bytes(), nil
 }
+
+// ZstdContentEncoding encodes the payload using zstd algorithm
+type ZstdContentEncoding struct {
+	level int
+}
+
+// NewZstdContentEncoding creates a new Zstd content type
+func NewZstdContentEncoding(level int) *ZstdContentEncoding {
+	if level < zstd.BestSpeed {
+		level = zstd.BestSpeed
+	} else if level > zstd.BestCompression {
+		level = zstd.BestCompression
+	}
+
+	return &ZstdContentEncoding{
+		level,
+	}
+}
+
+func (c *ZstdContentEncoding) name() string {
+	return "zstd"
+}
+
+func (c *ZstdContentEncoding) encode(payload []byte) ([]byte, error) {
+	return zstd.CompressLevel(nil, payload, c.level)
+}
+
+// NewContentEncodingForEndpoint returns the content encoding matching the compression settings of the endpoint
+func NewContentEncodingForEndpoint(endpoint config.Endpoint) ContentEncoding {
+	if !endpoint.UseCompression {
+		return IdentityContentType
+	}
+	if endpoint.CompressionKind == config.ZstdCompressionKind {
+		return NewZstdContentEncoding(endpoint.CompressionLevel)
+	}
+	return NewGzipContentEncoding(endpoint.CompressionLevel)
+}
@@ -8,9 +8,20 @@ package sender
 import (
 	"bytes"
 	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/DataDog/zstd"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	logshttp "github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
 
 func TestIdentityContentType(t *testing.T) {
@@ -42,6 +53,75 @@ func TestGzipContentEncodingName(t *testing.T) {
 	assert.Equal(t, NewGzipContentEncoding(gzip.BestCompression).name(), "gzip")
 }
 
+func TestZstdContentEncoding(t *testing.T) {
+	payload := []byte("my payload")
+
+	for _, level := range []int{-1, zstd.BestSpeed, zstd.DefaultCompression, zstd.BestCompression, 100} {
+		encodedPayload, err := NewZstdContentEncoding(level).encode(payload)
+		assert.Nil(t, err)
+
+		decompressedPayload, err := zstd.Decompress(nil, encodedPayload)
+		assert.Nil(t, err)
+
+		assert.Equal(t, payload, decompressedPayload)
+	}
+}
+
+func TestZstdContentEncodingName(t *testing.T) {
+	assert.Equal(t, NewZstdContentEncoding(zstd.DefaultCompression).name(), "zstd")
+}
+
+func TestNewContentEncodingForEndpoint(t *testing.T) {
+	assert.Equal(t, IdentityContentType, NewContentEncodingForEndpoint(config.Endpoint{UseCompression: false, CompressionKind: config.ZstdCompressionKind}))
+	assert.Equal(t, NewGzipContentEncoding(6), NewContentEncodingForEndpoint(config.Endpoint{UseCompression: true, CompressionKind: config.GzipCompressionKind, CompressionLevel: 6}))
+	assert.Equal(t, NewZstdContentEncoding(3), NewContentEncodingForEndpoint(config.Endpoint{UseCompression: true, CompressionKind: config.ZstdCompressionKind, CompressionLevel: 3}))
+}
+
+func TestZstdContentEncodingHTTPRoundTrip(t *testing.T) {
+	received := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "zstd", r.Header.Get("Content-Encoding"))
+		decompressed, err := zstd.Decompress(nil, body)
+		assert.Nil(t, err)
+		received <- decompressed
+	}))
+	defer server.Close()
+
+	url := strings.Split(server.URL, ":")
+	port, _ := strconv.Atoi(url[2])
+	endpoint := config.Endpoint{
+		APIKey:           "test",
+		Host:             strings.TrimPrefix(url[1], "//"),
+		Port:             port,
+		UseCompression:   true,
+		CompressionKind:  config.ZstdCompressionKind,
+		CompressionLevel: zstd.DefaultCompression,
+		BackoffFactor:    1,
+		BackoffBase:      1,
+		BackoffMax:       10,
+		RecoveryInterval: 1,
+	}
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	defer destinationsCtx.Stop()
+	destination := logshttp.NewDestination(endpoint, logshttp.JSONContentType, destinationsCtx, 0, true, "test")
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	destination.Start(input, output, nil)
+
+	content := []byte(`[{"message":"hello"},{"message":"world"}]`)
+	encoding := NewContentEncodingForEndpoint(endpoint)
+	encoded, err := encoding.encode(content)
+	assert.Nil(t, err)
+	input <- &message.Payload{Encoded: encoded, Encoding: encoding.name(), UnencodedSize: len(content)}
+	<-output
+
+	assert.Equal(t, content, <-received)
+}
+
 func decompress(payload []byte) ([]byte, error) {
 	reader, err := gzip.NewReader(bytes.NewReader(payload))
 	if err != nil {
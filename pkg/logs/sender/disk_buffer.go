@@ -0,0 +1,295 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const bufferedPayloadExtension = ".payload"
+
+// the files are named after the time the payloads are stored at, so that their
+// names are sorted in the order they must be sent in
+const bufferedPayloadFileFormat = "2006_01_02__15_04_05.000000000_"
+
+var (
+	tlmDiskBufferSize         = telemetry.NewGauge("logs_sender", "disk_buffer_size_in_bytes", []string{}, "Disk space used by the payloads waiting to be sent")
+	tlmDiskBufferFiles        = telemetry.NewGauge("logs_sender", "disk_buffer_files", []string{}, "Number of payloads stored on disk")
+	tlmDiskBufferFilesRemoved = telemetry.NewCounter("logs_sender", "disk_buffer_files_removed", []string{}, "Payloads removed from the disk buffer because it was full")
+)
+
+// bufferedPayload is the representation of a payload on disk.
+type bufferedPayload struct {
+	Encoded       []byte
+	Encoding      string
+	UnencodedSize int
+	Messages      []bufferedMessage
+}
+
+// bufferedMessage is the representation of a message of a payload on disk. The
+// origin of the message is not stored, it is only needed to update the auditor
+// which is done once the payload is stored.
+type bufferedMessage struct {
+	Content            []byte
+	Status             string
+	IngestionTimestamp int64
+	Timestamp          time.Time
+}
+
+// DiskBuffer stores the payloads on disk until they are sent, so that they are
+// neither lost during a long outage of the intake nor when the agent restarts.
+// The payloads are replayed in the order they were stored in.
+type DiskBuffer struct {
+	mu                 sync.Mutex
+	storagePath        string
+	maxSizeInBytes     int64
+	maxDiskRatio       float64
+	pending            []string
+	inFlight           map[*message.Payload]string
+	sizes              map[string]int64
+	currentSizeInBytes int64
+	available          chan struct{}
+}
+
+// NewDiskBuffer returns a new disk buffer storing its payloads in storagePath,
+// the payloads stored by a previous run are loaded to be sent again.
+func NewDiskBuffer(storagePath string, maxSizeInBytes int64, maxDiskRatio float64) (*DiskBuffer, error) {
+	if err := os.MkdirAll(storagePath, 0700); err != nil {
+		return nil, err
+	}
+	b := &DiskBuffer{
+		storagePath:    storagePath,
+		maxSizeInBytes: maxSizeInBytes,
+		maxDiskRatio:   maxDiskRatio,
+		inFlight:       make(map[*message.Payload]string),
+		sizes:          make(map[string]int64),
+		available:      make(chan struct{}, 1),
+	}
+	if err := b.reloadExistingFiles(); err != nil {
+		return nil, err
+	}
+	if len(b.pending) > 0 {
+		log.Infof("Found %d logs payloads to send in %s", len(b.pending), storagePath)
+		b.notify()
+	}
+	// Check if there is an error when computing the available space
+	// to warn the user sooner (and not when there is an outage)
+	_, err := b.computeAvailableSpace()
+	return b, err
+}
+
+// Store durably writes a payload on disk, the oldest payloads waiting to be sent
+// are removed when there is not enough room for it.
+func (b *DiskBuffer) Store(payload *message.Payload) error {
+	buffered := bufferedPayload{
+		Encoded:       payload.Encoded,
+		Encoding:      payload.Encoding,
+		UnencodedSize: payload.UnencodedSize,
+		Messages:      make([]bufferedMessage, 0, len(payload.Messages)),
+	}
+	for _, msg := range payload.Messages {
+		buffered.Messages = append(buffered.Messages, bufferedMessage{
+			Content:            msg.Content,
+			Status:             msg.GetStatus(),
+			IngestionTimestamp: msg.IngestionTimestamp,
+			Timestamp:          msg.Timestamp,
+		})
+	}
+	bytes, err := json.Marshal(buffered)
+	if err != nil {
+		return err
+	}
+	size := int64(len(bytes))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.makeRoomFor(size); err != nil {
+		return err
+	}
+
+	filename := time.Now().UTC().Format(bufferedPayloadFileFormat)
+	file, err := ioutil.TempFile(b.storagePath, filename+"*"+bufferedPayloadExtension)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(bytes); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	b.pending = append(b.pending, file.Name())
+	b.sizes[file.Name()] = size
+	b.currentSizeInBytes += size
+	tlmDiskBufferSize.Add(float64(size))
+	tlmDiskBufferFiles.Inc()
+	b.notify()
+	return nil
+}
+
+// Next returns the oldest payload waiting to be sent, or nil if there is none.
+// The payload stays on disk until it is removed once it has been sent.
+func (b *DiskBuffer) Next() *message.Payload {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for len(b.pending) > 0 {
+		filename := b.pending[0]
+		b.pending = b.pending[1:]
+
+		payload, err := readBufferedPayload(filename)
+		if err != nil {
+			log.Errorf("Could not read the logs payload %s, removing it: %v", filename, err)
+			b.removeFile(filename)
+			continue
+		}
+		b.inFlight[payload] = filename
+		return payload
+	}
+	return nil
+}
+
+// Remove removes a payload returned by Next from the disk, it returns false if
+// the payload does not come from this buffer.
+func (b *DiskBuffer) Remove(payload *message.Payload) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	filename, exists := b.inFlight[payload]
+	if !exists {
+		return false
+	}
+	delete(b.inFlight, payload)
+	b.removeFile(filename)
+	return true
+}
+
+// Available returns a channel notified when new payloads can be read with Next.
+func (b *DiskBuffer) Available() <-chan struct{} {
+	return b.available
+}
+
+// notify signals that there are payloads to read without blocking.
+func (b *DiskBuffer) notify() {
+	select {
+	case b.available <- struct{}{}:
+	default:
+	}
+}
+
+// makeRoomFor removes the oldest payloads waiting to be sent until there is
+// enough room to store size bytes. The payloads being sent are kept.
+func (b *DiskBuffer) makeRoomFor(size int64) error {
+	if size > b.maxSizeInBytes {
+		return fmt.Errorf("the payload is too big. Current:%v Maximum:%v", size, b.maxSizeInBytes)
+	}
+	maxStorageInBytes, err := b.computeAvailableSpace()
+	if err != nil {
+		return err
+	}
+	for len(b.pending) > 0 && b.currentSizeInBytes+size > maxStorageInBytes {
+		filename := b.pending[0]
+		b.pending = b.pending[1:]
+		log.Errorf("Maximum disk space for logs payloads is reached. Removing %s", filename)
+		b.removeFile(filename)
+		tlmDiskBufferFilesRemoved.Inc()
+	}
+	if b.currentSizeInBytes+size > maxStorageInBytes {
+		return fmt.Errorf("not enough disk space to store the payload. Current:%v Available:%v", size, maxStorageInBytes-b.currentSizeInBytes)
+	}
+	return nil
+}
+
+// computeAvailableSpace returns the maximum disk space the payloads can use.
+func (b *DiskBuffer) computeAvailableSpace() (int64, error) {
+	usage, err := filesystem.NewDisk().GetUsage(b.storagePath)
+	if err != nil {
+		return 0, err
+	}
+	diskReserved := float64(usage.Total) * (1 - b.maxDiskRatio)
+	availableDiskUsage := int64(usage.Available) - int64(math.Ceil(diskReserved))
+	if b.currentSizeInBytes+availableDiskUsage < b.maxSizeInBytes {
+		return b.currentSizeInBytes + availableDiskUsage, nil
+	}
+	return b.maxSizeInBytes, nil
+}
+
+func (b *DiskBuffer) removeFile(filename string) {
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Could not remove the logs payload %s: %v", filename, err)
+	}
+	b.currentSizeInBytes -= b.sizes[filename]
+	tlmDiskBufferSize.Sub(float64(b.sizes[filename]))
+	tlmDiskBufferFiles.Dec()
+	delete(b.sizes, filename)
+}
+
+func (b *DiskBuffer) reloadExistingFiles() error {
+	entries, err := ioutil.ReadDir(b.storagePath)
+	if err != nil {
+		return err
+	}
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() && filepath.Ext(entry.Name()) == bufferedPayloadExtension {
+			files = append(files, entry)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+	for _, file := range files {
+		filename := filepath.Join(b.storagePath, file.Name())
+		b.pending = append(b.pending, filename)
+		b.sizes[filename] = file.Size()
+		b.currentSizeInBytes += file.Size()
+		tlmDiskBufferSize.Add(float64(file.Size()))
+		tlmDiskBufferFiles.Inc()
+	}
+	return nil
+}
+
+func readBufferedPayload(filename string) (*message.Payload, error) {
+	bytes, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var buffered bufferedPayload
+	if err := json.Unmarshal(bytes, &buffered); err != nil {
+		return nil, err
+	}
+	payload := &message.Payload{
+		Encoded:       buffered.Encoded,
+		Encoding:      buffered.Encoding,
+		UnencodedSize: buffered.UnencodedSize,
+		Messages:      make([]*message.Message, 0, len(buffered.Messages)),
+	}
+	for _, m := range buffered.Messages {
+		msg := message.NewMessage(m.Content, nil, m.Status, m.IngestionTimestamp)
+		msg.Timestamp = m.Timestamp
+		payload.Messages = append(payload.Messages, msg)
+	}
+	return payload, nil
+}
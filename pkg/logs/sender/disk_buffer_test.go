@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newBufferedPayload(content string) *message.Payload {
+	return &message.Payload{Encoded: []byte(content), Encoding: "identity", UnencodedSize: len(content)}
+}
+
+func TestDiskBufferStoreAndRemove(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	assert.Nil(t, buffer.Next())
+
+	require.NoError(t, buffer.Store(newBufferedPayload("first")))
+	require.NoError(t, buffer.Store(newBufferedPayload("second")))
+	<-buffer.Available()
+
+	payload := buffer.Next()
+	require.NotNil(t, payload)
+	assert.Equal(t, "first", string(payload.Encoded))
+	assert.Equal(t, "identity", payload.Encoding)
+	assert.Equal(t, 5, payload.UnencodedSize)
+
+	assert.True(t, buffer.Remove(payload))
+	assert.False(t, buffer.Remove(payload))
+	assert.False(t, buffer.Remove(newBufferedPayload("first")))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+bufferedPayloadExtension))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestDiskBufferReloadsExistingPayloads(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	require.NoError(t, buffer.Store(newBufferedPayload("first")))
+	require.NoError(t, buffer.Store(newBufferedPayload("second")))
+
+	// the payload which was being sent is sent again
+	assert.NotNil(t, buffer.Next())
+
+	buffer, err = NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	<-buffer.Available()
+	payload := buffer.Next()
+	require.NotNil(t, payload)
+	assert.Equal(t, "first", string(payload.Encoded))
+	payload = buffer.Next()
+	require.NotNil(t, payload)
+	assert.Equal(t, "second", string(payload.Encoded))
+	assert.Nil(t, buffer.Next())
+}
+
+func TestDiskBufferRemovesOldestPayloadsWhenFull(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := NewDiskBuffer(dir, 160, 1)
+	require.NoError(t, err)
+
+	require.NoError(t, buffer.Store(newBufferedPayload("first")))
+	require.NoError(t, buffer.Store(newBufferedPayload("second")))
+	require.NoError(t, buffer.Store(newBufferedPayload("third")))
+
+	payload := buffer.Next()
+	require.NotNil(t, payload)
+	assert.Equal(t, "second", string(payload.Encoded))
+
+	// a payload larger than the limit is not stored
+	assert.Error(t, buffer.Store(newBufferedPayload(string(make([]byte, 200)))))
+}
+
+func TestDiskBufferRemovesCorruptedPayloads(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "corrupted"+bufferedPayloadExtension), []byte("{"), 0600))
+	buffer, err := NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+
+	assert.Nil(t, buffer.Next())
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -27,12 +28,18 @@ var (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+//
+// When a disk buffer is used, the payloads are stored on disk before being sent
+// and the auditor is updated as soon as they are stored. They are removed from
+// the disk once they have been sent to a reliable destination, the payloads
+// that could not be sent before the sender stops are sent after a restart.
 type Sender struct {
 	inputChan    chan *message.Payload
 	outputChan   chan *message.Payload
 	destinations *client.Destinations
 	done         chan struct{}
 	bufferSize   int
+	buffer       *DiskBuffer
 }
 
 // NewSender returns a new sender.
@@ -46,9 +53,33 @@ func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload
 	}
 }
 
+// NewSenderWithDiskBuffer returns a new sender storing the payloads in buffer until they are sent.
+func NewSenderWithDiskBuffer(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, buffer *DiskBuffer) *Sender {
+	sender := NewSender(inputChan, outputChan, destinations, bufferSize)
+	sender.buffer = buffer
+	return sender
+}
+
 // Start starts the sender.
 func (s *Sender) Start() {
-	go s.run()
+	if s.buffer == nil {
+		go func() {
+			s.run(s.inputChan, s.outputChan)
+			s.done <- struct{}{}
+		}()
+		return
+	}
+
+	sendChan := make(chan *message.Payload)
+	deliveredChan := make(chan *message.Payload, s.bufferSize)
+	stopReplay := make(chan struct{})
+	go s.persist(sendChan, stopReplay)
+	go s.replay(sendChan, stopReplay)
+	go func() {
+		s.run(sendChan, deliveredChan)
+		close(deliveredChan)
+	}()
+	go s.acknowledge(deliveredChan)
 }
 
 // Stop stops the sender,
@@ -58,13 +89,13 @@ func (s *Sender) Stop() {
 	<-s.done
 }
 
-func (s *Sender) run() {
-	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, s.outputChan, s.bufferSize)
+func (s *Sender) run(inputChan chan *message.Payload, outputChan chan *message.Payload) {
+	reliableDestinations := buildDestinationSenders(s.destinations.Reliable, outputChan, s.bufferSize)
 
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
+	for payload := range inputChan {
 		var startInUse = time.Now()
 
 		sent := false
@@ -114,6 +145,52 @@ func (s *Sender) run() {
 		destSender.Stop()
 	}
 	close(sink)
+}
+
+// persist stores the incoming payloads on disk and updates the auditor once they
+// are stored. The payloads which cannot be stored are sent directly.
+func (s *Sender) persist(sendChan chan *message.Payload, stopReplay chan struct{}) {
+	defer close(stopReplay)
+	for payload := range s.inputChan {
+		if err := s.buffer.Store(payload); err != nil {
+			log.Warnf("Could not store logs payload on disk, sending it directly: %v", err)
+			sendChan <- payload
+			continue
+		}
+		s.outputChan <- payload
+	}
+}
+
+// replay sends the payloads stored on disk until the sender is stopped,
+// the payloads which are not sent yet stay on disk.
+func (s *Sender) replay(sendChan chan *message.Payload, stop chan struct{}) {
+	defer close(sendChan)
+	for {
+		payload := s.buffer.Next()
+		if payload == nil {
+			select {
+			case <-s.buffer.Available():
+				continue
+			case <-stop:
+				return
+			}
+		}
+		select {
+		case sendChan <- payload:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// acknowledge removes the sent payloads from the disk, the payloads which were
+// not stored on disk are forwarded to the auditor.
+func (s *Sender) acknowledge(deliveredChan chan *message.Payload) {
+	for payload := range deliveredChan {
+		if !s.buffer.Remove(payload) {
+			s.outputChan <- payload
+		}
+	}
 	s.done <- struct{}{}
 }
 
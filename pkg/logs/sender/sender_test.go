@@ -6,15 +6,22 @@
 package sender
 
 import (
+	"io/ioutil"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/metrics"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func newMessage(content []byte, source *config.LogSource, status string) *message.Payload {
@@ -299,3 +306,111 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	sender.Stop()
 }
+
+func bufferedFiles(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+bufferedPayloadExtension))
+	require.NoError(t, err)
+	return files
+}
+
+func TestSenderWithDiskBuffer(t *testing.T) {
+	dir := t.TempDir()
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	respondChan := make(chan int)
+	server := http.NewTestServerWithOptions(200, 0, true, respondChan)
+	destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
+
+	buffer, err := NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	sender := NewSenderWithDiskBuffer(input, output, destinations, 10, buffer)
+	sender.Start()
+
+	expectedPayload := newMessage([]byte("fake line"), config.NewLogSource("", &config.LogsConfig{}), "")
+	input <- expectedPayload
+
+	// the auditor is updated once the payload is stored, before it is sent
+	assert.Equal(t, expectedPayload, <-output)
+	<-respondChan
+	assert.Eventually(t, func() bool { return len(bufferedFiles(t, dir)) == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	server.Stop()
+	sender.Stop()
+}
+
+func TestSenderWithDiskBufferReplaysStoredPayloads(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	require.NoError(t, buffer.Store(newBufferedPayload("stored before restart")))
+
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	respondChan := make(chan int)
+	server := http.NewTestServerWithOptions(200, 0, true, respondChan)
+	destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
+
+	buffer, err = NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	sender := NewSenderWithDiskBuffer(input, output, destinations, 10, buffer)
+	sender.Start()
+
+	<-respondChan
+	assert.Eventually(t, func() bool { return len(bufferedFiles(t, dir)) == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	// the auditor was already updated before the restart
+	select {
+	case <-output:
+		assert.Fail(t, "the replayed payload should not be sent to the auditor")
+	default:
+	}
+
+	server.Stop()
+	sender.Stop()
+}
+
+func TestSenderWithDiskBufferToWebhook(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies <- string(body)
+	}))
+	defer server.Close()
+
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	defer destinationsContext.Stop()
+	destination := http.NewWebhookDestination(config.WebhookEndpoint{
+		URL:    server.URL,
+		Format: config.NDJSONWebhookFormat,
+	}, destinationsContext, 1, "")
+	destinations := client.NewDestinations([]client.Destination{destination}, nil)
+
+	dir := t.TempDir()
+	buffer, err := NewDiskBuffer(dir, 1024*1024, 1)
+	require.NoError(t, err)
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+	sender := NewSenderWithDiskBuffer(input, output, destinations, 10, buffer)
+	sender.Start()
+
+	logsSent := metrics.LogsSent.Value()
+	payload := &message.Payload{Encoded: []byte("datadog payload"), Encoding: "identity"}
+	for _, content := range []string{`{"message":"hello","status":"info"}`, `{"message":"world","status":"error"}`} {
+		payload.Messages = append(payload.Messages, message.NewMessageWithSource([]byte(content), "", config.NewLogSource("", &config.LogsConfig{}), 0))
+	}
+	input <- payload
+	<-output
+
+	// the payload is sent once read back from the disk, with its messages
+	assert.Equal(t, `{"message":"hello","status":"info","timestamp":0,"hostname":"","service":"","ddsource":"","ddtags":""}
+{"message":"world","status":"error","timestamp":0,"hostname":"","service":"","ddsource":"","ddtags":""}
+`, <-bodies)
+	assert.Eventually(t, func() bool { return len(bufferedFiles(t, dir)) == 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, logsSent+2, metrics.LogsSent.Value())
+
+	sender.Stop()
+}
@@ -139,6 +139,9 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 	switch c.Type {
 	case config.TCPType, config.UDPType:
 		dictionary["Port"] = c.Port
+	case config.SyslogType:
+		dictionary["Port"] = c.Port
+		dictionary["Protocol"] = c.Protocol
 	case config.FileType:
 		dictionary["Path"] = c.Path
 		dictionary["TailingMode"] = c.TailingMode
@@ -168,6 +171,7 @@ func (b *Builder) toDictionary(c *config.LogsConfig) map[string]interface{} {
 func (b *Builder) getMetricsStatus() map[string]int64 {
 	var metrics = make(map[string]int64, 2)
 	metrics["LogsProcessed"] = b.logsExpVars.Get("LogsProcessed").(*expvar.Int).Value()
+	metrics["LogsThrottled"] = b.logsExpVars.Get("LogsThrottled").(*expvar.Int).Value()
 	metrics["LogsSent"] = b.logsExpVars.Get("LogsSent").(*expvar.Int).Value()
 	metrics["BytesSent"] = b.logsExpVars.Get("BytesSent").(*expvar.Int).Value()
 	metrics["EncodedBytesSent"] = b.logsExpVars.Get("EncodedBytesSent").(*expvar.Int).Value()
@@ -132,6 +132,33 @@ func (s *Sketch) Quantile(c *Config, q float64) float64 {
 	return math.NaN()
 }
 
+// CountBelow returns an estimate of the number of items <= v.
+//
+// The items are only known with the relative accuracy of the sketch, all the
+// items of the bin of v are counted.
+func (s *Sketch) CountBelow(c *Config, v float64) int {
+	switch {
+	case s.count == 0, v < s.Basic.Min:
+		return 0
+	case v >= s.Basic.Max:
+		return s.count
+	}
+
+	var (
+		n int
+		k = c.key(v)
+	)
+
+	for _, b := range s.bins {
+		if b.k > k {
+			break
+		}
+		n += int(b.n)
+	}
+
+	return n
+}
+
 func rank(count int, q float64) float64 {
 	return math.RoundToEven(q * float64(count-1))
 }
@@ -173,6 +173,35 @@ func TestQuantile(t *testing.T) {
 	}
 }
 
+func TestCountBelow(t *testing.T) {
+	var (
+		c = Default()
+		s = &Sketch{}
+	)
+
+	require.Equal(t, 0, s.CountBelow(c, 1))
+
+	for i := -50; i <= 50; i++ {
+		s.Insert(c, float64(i))
+	}
+
+	for _, tt := range []struct {
+		v    float64
+		want int
+	}{
+		{v: -100, want: 0},
+		{v: -50, want: 1},
+		{v: -0.5, want: 50},
+		{v: 0, want: 51},
+		{v: 10, want: 61},
+		{v: 49, want: 100},
+		{v: 50, want: 101},
+		{v: 1000, want: 101},
+	} {
+		require.Equal(t, tt.want, s.CountBelow(c, tt.v), "CountBelow(%g)", tt.v)
+	}
+}
+
 func TestRank(t *testing.T) {
 	t.Run("101", func(t *testing.T) {
 		// when cnt=101:
@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package remotewrite builds the forwarder sending the metrics to Prometheus
+// remote write endpoints.
+package remotewrite
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/resolver"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// buildKeysPerDomains returns the remote write endpoints as domains of the
+// forwarder. The endpoints are full URLs, their path is the remote write route.
+// They don't use Datadog API keys, the credentials are set in the URLs or in
+// the headers.
+func buildKeysPerDomains(conf config.Config) (map[string][]string, error) {
+	keysPerDomain := map[string][]string{}
+	for _, endpoint := range conf.GetStringSlice("remote_write.endpoints") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse remote write endpoint: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid remote write endpoint %q: the scheme must be http or https", u.Redacted())
+		}
+		// an empty key so that the forwarder creates a transaction for the domain
+		keysPerDomain[endpoint] = []string{""}
+	}
+	return keysPerDomain, nil
+}
+
+// NewForwarder returns a forwarder for the Prometheus remote write endpoints,
+// or nil if remote write is disabled.
+func NewForwarder() forwarder.Forwarder {
+	if !config.Datadog.GetBool("remote_write.enabled") {
+		return nil
+	}
+
+	keysPerDomain, err := buildKeysPerDomains(config.Datadog)
+	if err != nil {
+		log.Errorf("Cannot build keys per domains: %v", err)
+		return nil
+	}
+	if len(keysPerDomain) == 0 {
+		log.Warn("remote_write is enabled but no endpoint is configured in remote_write.endpoints")
+		return nil
+	}
+
+	options := forwarder.NewOptionsWithResolvers(resolver.NewSingleDomainResolvers(keysPerDomain))
+	options.DisableAPIKeyChecking = true
+
+	return forwarder.NewDefaultForwarder(options)
+}
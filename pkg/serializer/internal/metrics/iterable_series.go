@@ -23,6 +23,28 @@ import (
 // IterableSeries is a serializer for metrics.IterableSeries
 type IterableSeries struct {
 	*metrics.IterableSeries
+	// RemoteWrite, if not nil, encodes the series in remote write payloads
+	// while they are iterated
+	RemoteWrite *RemoteWriteEncoder
+	// Routes collect the series sent to the domains restricted to some metrics
+	// while they are iterated
+	Routes []*MetricRoute
+}
+
+// MoveNext advances to the next serie, adding it to the remote write payloads
+// and to the routes if needed.
+func (series IterableSeries) MoveNext() bool {
+	ok := series.IterableSeries.MoveNext()
+	if !ok {
+		return false
+	}
+	if series.RemoteWrite != nil {
+		series.RemoteWrite.AddSerie(series.Current())
+	}
+	for _, route := range series.Routes {
+		route.AddSerie(series.Current())
+	}
+	return true
 }
 
 // WriteHeader writes the payload header for this type
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// MetricRoute collects the metrics sent to a domain restricted to the metrics
+// whose name starts with one of the prefixes of its forwarder routing rule.
+type MetricRoute struct {
+	Domain   string
+	Prefixes []string
+	Series   Series
+}
+
+// Matches returns true if the metric is sent to the domain of the route.
+func (r *MetricRoute) Matches(name string) bool {
+	for _, prefix := range r.Prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSerie adds the serie to the series of the route if it matches.
+func (r *MetricRoute) AddSerie(serie *metrics.Serie) {
+	if r.Matches(serie.Name) {
+		r.Series = append(r.Series, serie)
+	}
+}
+
+// FilterSketches returns the sketches matching the route.
+func (r *MetricRoute) FilterSketches(sketches metrics.SketchSeriesList) metrics.SketchSeriesList {
+	var filtered metrics.SketchSeriesList
+	for _, ss := range sketches {
+		if r.Matches(ss.Name) {
+			filtered = append(filtered, ss)
+		}
+	}
+	return filtered
+}
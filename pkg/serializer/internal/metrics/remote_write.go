@@ -0,0 +1,285 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/richardartoul/molecule"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// constants for the protobuf data we will be writing, taken from WriteRequest in
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+const (
+	writeRequestTimeseries = 1
+	timeseriesLabels       = 1
+	timeseriesSamples      = 2
+	labelName              = 1
+	labelValue             = 2
+	sampleValue            = 1
+	sampleTimestamp        = 2
+)
+
+type remoteWriteLabel struct {
+	name  string
+	value string
+}
+
+type remoteWriteSample struct {
+	value     float64
+	timestamp int64 // in milliseconds
+}
+
+// RemoteWriteEncoder encodes series and sketches into Prometheus remote write
+// payloads: snappy compressed WriteRequest protobuf messages.
+//
+// The metric names and the tag keys are sanitized to be valid Prometheus names,
+// the tags `key:value` become labels and the tags without value are dropped.
+// The values of the tags sharing the same key are joined with a comma. The host
+// is sent as the `host` label unless a `host` tag is already set.
+//
+// Each point of a sketch is sent as the `<name>_count`, `<name>_sum`, `<name>_min`
+// and `<name>_max` series, and as a `<name>` series for each quantile, with a
+// `quantile` label. They are computed over the flush interval only, contrary to
+// the Prometheus summaries which are cumulative.
+type RemoteWriteEncoder struct {
+	maxSamples int
+	quantiles  []float64
+
+	buf      *bytes.Buffer
+	ps       *molecule.ProtoStream
+	samples  int
+	payloads []*[]byte
+
+	// reused for each series
+	labels        []remoteWriteLabel
+	sampleBuffer  []remoteWriteSample
+	quantileNames []string
+}
+
+// NewRemoteWriteEncoder returns an encoder building payloads of at most
+// maxSamples samples, sending the given quantiles of the sketches.
+func NewRemoteWriteEncoder(maxSamples int, quantiles []float64) *RemoteWriteEncoder {
+	if maxSamples <= 0 {
+		maxSamples = 1
+	}
+	buf := bytes.NewBuffer(nil)
+	e := &RemoteWriteEncoder{
+		maxSamples: maxSamples,
+		quantiles:  quantiles,
+		buf:        buf,
+		ps:         molecule.NewProtoStream(buf),
+	}
+	for _, q := range quantiles {
+		e.quantileNames = append(e.quantileNames, strconv.FormatFloat(q, 'f', -1, 64))
+	}
+	return e
+}
+
+// AddSerie adds a serie to the payloads.
+func (e *RemoteWriteEncoder) AddSerie(serie *metrics.Serie) {
+	if serie == nil || len(serie.Points) == 0 {
+		return
+	}
+
+	e.sampleBuffer = e.sampleBuffer[:0]
+	for _, p := range serie.Points {
+		e.sampleBuffer = append(e.sampleBuffer, remoteWriteSample{value: p.Value, timestamp: int64(p.Ts * 1000)})
+	}
+
+	e.setLabels(serie.Name, serie.Host, serie.Tags.UnsafeToReadOnlySliceString(), "")
+	e.writeTimeseries()
+}
+
+// AddSketchSeries adds the series computed from the sketches to the payloads.
+func (e *RemoteWriteEncoder) AddSketchSeries(sketches metrics.SketchSeriesList) {
+	c := quantile.Default()
+	for _, ss := range sketches {
+		tags := ss.Tags.UnsafeToReadOnlySliceString()
+
+		for _, aggregate := range []string{"count", "sum", "min", "max"} {
+			e.sampleBuffer = e.sampleBuffer[:0]
+			for _, p := range ss.Points {
+				if p.Sketch == nil {
+					continue
+				}
+				var value float64
+				switch aggregate {
+				case "count":
+					value = float64(p.Sketch.Basic.Cnt)
+				case "sum":
+					value = p.Sketch.Basic.Sum
+				case "min":
+					value = p.Sketch.Basic.Min
+				case "max":
+					value = p.Sketch.Basic.Max
+				}
+				e.sampleBuffer = append(e.sampleBuffer, remoteWriteSample{value: value, timestamp: p.Ts * 1000})
+			}
+			e.setLabels(ss.Name+"_"+aggregate, ss.Host, tags, "")
+			e.writeTimeseries()
+		}
+
+		for i, q := range e.quantiles {
+			e.sampleBuffer = e.sampleBuffer[:0]
+			for _, p := range ss.Points {
+				if p.Sketch != nil {
+					e.sampleBuffer = append(e.sampleBuffer, remoteWriteSample{value: p.Sketch.Quantile(c, q), timestamp: p.Ts * 1000})
+				}
+			}
+			e.setLabels(ss.Name, ss.Host, tags, e.quantileNames[i])
+			e.writeTimeseries()
+		}
+	}
+}
+
+// Payloads returns the compressed payloads built so far. The encoder must not
+// be used afterwards.
+func (e *RemoteWriteEncoder) Payloads() []*[]byte {
+	e.finishPayload()
+	return e.payloads
+}
+
+// setLabels sets the sorted labels of the next timeseries.
+func (e *RemoteWriteEncoder) setLabels(name, host string, tags []string, quantileValue string) {
+	e.labels = append(e.labels[:0], remoteWriteLabel{name: "__name__", value: sanitizeRemoteWriteName(name, true)})
+
+	hasHost := false
+	for _, tag := range tags {
+		i := strings.IndexByte(tag, ':')
+		if i <= 0 || i == len(tag)-1 {
+			continue
+		}
+		key := sanitizeRemoteWriteName(tag[:i], false)
+		if key == "host" {
+			hasHost = true
+		}
+		e.labels = append(e.labels, remoteWriteLabel{name: key, value: tag[i+1:]})
+	}
+	if host != "" && !hasHost {
+		e.labels = append(e.labels, remoteWriteLabel{name: "host", value: host})
+	}
+	if quantileValue != "" {
+		e.labels = append(e.labels, remoteWriteLabel{name: "quantile", value: quantileValue})
+	}
+
+	// the labels must be sorted by name and unique
+	sort.SliceStable(e.labels, func(i, j int) bool {
+		return e.labels[i].name < e.labels[j].name
+	})
+	j := 0
+	for i := 1; i < len(e.labels); i++ {
+		if e.labels[i].name == e.labels[j].name {
+			if e.labels[i].value != e.labels[j].value {
+				e.labels[j].value += "," + e.labels[i].value
+			}
+			continue
+		}
+		j++
+		e.labels[j] = e.labels[i]
+	}
+	e.labels = e.labels[:j+1]
+}
+
+// writeTimeseries writes the labels and samples in the buffers as a timeseries,
+// starting a new payload when the current one is full.
+func (e *RemoteWriteEncoder) writeTimeseries() {
+	if len(e.sampleBuffer) == 0 {
+		return
+	}
+
+	// the samples of a timeseries must be ordered by time
+	sort.Slice(e.sampleBuffer, func(i, j int) bool {
+		return e.sampleBuffer[i].timestamp < e.sampleBuffer[j].timestamp
+	})
+
+	if e.samples > 0 && e.samples+len(e.sampleBuffer) > e.maxSamples {
+		e.finishPayload()
+	}
+
+	err := e.ps.Embedded(writeRequestTimeseries, func(ps *molecule.ProtoStream) error {
+		for _, label := range e.labels {
+			err := ps.Embedded(timeseriesLabels, func(ps *molecule.ProtoStream) error {
+				if err := ps.String(labelName, label.name); err != nil {
+					return err
+				}
+				return ps.String(labelValue, label.value)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		for _, sample := range e.sampleBuffer {
+			err := ps.Embedded(timeseriesSamples, func(ps *molecule.ProtoStream) error {
+				if err := ps.Double(sampleValue, sample.value); err != nil {
+					return err
+				}
+				return ps.Int64(sampleTimestamp, sample.timestamp)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// writing to a bytes.Buffer does not fail
+		log.Debugf("Could not encode a remote write timeseries: %s", err)
+		return
+	}
+	e.samples += len(e.sampleBuffer)
+}
+
+func (e *RemoteWriteEncoder) finishPayload() {
+	if e.samples == 0 {
+		return
+	}
+	payload := snappy.Encode(nil, e.buf.Bytes())
+	e.payloads = append(e.payloads, &payload)
+	e.buf.Reset()
+	e.samples = 0
+}
+
+// sanitizeRemoteWriteName replaces the characters which are not valid in a
+// Prometheus metric name, or label name if metricName is false, by underscores.
+func sanitizeRemoteWriteName(name string, metricName bool) string {
+	valid := func(i int, c rune) bool {
+		return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9') || (metricName && c == ':')
+	}
+
+	clean := true
+	for i, c := range name {
+		if !valid(i, c) {
+			clean = false
+			break
+		}
+	}
+	if clean && name != "" {
+		return name
+	}
+
+	var b strings.Builder
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		b.WriteByte('_')
+	}
+	for i, c := range name {
+		if valid(i, c) || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
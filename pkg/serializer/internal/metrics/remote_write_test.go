@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func decodeRemoteWritePayloads(t *testing.T, payloads []*[]byte) []RemoteWriteTimeseries {
+	t.Helper()
+	var timeseries []RemoteWriteTimeseries
+	for _, payload := range payloads {
+		decoded, err := DecodeRemoteWrite(*payload)
+		require.NoError(t, err)
+		timeseries = append(timeseries, decoded...)
+	}
+	return timeseries
+}
+
+func TestRemoteWriteEncoderSeries(t *testing.T) {
+	encoder := NewRemoteWriteEncoder(100, nil)
+	encoder.AddSerie(&metrics.Serie{
+		Name:   "my.metric-name",
+		Host:   "localhost",
+		Tags:   tagset.CompositeTagsFromSlice([]string{"env:prod", "service:web", "service:api", "novalue", "1st.key:x"}),
+		Points: []metrics.Point{{Ts: 20, Value: 2}, {Ts: 10, Value: 1}},
+	})
+	encoder.AddSerie(&metrics.Serie{
+		Name:   "other",
+		Host:   "localhost",
+		Tags:   tagset.CompositeTagsFromSlice([]string{"host:tagged"}),
+		Points: []metrics.Point{{Ts: 10, Value: 3}},
+	})
+	// series without points are skipped
+	encoder.AddSerie(&metrics.Serie{Name: "empty"})
+
+	payloads := encoder.Payloads()
+	require.Len(t, payloads, 1)
+	timeseries := decodeRemoteWritePayloads(t, payloads)
+	require.Len(t, timeseries, 2)
+
+	assert.Equal(t, map[string]string{
+		"__name__": "my_metric_name",
+		"env":      "prod",
+		"service":  "web,api",
+		"host":     "localhost",
+		"_1st_key": "x",
+	}, timeseries[0].Labels)
+	assert.Equal(t, []float64{1, 2}, timeseries[0].Values)
+	assert.Equal(t, []int64{10000, 20000}, timeseries[0].Timestamps)
+
+	assert.Equal(t, map[string]string{"__name__": "other", "host": "tagged"}, timeseries[1].Labels)
+	assert.Equal(t, []float64{3}, timeseries[1].Values)
+}
+
+func TestRemoteWriteEncoderSketches(t *testing.T) {
+	encoder := NewRemoteWriteEncoder(100, []float64{0.5, 0.99})
+	encoder.AddSketchSeries(metrics.SketchSeriesList{Makeseries(1)})
+
+	timeseries := decodeRemoteWritePayloads(t, encoder.Payloads())
+	require.Len(t, timeseries, 6)
+
+	byName := map[string]RemoteWriteTimeseries{}
+	for _, ts := range timeseries {
+		assert.Equal(t, "host.1", ts.Labels["host"])
+		assert.Equal(t, "1", ts.Labels["a"])
+		assert.Len(t, ts.Values, 6)
+		byName[ts.Labels["__name__"]+"/"+ts.Labels["quantile"]] = ts
+	}
+
+	assert.Equal(t, []float64{0, 1, 2, 3, 4, 5}, byName["name_1_count/"].Values)
+	assert.Equal(t, []float64{0, 0, 1, 3, 6, 10}, byName["name_1_sum/"].Values)
+	assert.Equal(t, []float64{0, 0, 0, 0, 0, 0}, byName["name_1_min/"].Values)
+	assert.Equal(t, float64(4), byName["name_1_max/"].Values[5])
+	assert.Equal(t, []int64{0, 10000, 20000, 30000, 40000, 50000}, byName["name_1_min/"].Timestamps)
+	assert.Contains(t, byName, "name_1/0.5")
+	assert.InEpsilon(t, 4, byName["name_1/0.99"].Values[5], 0.02)
+}
+
+func TestRemoteWriteEncoderMaxSamples(t *testing.T) {
+	encoder := NewRemoteWriteEncoder(2, nil)
+	for _, name := range []string{"a", "b", "c"} {
+		encoder.AddSerie(&metrics.Serie{Name: name, Points: []metrics.Point{{Ts: 10, Value: 1}}})
+	}
+	// a timeseries is never split, even if it has more samples than the maximum
+	encoder.AddSerie(&metrics.Serie{Name: "d", Points: []metrics.Point{{Ts: 10, Value: 1}, {Ts: 20, Value: 2}, {Ts: 30, Value: 3}}})
+
+	payloads := encoder.Payloads()
+	require.Len(t, payloads, 3)
+
+	var names [][]string
+	for _, payload := range payloads {
+		timeseries := decodeRemoteWritePayloads(t, []*[]byte{payload})
+		var payloadNames []string
+		for _, ts := range timeseries {
+			payloadNames = append(payloadNames, ts.Labels["__name__"])
+		}
+		names = append(names, payloadNames)
+	}
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}, {"d"}}, names)
+}
+
+func TestRemoteWriteEncoderEmpty(t *testing.T) {
+	encoder := NewRemoteWriteEncoder(100, nil)
+	assert.Empty(t, encoder.Payloads())
+}
+
+func TestSanitizeRemoteWriteName(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		metricName bool
+		expected   string
+	}{
+		{"valid_name", true, "valid_name"},
+		{"dotted.name", true, "dotted_name"},
+		{"name:with:colons", true, "name:with:colons"},
+		{"name:with:colons", false, "name_with_colons"},
+		{"9lives", true, "_9lives"},
+		{"élan", false, "_lan"},
+		{"", false, "_"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sanitizeRemoteWriteName(tc.name, tc.metricName))
+		})
+	}
+}
+
+func TestIterableSeriesRemoteWrite(t *testing.T) {
+	encoder := NewRemoteWriteEncoder(100, nil)
+	series := IterableSeries{
+		IterableSeries: CreateIterableSeries(metrics.Series{
+			{Name: "a", Points: []metrics.Point{{Ts: 10, Value: 1}}},
+			{Name: "b", Points: []metrics.Point{{Ts: 10, Value: 2}}},
+		}),
+		RemoteWrite: encoder,
+	}
+	_, err := series.MarshalJSON()
+	require.NoError(t, err)
+
+	timeseries := decodeRemoteWritePayloads(t, encoder.Payloads())
+	require.Len(t, timeseries, 2)
+	assert.Equal(t, "a", timeseries[0].Labels["__name__"])
+	assert.Equal(t, "b", timeseries[1].Labels["__name__"])
+}
@@ -43,6 +43,28 @@ func (series Series) MarshalJSON() ([]byte, error) {
 	return reqBody.Bytes(), err
 }
 
+// MarshalSplitCompress uses the stream compressor to marshal and compress series payloads.
+// If a compressed payload is larger than the max, a new payload will be generated. This method returns a slice of
+// compressed protobuf marshaled MetricPayload objects.
+func (series Series) MarshalSplitCompress(bufferContext *marshaler.BufferContext) ([]*[]byte, error) {
+	return marshalSplitCompress(&seriesIterator{series: series, index: -1}, bufferContext)
+}
+
+// seriesIterator iterates over a slice of series
+type seriesIterator struct {
+	series Series
+	index  int
+}
+
+func (it *seriesIterator) MoveNext() bool {
+	it.index++
+	return it.index < len(it.series)
+}
+
+func (it *seriesIterator) Current() *metrics.Serie {
+	return it.series[it.index]
+}
+
 // SplitPayload breaks the payload into, at least, "times" number of pieces
 func (series Series) SplitPayload(times int) ([]marshaler.AbstractMarshaler, error) {
 	seriesExpvar.Add("TimesSplit", 1)
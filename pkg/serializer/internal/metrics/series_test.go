@@ -343,6 +343,27 @@ func TestMarshalSplitCompressPointsLimitTooBig(t *testing.T) {
 	require.Len(t, payloads, 0)
 }
 
+func TestSeriesMarshalSplitCompress(t *testing.T) {
+	var series Series
+	iterableSeries := makeSeries(10, 50)
+	for iterableSeries.MoveNext() {
+		series = append(series, iterableSeries.Current())
+	}
+
+	expected, err := makeSeries(10, 50).MarshalSplitCompress(marshaler.DefaultBufferContext())
+	require.NoError(t, err)
+	payloads, err := series.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	require.NoError(t, err)
+	require.Len(t, payloads, len(expected))
+	for i := range payloads {
+		expectedPayload, err := decompressPayload(*expected[i])
+		require.NoError(t, err)
+		payload, err := decompressPayload(*payloads[i])
+		require.NoError(t, err)
+		assert.Equal(t, expectedPayload, payload)
+	}
+}
+
 // test taken from the spliter
 func TestPayloadsSeries(t *testing.T) {
 	testSeries := metrics.Series{}
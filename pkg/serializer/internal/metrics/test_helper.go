@@ -11,6 +11,10 @@ package metrics
 import (
 	"fmt"
 
+	"github.com/golang/snappy"
+	"github.com/richardartoul/molecule"
+	"github.com/richardartoul/molecule/src/codec"
+
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/quantile"
@@ -60,3 +64,64 @@ func CreateIterableSeries(series metrics.Series) *metrics.IterableSeries {
 	iterableSeries.SenderStopped()
 	return iterableSeries
 }
+
+// RemoteWriteTimeseries is a decoded remote write timeseries
+type RemoteWriteTimeseries struct {
+	Labels     map[string]string
+	Values     []float64
+	Timestamps []int64
+}
+
+// DecodeRemoteWrite decompresses and decodes a remote write payload
+func DecodeRemoteWrite(payload []byte) ([]RemoteWriteTimeseries, error) {
+	data, err := snappy.Decode(nil, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeseries []RemoteWriteTimeseries
+	err = molecule.MessageEach(codec.NewBuffer(data), func(fieldNum int32, value molecule.Value) (bool, error) {
+		if fieldNum != writeRequestTimeseries {
+			return true, nil
+		}
+		ts := RemoteWriteTimeseries{Labels: map[string]string{}}
+		err := molecule.MessageEach(codec.NewBuffer(value.Bytes), func(fieldNum int32, value molecule.Value) (bool, error) {
+			switch fieldNum {
+			case timeseriesLabels:
+				var name, labelVal string
+				err := molecule.MessageEach(codec.NewBuffer(value.Bytes), func(fieldNum int32, value molecule.Value) (bool, error) {
+					var err error
+					switch fieldNum {
+					case labelName:
+						name, err = value.AsStringSafe()
+					case labelValue:
+						labelVal, err = value.AsStringSafe()
+					}
+					return true, err
+				})
+				ts.Labels[name] = labelVal
+				return true, err
+			case timeseriesSamples:
+				var v float64
+				var timestamp int64
+				err := molecule.MessageEach(codec.NewBuffer(value.Bytes), func(fieldNum int32, value molecule.Value) (bool, error) {
+					var err error
+					switch fieldNum {
+					case sampleValue:
+						v, err = value.AsDouble()
+					case sampleTimestamp:
+						timestamp, err = value.AsInt64()
+					}
+					return true, err
+				})
+				ts.Values = append(ts.Values, v)
+				ts.Timestamps = append(ts.Timestamps, timestamp)
+				return true, err
+			}
+			return true, nil
+		})
+		timeseries = append(timeseries, ts)
+		return true, err
+	})
+	return timeseries, err
+}
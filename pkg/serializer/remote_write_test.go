@@ -0,0 +1,158 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+// +build test
+
+package serializer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/forwarder"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/remotewrite"
+	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
+)
+
+func createRemoteWriteMatcher(names ...string) interface{} {
+	return mock.MatchedBy(func(payloads forwarder.Payloads) bool {
+		var decodedNames []string
+		for _, payload := range payloads {
+			timeseries, err := metricsserializer.DecodeRemoteWrite(*payload)
+			if err != nil {
+				return false
+			}
+			for _, ts := range timeseries {
+				decodedNames = append(decodedNames, ts.Labels["__name__"])
+			}
+		}
+		return assert.ObjectsAreEqual(names, decodedNames)
+	})
+}
+
+var remoteWriteHeadersMatcher = mock.MatchedBy(func(headers http.Header) bool {
+	return headers.Get("Content-Encoding") == "snappy" &&
+		headers.Get("Content-Type") == protobufContentType &&
+		headers.Get(remoteWriteVersionHTTPHeader) == remoteWriteVersion
+})
+
+func TestSendRemoteWriteSeries(t *testing.T) {
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1Series", mock.Anything, mock.Anything).Return(nil).Times(1)
+	rw := &forwarder.MockedForwarder{}
+	rw.On("SubmitRemoteWrite", createRemoteWriteMatcher("a", "b"), remoteWriteHeadersMatcher).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil, rw)
+	series := metrics.Series{
+		{Name: "a", Points: []metrics.Point{{Ts: 10, Value: 1}}},
+		{Name: "b", Points: []metrics.Point{{Ts: 10, Value: 2}}},
+	}
+	err := s.SendIterableSeries(metricsserializer.CreateIterableSeries(series))
+	require.NoError(t, err)
+	f.AssertExpectations(t)
+	rw.AssertExpectations(t)
+}
+
+func TestSendRemoteWriteSeriesPayloadsDisabled(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("enable_payloads.series", false)
+	defer mockConfig.Set("enable_payloads.series", true)
+
+	f := &forwarder.MockedForwarder{}
+	rw := &forwarder.MockedForwarder{}
+	rw.On("SubmitRemoteWrite", createRemoteWriteMatcher("a"), remoteWriteHeadersMatcher).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil, rw)
+	series := metrics.Series{{Name: "a", Points: []metrics.Point{{Ts: 10, Value: 1}}}}
+	err := s.SendIterableSeries(metricsserializer.CreateIterableSeries(series))
+	require.NoError(t, err)
+	f.AssertNotCalled(t, "SubmitV1Series")
+	f.AssertNotCalled(t, "SubmitSeries")
+	rw.AssertExpectations(t)
+}
+
+func TestSendRemoteWriteSketch(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("remote_write.sketch_quantiles", []string{"0.5", "invalid"})
+	defer mockConfig.Set("remote_write.sketch_quantiles", nil)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSketchSeries", mock.Anything, mock.Anything).Return(nil).Times(1)
+	rw := &forwarder.MockedForwarder{}
+	rw.On("SubmitRemoteWrite", createRemoteWriteMatcher("name_0_count", "name_0_sum", "name_0_min", "name_0_max", "name_0"), remoteWriteHeadersMatcher).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil, rw)
+	err := s.SendSketch(metrics.SketchSeriesList{metricsserializer.Makeseries(0)})
+	require.NoError(t, err)
+	f.AssertExpectations(t)
+	rw.AssertExpectations(t)
+}
+
+func TestSendRemoteWriteToEndpoint(t *testing.T) {
+	type request struct {
+		path   string
+		header http.Header
+		body   []byte
+		user   string
+		pass   string
+	}
+	requests := make(chan request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		user, pass, _ := r.BasicAuth()
+		requests <- request{path: r.URL.Path, header: r.Header, body: body, user: user, pass: pass}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockConfig := config.Mock()
+	mockConfig.Set("remote_write.enabled", true)
+	mockConfig.Set("remote_write.endpoints", []string{"http://user:secret@" + server.Listener.Addr().String() + "/api/v1/push"})
+	mockConfig.Set("remote_write.headers", map[string]string{"X-Scope-OrgID": "tenant"})
+	defer func() {
+		mockConfig.Set("remote_write.enabled", false)
+		mockConfig.Set("remote_write.endpoints", []string{})
+		mockConfig.Set("remote_write.headers", map[string]string{})
+	}()
+
+	rw := remotewrite.NewForwarder()
+	require.NotNil(t, rw)
+	require.NoError(t, rw.Start())
+	defer rw.Stop()
+
+	s := NewSerializer(forwarder.NoopForwarder{}, nil, nil, rw)
+	series := metrics.Series{{Name: "my.metric", Host: "localhost", Points: []metrics.Point{{Ts: 10, Value: 1}}}}
+	require.NoError(t, s.SendIterableSeries(metricsserializer.CreateIterableSeries(series)))
+
+	select {
+	case r := <-requests:
+		assert.Equal(t, "/api/v1/push", r.path)
+		assert.Equal(t, "snappy", r.header.Get("Content-Encoding"))
+		assert.Equal(t, protobufContentType, r.header.Get("Content-Type"))
+		assert.Equal(t, remoteWriteVersion, r.header.Get(remoteWriteVersionHTTPHeader))
+		assert.Equal(t, "tenant", r.header.Get("X-Scope-OrgID"))
+		assert.Equal(t, "user", r.user)
+		assert.Equal(t, "secret", r.pass)
+
+		timeseries, err := metricsserializer.DecodeRemoteWrite(r.body)
+		require.NoError(t, err)
+		require.Len(t, timeseries, 1)
+		assert.Equal(t, map[string]string{"__name__": "my_metric", "host": "localhost"}, timeseries[0].Labels)
+		assert.Equal(t, []float64{1}, timeseries[0].Values)
+		assert.Equal(t, []int64{10000}, timeseries[0].Timestamps)
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "the remote write endpoint did not receive the payload")
+	}
+}
@@ -33,6 +33,8 @@ const (
 	protobufContentType                         = "application/x-protobuf"
 	jsonContentType                             = "application/json"
 	payloadVersionHTTPHeader                    = "DD-Agent-Payload"
+	remoteWriteVersionHTTPHeader                = "X-Prometheus-Remote-Write-Version"
+	remoteWriteVersion                          = "0.1.0"
 	maxItemCountForCreateMarshalersBySourceType = 100
 )
 
@@ -102,6 +104,7 @@ type Serializer struct {
 	Forwarder             forwarder.Forwarder
 	orchestratorForwarder forwarder.Forwarder
 	contlcycleForwarder   forwarder.Forwarder
+	remoteWriteForwarder  forwarder.Forwarder
 
 	seriesJSONPayloadBuilder *stream.JSONPayloadBuilder
 
@@ -120,14 +123,25 @@ type Serializer struct {
 	enableServiceChecksJSONStream bool
 	enableEventsJSONStream        bool
 	enableSketchProtobufStream    bool
+
+	// The series and sketches sent to these domains are restricted to the
+	// metrics matching the prefixes of their forwarder routing rule.
+	metricRoutes []config.ForwarderRoutingRule
+
+	// The series and sketches are also sent to the Prometheus remote write
+	// endpoints if the remote write forwarder is set.
+	remoteWriteMaxSamples  int
+	remoteWriteQuantiles   []float64
+	remoteWriteExtraHeader http.Header
 }
 
 // NewSerializer returns a new Serializer initialized
-func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder, contlcycleForwarder forwarder.Forwarder) *Serializer {
+func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder, contlcycleForwarder, remoteWriteForwarder forwarder.Forwarder) *Serializer {
 	s := &Serializer{
 		Forwarder:                     forwarder,
 		orchestratorForwarder:         orchestratorForwarder,
 		contlcycleForwarder:           contlcycleForwarder,
+		remoteWriteForwarder:          remoteWriteForwarder,
 		seriesJSONPayloadBuilder:      stream.NewJSONPayloadBuilder(config.Datadog.GetBool("enable_json_stream_shared_compressor_buffers")),
 		enableEvents:                  config.Datadog.GetBool("enable_payloads.events"),
 		enableSeries:                  config.Datadog.GetBool("enable_payloads.series"),
@@ -155,10 +169,86 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder, contlcy
 	if !s.enableJSONToV1Intake {
 		log.Warn("JSON to V1 intake is disabled: all payloads to that endpoint will be dropped")
 	}
+	if s.remoteWriteForwarder != nil {
+		s.initRemoteWrite()
+	}
+
+	routingRules, _ := config.GetForwarderRoutingRules()
+	for _, rule := range routingRules {
+		if len(rule.MetricPrefixes) > 0 {
+			s.metricRoutes = append(s.metricRoutes, rule)
+		}
+	}
 
 	return s
 }
 
+// newMetricRoutes returns empty routes collecting the metrics sent to the domains
+// with metric prefixes.
+func (s *Serializer) newMetricRoutes() []*metricsserializer.MetricRoute {
+	routes := make([]*metricsserializer.MetricRoute, 0, len(s.metricRoutes))
+	for _, rule := range s.metricRoutes {
+		routes = append(routes, &metricsserializer.MetricRoute{Domain: rule.Domain, Prefixes: rule.MetricPrefixes})
+	}
+	return routes
+}
+
+// sendRoutedSeries sends the series collected by a route to its domain.
+func (s *Serializer) sendRoutedSeries(route *metricsserializer.MetricRoute, useV1API bool) error {
+	if len(route.Series) == 0 {
+		return nil
+	}
+
+	if useV1API {
+		payloads, extraHeaders, err := s.serializePayloadJSON(route.Series, true)
+		if err != nil {
+			return fmt.Errorf("dropping series payload for %s: %s", route.Domain, err)
+		}
+		return s.Forwarder.SubmitV1SeriesToDomain(route.Domain, payloads, extraHeaders)
+	}
+
+	payloads, err := route.Series.MarshalSplitCompress(marshaler.DefaultBufferContext())
+	if err != nil {
+		return fmt.Errorf("dropping series payload for %s: %s", route.Domain, err)
+	}
+	return s.Forwarder.SubmitSeriesToDomain(route.Domain, payloads, protobufExtraHeadersWithCompression)
+}
+
+// initRemoteWrite reads the configuration of the Prometheus remote write output.
+func (s *Serializer) initRemoteWrite() {
+	s.remoteWriteMaxSamples = config.Datadog.GetInt("remote_write.max_samples_per_send")
+
+	for _, q := range config.Datadog.GetStringSlice("remote_write.sketch_quantiles") {
+		value, err := strconv.ParseFloat(q, 64)
+		if err != nil || value < 0 || value > 1 {
+			log.Errorf("Invalid quantile '%s' in 'remote_write.sketch_quantiles', it must be between 0 and 1 (skipping)", q)
+			continue
+		}
+		s.remoteWriteQuantiles = append(s.remoteWriteQuantiles, value)
+	}
+
+	s.remoteWriteExtraHeader = make(http.Header)
+	for k, v := range config.Datadog.GetStringMapString("remote_write.headers") {
+		s.remoteWriteExtraHeader.Set(k, v)
+	}
+	s.remoteWriteExtraHeader.Set("Content-Type", protobufContentType)
+	s.remoteWriteExtraHeader.Set("Content-Encoding", "snappy")
+	s.remoteWriteExtraHeader.Set(remoteWriteVersionHTTPHeader, remoteWriteVersion)
+}
+
+// sendRemoteWrite sends the payloads built by the encoder to the Prometheus
+// remote write endpoints.
+func (s *Serializer) sendRemoteWrite(encoder *metricsserializer.RemoteWriteEncoder) error {
+	payloads := encoder.Payloads()
+	if len(payloads) == 0 {
+		return nil
+	}
+	if err := s.remoteWriteForwarder.SubmitRemoteWrite(payloads, s.remoteWriteExtraHeader); err != nil {
+		return fmt.Errorf("dropping remote write payload: %s", err)
+	}
+	return nil
+}
+
 func (s Serializer) serializePayload(
 	jsonMarshaler marshaler.JSONMarshaler,
 	protoMarshaler marshaler.ProtoMarshaler,
@@ -298,12 +388,24 @@ func (s *Serializer) SendServiceChecks(serviceChecks metrics.ServiceChecks) erro
 
 // SendIterableSeries serializes a list of series and sends the payload to the forwarder
 func (s *Serializer) SendIterableSeries(series *metrics.IterableSeries) error {
+	var remoteWrite *metricsserializer.RemoteWriteEncoder
+	if s.remoteWriteForwarder != nil {
+		remoteWrite = metricsserializer.NewRemoteWriteEncoder(s.remoteWriteMaxSamples, nil)
+	}
+
 	if !s.enableSeries {
 		log.Debug("series payloads are disabled: dropping it")
-		return nil
+		if remoteWrite == nil {
+			return nil
+		}
+		for series.MoveNext() {
+			remoteWrite.AddSerie(series.Current())
+		}
+		return s.sendRemoteWrite(remoteWrite)
 	}
 
-	seriesSerializer := metricsserializer.IterableSeries{IterableSeries: series}
+	routes := s.newMetricRoutes()
+	seriesSerializer := metricsserializer.IterableSeries{IterableSeries: series, RemoteWrite: remoteWrite, Routes: routes}
 	useV1API := !config.Datadog.GetBool("use_v2_api.series")
 
 	var seriesPayloads forwarder.Payloads
@@ -323,6 +425,18 @@ func (s *Serializer) SendIterableSeries(series *metrics.IterableSeries) error {
 		return fmt.Errorf("dropping series payload: %s", err)
 	}
 
+	if remoteWrite != nil {
+		if err := s.sendRemoteWrite(remoteWrite); err != nil {
+			log.Warn(err)
+		}
+	}
+
+	for _, route := range routes {
+		if err := s.sendRoutedSeries(route, useV1API); err != nil {
+			log.Warn(err)
+		}
+	}
+
 	if useV1API {
 		return s.Forwarder.SubmitV1Series(seriesPayloads, extraHeaders)
 	}
@@ -331,27 +445,53 @@ func (s *Serializer) SendIterableSeries(series *metrics.IterableSeries) error {
 
 // SendSketch serializes a list of SketSeriesList and sends the payload to the forwarder
 func (s *Serializer) SendSketch(sketches metrics.SketchSeriesList) error {
+	if s.remoteWriteForwarder != nil {
+		remoteWrite := metricsserializer.NewRemoteWriteEncoder(s.remoteWriteMaxSamples, s.remoteWriteQuantiles)
+		remoteWrite.AddSketchSeries(sketches)
+		if err := s.sendRemoteWrite(remoteWrite); err != nil {
+			log.Warn(err)
+		}
+	}
+
 	if !s.enableSketches {
 		log.Debug("sketches payloads are disabled: dropping it")
 		return nil
 	}
+
+	for _, route := range s.newMetricRoutes() {
+		routedSketches := route.FilterSketches(sketches)
+		if len(routedSketches) == 0 {
+			continue
+		}
+		payloads, extraHeaders, err := s.serializeSketches(routedSketches)
+		if err == nil {
+			err = s.Forwarder.SubmitSketchSeriesToDomain(route.Domain, payloads, extraHeaders)
+		}
+		if err != nil {
+			log.Warnf("dropping sketch payload for %s: %s", route.Domain, err)
+		}
+	}
+
+	payloads, extraHeaders, err := s.serializeSketches(sketches)
+	if err != nil {
+		return fmt.Errorf("dropping sketch payload: %s", err)
+	}
+	return s.Forwarder.SubmitSketchSeries(payloads, extraHeaders)
+}
+
+func (s *Serializer) serializeSketches(sketches metrics.SketchSeriesList) (forwarder.Payloads, http.Header, error) {
 	sketchesSerializer := metricsserializer.SketchSeriesList(sketches)
 	if s.enableSketchProtobufStream {
 		payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.DefaultBufferContext())
 		if err == nil {
-			return s.Forwarder.SubmitSketchSeries(payloads, protobufExtraHeadersWithCompression)
+			return payloads, protobufExtraHeadersWithCompression, nil
 		}
 		log.Warnf("Error: %v trying to stream compress SketchSeriesList - falling back to split/compress method", err)
 	}
 
 	compress := true
 	useV1API := false // Sketches only have a v2 endpoint
-	splitSketches, extraHeaders, err := s.serializePayload(sketchesSerializer, sketchesSerializer, compress, useV1API)
-	if err != nil {
-		return fmt.Errorf("dropping sketch payload: %s", err)
-	}
-
-	return s.Forwarder.SubmitSketchSeries(splitSketches, extraHeaders)
+	return s.serializePayload(sketchesSerializer, sketchesSerializer, compress, useV1API)
 }
 
 // SendMetadata serializes a metadata payload and sends it to the forwarder
@@ -405,7 +545,7 @@ func (s *Serializer) SendProcessesMetadata(data interface{}) error {
 	if err != nil {
 		return fmt.Errorf("could not compress processes metadata payload: %s", err)
 	}
-	if err := s.Forwarder.SubmitV1Intake(forwarder.Payloads{&compressedPayload}, jsonExtraHeadersWithCompression); err != nil {
+	if err := s.Forwarder.SubmitProcessesMetadata(forwarder.Payloads{&compressedPayload}, jsonExtraHeadersWithCompression); err != nil {
 		return err
 	}
 
@@ -213,7 +213,7 @@ func TestSendV1Events(t *testing.T) {
 	matcher := createJSONPayloadMatcher(`{"apiKey":"","events":{},"internalHostname"`)
 	f.On("SubmitV1Intake", matcher, jsonExtraHeadersWithCompression).Return(nil).Times(1)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 	err := s.SendEvents([]*metrics.Event{})
 	require.Nil(t, err)
 	f.AssertExpectations(t)
@@ -231,7 +231,7 @@ func TestSendV1EventsCreateMarshalersBySourceType(t *testing.T) {
 	defer config.Datadog.Set("enable_events_stream_payload_serialization", nil)
 	f := &forwarder.MockedForwarder{}
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 
 	events := metrics.Events{&metrics.Event{SourceTypeName: "source1"}, &metrics.Event{SourceTypeName: "source2"}, &metrics.Event{SourceTypeName: "source3"}}
 	payloadsCountMatcher := func(payloadCount int) interface{} {
@@ -261,7 +261,7 @@ func TestSendV1ServiceChecks(t *testing.T) {
 	config.Datadog.Set("enable_service_checks_stream_payload_serialization", false)
 	defer config.Datadog.Set("enable_service_checks_stream_payload_serialization", nil)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 	err := s.SendServiceChecks(metrics.ServiceChecks{&metrics.ServiceCheck{}})
 	require.Nil(t, err)
 	f.AssertExpectations(t)
@@ -275,7 +275,7 @@ func TestSendV1Series(t *testing.T) {
 	config.Datadog.Set("enable_stream_payload_serialization", false)
 	defer config.Datadog.Set("enable_stream_payload_serialization", nil)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 
 	err := s.SendIterableSeries(metricsserializer.CreateIterableSeries(metrics.Series{}))
 	require.Nil(t, err)
@@ -289,7 +289,7 @@ func TestSendSeries(t *testing.T) {
 	config.Datadog.Set("use_v2_api.series", true)
 	defer config.Datadog.Set("use_v2_api.series", false)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 
 	err := s.SendIterableSeries(metricsserializer.CreateIterableSeries(metrics.Series{&metrics.Serie{}}))
 	require.Nil(t, err)
@@ -302,7 +302,7 @@ func TestSendSketch(t *testing.T) {
 	matcher := createProtoPayloadMatcher([]byte{18, 0})
 	f.On("SubmitSketchSeries", matcher, protobufExtraHeadersWithCompression).Return(nil).Times(1)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 	err := s.SendSketch(metrics.SketchSeriesList{})
 	require.Nil(t, err)
 	f.AssertExpectations(t)
@@ -312,7 +312,7 @@ func TestSendMetadata(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 	f.On("SubmitMetadata", jsonPayloads, jsonExtraHeadersWithCompression).Return(nil).Times(1)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 
 	payload := &testPayload{}
 	err := s.SendMetadata(payload)
@@ -333,15 +333,15 @@ func TestSendProcessesMetadata(t *testing.T) {
 	f := &forwarder.MockedForwarder{}
 	payload := []byte("\"test\"")
 	payloads, _ := mkPayloads(payload, true)
-	f.On("SubmitV1Intake", payloads, jsonExtraHeadersWithCompression).Return(nil).Times(1)
+	f.On("SubmitProcessesMetadata", payloads, jsonExtraHeadersWithCompression).Return(nil).Times(1)
 
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 
 	err := s.SendProcessesMetadata("test")
 	require.Nil(t, err)
 	f.AssertExpectations(t)
 
-	f.On("SubmitV1Intake", payloads, jsonExtraHeadersWithCompression).Return(fmt.Errorf("some error")).Times(1)
+	f.On("SubmitProcessesMetadata", payloads, jsonExtraHeadersWithCompression).Return(fmt.Errorf("some error")).Times(1)
 	err = s.SendProcessesMetadata("test")
 	require.NotNil(t, err)
 	f.AssertExpectations(t)
@@ -370,7 +370,7 @@ func TestSendWithDisabledKind(t *testing.T) {
 	}()
 
 	f := &forwarder.MockedForwarder{}
-	s := NewSerializer(f, nil, nil)
+	s := NewSerializer(f, nil, nil, nil)
 
 	payload := &testPayload{}
 
@@ -390,3 +390,73 @@ func TestSendWithDisabledKind(t *testing.T) {
 	s.SendMetadata(payload)
 	f.AssertNumberOfCalls(t, "SubmitMetadata", 1) // called once for the metadata
 }
+
+func TestSendRoutedSeries(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("forwarder_routing_rules", []map[string]interface{}{
+		{"domain": "https://app.datadoghq.eu", "metric_prefixes": []string{"prod."}},
+	})
+	mockConfig.Set("use_v2_api.series", true)
+	defer mockConfig.Set("forwarder_routing_rules", nil)
+	defer mockConfig.Set("use_v2_api.series", false)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSeries", mock.Anything, protobufExtraHeadersWithCompression).Return(nil).Times(1)
+	f.On("SubmitSeriesToDomain", "https://app.datadoghq.eu", mock.Anything, protobufExtraHeadersWithCompression).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil, nil)
+	series := metrics.Series{
+		{Name: "prod.requests", Points: []metrics.Point{{Ts: 10, Value: 1}}},
+		{Name: "staging.requests", Points: []metrics.Point{{Ts: 10, Value: 1}}},
+	}
+	err := s.SendIterableSeries(metricsserializer.CreateIterableSeries(series))
+	require.NoError(t, err)
+	f.AssertExpectations(t)
+}
+
+func TestSendRoutedV1Series(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("forwarder_routing_rules", []map[string]interface{}{
+		{"domain": "https://app.datadoghq.eu", "metric_prefixes": []string{"prod."}},
+	})
+	mockConfig.Set("enable_stream_payload_serialization", false)
+	defer mockConfig.Set("forwarder_routing_rules", nil)
+	defer mockConfig.Set("enable_stream_payload_serialization", nil)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitV1Series", createJSONPayloadMatcher(`{"series":[{"metric":"prod.requests"`), jsonExtraHeadersWithCompression).Return(nil).Times(1)
+	f.On("SubmitV1SeriesToDomain", "https://app.datadoghq.eu", mock.MatchedBy(func(payloads forwarder.Payloads) bool {
+		require.Len(t, payloads, 1)
+		payload, err := compression.Decompress(*payloads[0])
+		require.NoError(t, err)
+		return strings.Contains(string(payload), `"metric":"prod.requests"`) && !strings.Contains(string(payload), "staging")
+	}), jsonExtraHeadersWithCompression).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil, nil)
+	series := metrics.Series{
+		{Name: "prod.requests", Points: []metrics.Point{{Ts: 10, Value: 1}}},
+		{Name: "staging.requests", Points: []metrics.Point{{Ts: 10, Value: 1}}},
+	}
+	err := s.SendIterableSeries(metricsserializer.CreateIterableSeries(series))
+	require.NoError(t, err)
+	f.AssertExpectations(t)
+}
+
+func TestSendRoutedSketch(t *testing.T) {
+	mockConfig := config.Mock()
+	mockConfig.Set("forwarder_routing_rules", []map[string]interface{}{
+		{"domain": "https://app.datadoghq.eu", "metric_prefixes": []string{"name.1"}},
+		{"domain": "https://other.datadoghq.com", "metric_prefixes": []string{"unknown."}},
+	})
+	defer mockConfig.Set("forwarder_routing_rules", nil)
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSketchSeries", mock.Anything, mock.Anything).Return(nil).Times(1)
+	f.On("SubmitSketchSeriesToDomain", "https://app.datadoghq.eu", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil, nil, nil)
+	err := s.SendSketch(metrics.SketchSeriesList{metricsserializer.Makeseries(1), metricsserializer.Makeseries(2)})
+	require.NoError(t, err)
+	f.AssertExpectations(t)
+	f.AssertNotCalled(t, "SubmitSketchSeriesToDomain", "https://other.datadoghq.com", mock.Anything, mock.Anything)
+}
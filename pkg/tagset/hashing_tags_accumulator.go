@@ -98,6 +98,22 @@ func (h *HashingTagsAccumulator) Truncate(len int) {
 	h.hash = h.hash[0:len]
 }
 
+// Retain removes in place the tags for which keep returns false, preserving the
+// order of the remaining tags
+func (h *HashingTagsAccumulator) Retain(keep func(tag string) bool) {
+	j := 0
+	for i := range h.data {
+		if !keep(h.data[i]) {
+			continue
+		}
+		h.data[j] = h.data[i]
+		h.hash[j] = h.hash[i]
+		j++
+	}
+
+	h.Truncate(j)
+}
+
 // Less implements sort.Interface.Less
 func (h *HashingTagsAccumulator) Less(i, j int) bool {
 	// FIXME(vickenty): could sort using hashes, which is faster, but a lot of tests check for order.
@@ -69,3 +69,16 @@ func TestHashingTagsAccumulatorCopy(t *testing.T) {
 	assert.Equal(t, []string{"test", "b", "c"}, tagsCopy)
 	assert.Equal(t, []string{"a", "b", "c"}, tb.data)
 }
+
+func TestHashingTagsAccumulatorRetain(t *testing.T) {
+	tb := NewHashingTagsAccumulatorWithTags([]string{"a", "b:1", "c", "b:2", "d"})
+	expected := NewHashingTagsAccumulatorWithTags([]string{"a", "c", "d"})
+
+	tb.Retain(func(tag string) bool { return tag[0] != 'b' })
+	assert.Equal(t, expected.data, tb.data)
+	assert.Equal(t, expected.hash, tb.hash)
+
+	tb.Retain(func(string) bool { return false })
+	assert.Equal(t, []string{}, tb.data)
+	assert.Equal(t, []uint64{}, tb.hash)
+}
@@ -8,6 +8,7 @@ package agent
 import (
 	"context"
 	"runtime"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -42,10 +43,12 @@ type Agent struct {
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	SpanRules             *filters.SpanRules
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
+	TailSampler           *sampler.TailSampler // nil if the tail sampling is disabled
 	EventProcessor        *event.Processor
 	TraceWriter           *writer.TraceWriter
 	StatsWriter           *writer.StatsWriter
@@ -84,6 +87,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsChan),
 		Blacklister:           filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:              filters.NewReplacer(conf.ReplaceTags),
+		SpanRules:             filters.NewSpanRules(conf.SpanRules),
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(),
@@ -97,6 +101,9 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		conf:                  conf,
 		ctx:                   ctx,
 	}
+	if conf.TailSampling != nil && conf.TailSampling.Enabled {
+		agnt.TailSampler = sampler.NewTailSampler(conf.TailSampling, agnt.sendTailChunks)
+	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf)
 	return agnt
@@ -116,6 +123,9 @@ func (a *Agent) Run() {
 	} {
 		starter.Start()
 	}
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
 
 	go a.TraceWriter.Run()
 	go a.StatsWriter.Run()
@@ -166,6 +176,10 @@ func (a *Agent) loop() {
 			if err := a.Receiver.Stop(); err != nil {
 				log.Error(err)
 			}
+			if a.TailSampler != nil {
+				// flush the buffered traces before stopping the writer
+				a.TailSampler.Stop()
+			}
 			for _, stopper := range []interface{ Stop() }{
 				a.Concentrator,
 				a.ClientStatsAggregator,
@@ -200,6 +214,9 @@ func (a *Agent) Process(p *api.Payload) {
 	ts := p.Source
 	ss := new(writer.SampledChunks)
 	statsInput := stats.NewStatsInput(len(p.TracerPayload.Chunks), p.TracerPayload.ContainerID, p.ClientComputedStats, a.conf)
+	// tailChunks holds the chunks for the tail sampler, added once the payload metadata
+	// filled from the roots of the chunks is complete.
+	var tailChunks []tailChunk
 
 	p.TracerPayload.Env = traceutil.NormalizeTag(p.TracerPayload.Env)
 	for i := 0; i < len(p.Chunks()); {
@@ -223,6 +240,24 @@ func (a *Agent) Process(p *api.Payload) {
 		// Root span is used to carry some trace-level metadata, such as sampling rate and priority.
 		root := traceutil.GetRoot(chunk.Spans)
 		normalizeChunk(chunk, root)
+
+		// The span rules are applied before the stats are computed, so that they are
+		// consistent with the traces. They may drop the root, the priority and the origin
+		// are already carried by the chunk.
+		spans, keepTrace := a.SpanRules.Apply(chunk.Spans)
+		if !keepTrace || len(spans) == 0 {
+			log.Debugf("Trace rejected by span rules.")
+			atomic.AddInt64(&ts.TracesFiltered, 1)
+			atomic.AddInt64(&ts.SpansFiltered, tracen)
+			p.RemoveChunk(i)
+			continue
+		}
+		if dropped := tracen - int64(len(spans)); dropped > 0 {
+			atomic.AddInt64(&ts.SpansFiltered, dropped)
+			chunk.Spans = spans
+			tracen = int64(len(spans))
+			root = keptRoot(spans, root)
+		}
 		if !a.Blacklister.Allows(root) {
 			log.Debugf("Trace rejected by ignore resources rules. root: %v", root)
 			atomic.AddInt64(&ts.TracesFiltered, 1)
@@ -299,6 +334,20 @@ func (a *Agent) Process(p *api.Payload) {
 		}
 
 		numEvents, keep, filteredChunk := a.sample(now, ts, pt)
+		if a.TailSampler != nil && filteredChunk != nil {
+			// the chunks rejected by the user (filteredChunk is nil) are never kept
+			tc := &sampler.TailChunk{Chunk: chunk, NumEvents: numEvents}
+			if !keep && numEvents > 0 {
+				tc.DroppedChunk = filteredChunk
+			}
+			tailChunks = append(tailChunks, tailChunk{TailChunk: tc, sampled: keep})
+			if !keep {
+				// the chunk is buffered until the decision is made, or sent with
+				// the chunks of its trace if it is already kept
+				p.RemoveChunk(i)
+				continue
+			}
+		}
 		if !keep {
 			if numEvents == 0 {
 				// the trace was dropped and no analyzed span were kept
@@ -327,6 +376,9 @@ func (a *Agent) Process(p *api.Payload) {
 			ss = new(writer.SampledChunks)
 		}
 	}
+	if len(tailChunks) > 0 {
+		a.addTailChunks(now, tracerPayloadMetadata(p.TracerPayload), tailChunks)
+	}
 	ss.TracerPayload = p.TracerPayload
 	ss.TracerPayload.Chunks = newChunksArray(p.TracerPayload.Chunks)
 	if ss.Size > 0 {
@@ -337,6 +389,62 @@ func (a *Agent) Process(p *api.Payload) {
 	}
 }
 
+// tracerPayloadMetadata returns a copy of tp without its chunks.
+func tracerPayloadMetadata(tp *pb.TracerPayload) *pb.TracerPayload {
+	metadata := *tp
+	metadata.Chunks = nil
+	return &metadata
+}
+
+// tailChunk is a chunk for the tail sampler.
+type tailChunk struct {
+	*sampler.TailChunk
+	// sampled reports whether the chunk was sampled by the other samplers, in which
+	// case it is already sent with its payload.
+	sampled bool
+}
+
+// addTailChunks adds to the TailSampler the chunks of a payload, with the metadata of
+// the payload, in the order they were received. The chunks not sampled by the other
+// samplers whose trace is already kept are sent right away.
+func (a *Agent) addTailChunks(now time.Time, metadata *pb.TracerPayload, chunks []tailChunk) {
+	var send []*sampler.TailChunk
+	for _, c := range chunks {
+		c.TracerPayload = metadata
+		if a.TailSampler.Add(now, c.TailChunk, c.sampled) && !c.sampled {
+			send = append(send, c.TailChunk)
+		}
+	}
+	if len(send) > 0 {
+		a.sendTailChunks(send)
+	}
+}
+
+// sendTailChunks sends to the TraceWriter the chunks released by the TailSampler,
+// grouped by the payload in which they were received.
+func (a *Agent) sendTailChunks(chunks []*sampler.TailChunk) {
+	payloads := make(map[*pb.TracerPayload]*writer.SampledChunks)
+	var order []*writer.SampledChunks
+	for _, c := range chunks {
+		ss, ok := payloads[c.TracerPayload]
+		if !ok || ss.Size > writer.MaxPayloadSize {
+			tp := *c.TracerPayload
+			ss = &writer.SampledChunks{TracerPayload: &tp}
+			payloads[c.TracerPayload] = ss
+			order = append(order, ss)
+		}
+		ss.TracerPayload.Chunks = append(ss.TracerPayload.Chunks, c.Chunk)
+		if !c.Chunk.DroppedTrace {
+			ss.SpanCount += int64(len(c.Chunk.Spans))
+		}
+		ss.EventCount += c.NumEvents
+		ss.Size += c.Chunk.Msgsize()
+	}
+	for _, ss := range order {
+		a.TraceWriter.In <- ss
+	}
+}
+
 // newChunksArray creates a new array which will point only to sampled chunks.
 
 // The underlying array behind TracePayload.Chunks points to unsampled chunks
@@ -476,6 +584,49 @@ func traceContainsError(trace pb.Trace) bool {
 	return false
 }
 
+// keptRoot returns the root of the spans kept by the span rules. If the rules dropped
+// root, its trace-level metrics and meta are moved to the new root, so that the samplers
+// and the stats see the same rates, weight and metadata as if it was kept.
+func keptRoot(spans []*pb.Span, root *pb.Span) *pb.Span {
+	for _, s := range spans {
+		if s == root {
+			return root
+		}
+	}
+	newRoot := traceutil.GetRoot(spans)
+	for k, v := range root.Metrics {
+		if isTraceLevelMetric(k) {
+			traceutil.SetMetric(newRoot, k, v)
+		}
+	}
+	for k, v := range root.Meta {
+		if isTraceLevelMeta(k) {
+			traceutil.SetMeta(newRoot, k, v)
+		}
+	}
+	return newRoot
+}
+
+// isTraceLevelMetric reports whether the root span metric k applies to the whole trace,
+// such as the sampling rates and priority.
+func isTraceLevelMetric(k string) bool {
+	switch k {
+	case sampler.KeySamplingRateGlobal, tagSamplingPriority:
+		return true
+	}
+	return strings.HasSuffix(k, "_psr") || strings.HasPrefix(k, "_dd1.sr.")
+}
+
+// isTraceLevelMeta reports whether the root span meta k applies to the whole trace,
+// such as the env, the tracer hostname and the propagated tags.
+func isTraceLevelMeta(k string) bool {
+	switch k {
+	case "env", "version", tagHostname, tagOrigin:
+		return true
+	}
+	return strings.HasPrefix(k, "_dd.p.")
+}
+
 func filteredByTags(root *pb.Span, require, reject []*config.Tag) bool {
 	for _, tag := range reject {
 		if v, ok := root.Meta[tag.K]; ok && (tag.V == "" || v == tag.V) {
@@ -150,6 +150,147 @@ func TestProcess(t *testing.T) {
 		assert.EqualValues(2, want.SpansFiltered)
 	})
 
+	t.Run("SpanRules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.SpanRules = []*config.SpanRule{
+			{Name: "health_checks", Resource: "^GET /health", Drop: "trace"},
+			{Name: "cache", SpanName: "^redis\\.", Drop: "span"},
+			{Name: "pii", Meta: map[string]string{"user.email": ""}, DeleteTags: []string{"user.email"}, RenameService: "web-store"},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		now := time.Now()
+		newSpan := func(spanID, parentID uint64, name, resource string) *pb.Span {
+			return &pb.Span{
+				TraceID:  1,
+				SpanID:   spanID,
+				ParentID: parentID,
+				Service:  "web",
+				Name:     name,
+				Resource: resource,
+				Start:    now.Add(-time.Second).UnixNano(),
+				Duration: (500 * time.Millisecond).Nanoseconds(),
+			}
+		}
+		root := newSpan(1, 0, "http.request", "GET /users")
+		root.Meta = map[string]string{"user.email": "jane@example.com"}
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		assert := assert.New(t)
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(testutil.TraceChunkWithSpans([]*pb.Span{
+				root,
+				newSpan(2, 1, "redis.command", "GET"),
+			})),
+			Source: want,
+		})
+		assert.EqualValues(0, want.TracesFiltered)
+		assert.EqualValues(1, want.SpansFiltered)
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(testutil.TraceChunkWithSpans([]*pb.Span{
+				newSpan(1, 0, "http.request", "GET /health"),
+				newSpan(2, 1, "db.query", "SELECT 1"),
+			})),
+			Source: want,
+		})
+		assert.EqualValues(1, want.TracesFiltered)
+		assert.EqualValues(3, want.SpansFiltered)
+
+		ss := <-agnt.TraceWriter.In
+		assert.Len(ss.TracerPayload.Chunks, 1)
+		spans := ss.TracerPayload.Chunks[0].Spans
+		assert.Len(spans, 1)
+		assert.Equal("web-store", spans[0].Service)
+		assert.NotContains(spans[0].Meta, "user.email")
+
+		// the stats are computed on the same spans
+		in := <-agnt.Concentrator.In
+		assert.Len(in.Traces, 1)
+		assert.Len(in.Traces[0].TraceChunk.Spans, 1)
+	})
+
+	t.Run("SpanRulesDropRoot", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		// a short bucket interval lets the test flush the stats right away
+		cfg.BucketInterval = time.Millisecond
+		cfg.SpanRules = []*config.SpanRule{
+			{Name: "proxy", SpanName: "^http\\.proxy$", Drop: "span"},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewAgent(ctx, cfg)
+		defer cancel()
+
+		now := time.Now()
+		root := &pb.Span{
+			TraceID:  1,
+			SpanID:   1,
+			Service:  "web",
+			Name:     "http.proxy",
+			Resource: "GET /users",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+			Meta:     map[string]string{"_dd.origin": "rum", "_dd.p.tid": "640cfd8d00000000", "env": "prod", "_dd.p.dm": "-4"},
+			Metrics:  map[string]float64{"_sampling_priority_v1": 2, "_sample_rate": 0.5, "_dd.rule_psr": 0.5},
+		}
+		child := &pb.Span{
+			TraceID:  1,
+			SpanID:   2,
+			ParentID: 1,
+			Service:  "web",
+			Name:     "http.request",
+			Resource: "GET /users",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (200 * time.Millisecond).Nanoseconds(),
+		}
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		assert := assert.New(t)
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(testutil.TraceChunkWithSpans([]*pb.Span{root, child})),
+			Source:        want,
+		})
+		assert.EqualValues(0, want.TracesFiltered)
+		assert.EqualValues(1, want.SpansFiltered)
+		assert.EqualValues(0, want.TracesPriorityNone)
+
+		// the trace-level metadata of the dropped root is kept
+		ss := <-agnt.TraceWriter.In
+		require.Len(t, ss.TracerPayload.Chunks, 1)
+		chunk := ss.TracerPayload.Chunks[0]
+		assert.EqualValues(2, chunk.Priority)
+		assert.Equal("rum", chunk.Origin)
+		require.Len(t, chunk.Spans, 1)
+		assert.Equal("http.request", chunk.Spans[0].Name)
+		kept := chunk.Spans[0]
+		assert.Equal("640cfd8d00000000", kept.Meta["_dd.p.tid"])
+		assert.Equal("-4", kept.Meta["_dd.p.dm"])
+		assert.Equal("prod", kept.Meta["env"])
+		assert.Equal(0.5, kept.Metrics["_sample_rate"])
+		assert.Equal(0.5, kept.Metrics["_dd.rule_psr"])
+		assert.Equal("prod", ss.TracerPayload.Env)
+
+		// the stats are weighted by the sample rate of the dropped root
+		in := <-agnt.Concentrator.In
+		require.Len(t, in.Traces, 1)
+		assert.Equal(kept, in.Traces[0].Root)
+		agnt.Concentrator.Add(in)
+		time.Sleep(5 * time.Millisecond)
+		sp := agnt.Concentrator.Flush()
+		require.Len(t, sp.Stats, 1)
+		var hits uint64
+		for _, b := range sp.Stats[0].Stats {
+			for _, gs := range b.Stats {
+				hits += gs.Hits
+			}
+		}
+		assert.EqualValues(2, hits)
+	})
+
 	t.Run("BlacklistPayload", func(t *testing.T) {
 		// Regression test for DataDog/datadog-agent#6500
 		cfg := config.New()
@@ -354,6 +495,107 @@ func TestProcess(t *testing.T) {
 	})
 }
 
+func TestProcessTailSampling(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.ErrorTPS = 0
+	cfg.DisableRareSampler = true
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Name: "errors", Error: true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	agnt := NewAgent(ctx, cfg)
+	defer cancel()
+
+	newChunk := func(traceID, spanID uint64, isError int32) *pb.TraceChunk {
+		return &pb.TraceChunk{
+			Priority: int32(sampler.PriorityAutoDrop),
+			Spans: []*pb.Span{{
+				TraceID:  traceID,
+				SpanID:   spanID,
+				Service:  "web",
+				Name:     "http.request",
+				Resource: "GET /",
+				Start:    time.Now().UnixNano(),
+				Duration: int64(time.Millisecond),
+				Error:    isError,
+			}},
+		}
+	}
+	process := func(chunk *pb.TraceChunk) {
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+	}
+
+	// the error of trace 1 is in its second chunk, trace 2 has no error
+	process(newChunk(1, 1, 0))
+	process(newChunk(2, 3, 0))
+	process(newChunk(1, 2, 1))
+	assert.Len(t, agnt.TraceWriter.In, 0)
+
+	// the buffered traces are decided when stopping the sampler
+	agnt.TailSampler.Start()
+	agnt.TailSampler.Stop()
+	var spanIDs []uint64
+	for len(agnt.TraceWriter.In) > 0 {
+		ss := <-agnt.TraceWriter.In
+		for _, chunk := range ss.TracerPayload.Chunks {
+			assert.False(t, chunk.DroppedTrace)
+			for _, span := range chunk.Spans {
+				spanIDs = append(spanIDs, span.SpanID)
+			}
+		}
+	}
+	assert.ElementsMatch(t, []uint64{1, 2}, spanIDs)
+}
+
+func TestProcessTailSamplingPayloadMetadata(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.ErrorTPS = 0
+	cfg.DisableRareSampler = true
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Name: "errors", Error: true}}
+	ctx, cancel := context.WithCancel(context.Background())
+	agnt := NewAgent(ctx, cfg)
+	defer cancel()
+
+	newChunk := func(spanID uint64, isError int32, meta map[string]string) *pb.TraceChunk {
+		return &pb.TraceChunk{
+			Priority: int32(sampler.PriorityAutoDrop),
+			Spans: []*pb.Span{{
+				TraceID:  1,
+				SpanID:   spanID,
+				Service:  "web",
+				Name:     "http.request",
+				Resource: "GET /",
+				Start:    time.Now().UnixNano(),
+				Duration: int64(time.Millisecond),
+				Error:    isError,
+				Meta:     meta,
+			}},
+		}
+	}
+	// the payload metadata is only found in the root of the second chunk
+	tp := testutil.TracerPayloadWithChunk(newChunk(1, 1, nil))
+	tp.Chunks = append(tp.Chunks, newChunk(2, 0, map[string]string{"env": "prod", "version": "v1", "_dd.hostname": "tracer-host"}))
+	agnt.Process(&api.Payload{
+		TracerPayload: tp,
+		Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+	})
+	assert.Len(t, agnt.TraceWriter.In, 0)
+
+	agnt.TailSampler.Start()
+	agnt.TailSampler.Stop()
+	require.Len(t, agnt.TraceWriter.In, 1)
+	ss := <-agnt.TraceWriter.In
+	assert.Len(t, ss.TracerPayload.Chunks, 2)
+	assert.Equal(t, "prod", ss.TracerPayload.Env)
+	assert.Equal(t, "v1", ss.TracerPayload.AppVersion)
+	assert.Equal(t, "tracer-host", ss.TracerPayload.Hostname)
+}
+
 func spansToChunk(spans ...*pb.Span) *pb.TraceChunk {
 	return &pb.TraceChunk{Spans: spans}
 }
@@ -1330,6 +1572,7 @@ func TestConvertStats(t *testing.T) {
 								Resource:       "resource",
 								HTTPStatusCode: 400,
 								Type:           "web",
+								Tags:           []string{"region:us"},
 							},
 							{
 								Service:        "service",
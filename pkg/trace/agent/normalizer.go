@@ -209,6 +209,8 @@ func normalizeStatsGroup(b *pb.ClientGroupedStats, lang string) {
 		b.Resource = b.Name
 	}
 	b.Resource, _ = traceutil.TruncateResource(b.Resource)
+	// the additional aggregation dimensions only apply to the stats computed by the agent
+	b.Tags = nil
 }
 
 func isValidStatusCode(sc string) bool {
@@ -7,6 +7,7 @@ package api
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"expvar"
@@ -40,6 +41,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 )
 
@@ -591,6 +593,89 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	}
 }
 
+// handleSpans handles the payloads of the third-party tracing APIs, decoded in to spans by
+// decode. The spans are grouped by trace ID, and the traces are kept as they were already
+// sampled by the client.
+func (r *HTTPReceiver) handleSpans(v Version, w http.ResponseWriter, req *http.Request, decode func(body []byte) ([]*pb.Span, error)) {
+	ts := r.tagStats(v, req.Header)
+	tags := []string{"handler:spans", fmt.Sprintf("v:%s", v)}
+
+	var rd io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gzipr, err := gzip.NewReader(rd)
+		if err != nil {
+			httpDecodingError(err, tags, w)
+			atomic.AddInt64(&ts.TracesDropped.DecodingError, 1)
+			log.Errorf("Cannot decode %s traces payload: %v", v, err)
+			return
+		}
+		defer gzipr.Close()
+		rd = apiutil.NewLimitedReader(gzipr, r.conf.MaxRequestBytes)
+	}
+	body, err := ioutil.ReadAll(rd)
+	if err == nil {
+		var spans []*pb.Span
+		if spans, err = decode(body); err == nil {
+			r.handleSpanChunks(v, w, req, ts, traceChunksFromSpanPointers(spans))
+			return
+		}
+	}
+	httpDecodingError(err, tags, w)
+	if err == apiutil.ErrLimitedReaderLimitReached {
+		atomic.AddInt64(&ts.TracesDropped.PayloadTooLarge, 1)
+	} else {
+		atomic.AddInt64(&ts.TracesDropped.DecodingError, 1)
+	}
+	log.Errorf("Cannot decode %s traces payload: %v", v, err)
+}
+
+// handleSpanChunks sends the decoded chunks of handleSpans.
+func (r *HTTPReceiver) handleSpanChunks(v Version, w http.ResponseWriter, req *http.Request, ts *info.TagStats, chunks []*pb.TraceChunk) {
+	if r.rateLimited(int64(len(chunks))) {
+		// this payload can not be accepted
+		w.WriteHeader(r.rateLimiterResponse)
+		atomic.AddInt64(&ts.PayloadRefused, 1)
+		return
+	}
+	runMetaHook(chunks)
+
+	atomic.AddInt64(&ts.TracesReceived, int64(len(chunks)))
+	atomic.AddInt64(&ts.TracesBytes, req.Body.(*apiutil.LimitedReader).Count)
+	atomic.AddInt64(&ts.PayloadAccepted, 1)
+
+	tp := &pb.TracerPayload{
+		LanguageName:    ts.Lang,
+		LanguageVersion: ts.LangVersion,
+		ContainerID:     req.Header.Get(headerContainerID),
+		Chunks:          chunks,
+		TracerVersion:   ts.TracerVersion,
+	}
+	if ctags := getContainerTags(r.conf.ContainerTags, tp.ContainerID); ctags != "" {
+		tp.Tags = map[string]string{tagContainersTags: ctags}
+	}
+	payload := &Payload{
+		Source:        ts,
+		TracerPayload: tp,
+	}
+	w.WriteHeader(http.StatusAccepted)
+
+	select {
+	case r.out <- payload:
+		// ok
+	default:
+		// channel blocked, add a goroutine to ensure we never drop
+		r.wg.Add(1)
+		go func() {
+			metrics.Count("datadog.trace_agent.receiver.queued_send", 1, nil, 1)
+			defer func() {
+				r.wg.Done()
+				watchdog.LogOnPanic()
+			}()
+			r.out <- payload
+		}()
+	}
+}
+
 // runMetaHook runs the pb.MetaHook on all spans from traces.
 func runMetaHook(chunks []*pb.TraceChunk) {
 	hook, ok := pb.MetaHook()
@@ -805,6 +890,20 @@ func traceChunksFromSpans(spans []pb.Span) []*pb.TraceChunk {
 	return traceChunks
 }
 
+// traceChunksFromSpanPointers groups the spans by 128-bit trace ID, in auto-keep chunks.
+func traceChunksFromSpanPointers(spans []*pb.Span) []*pb.TraceChunk {
+	traceChunks := []*pb.TraceChunk{}
+	for _, t := range traceutil.GroupByTraceID(spans) {
+		traceChunks = append(traceChunks, &pb.TraceChunk{
+			// auto-keep all incoming traces; it was already chosen as a keeper on
+			// the client side.
+			Priority: int32(sampler.PriorityAutoKeep),
+			Spans:    t,
+		})
+	}
+	return traceChunks
+}
+
 func traceChunksFromTraces(traces pb.Traces) []*pb.TraceChunk {
 	traceChunks := make([]*pb.TraceChunk, 0, len(traces))
 	for _, trace := range traces {
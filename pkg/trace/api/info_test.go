@@ -124,6 +124,8 @@ func TestInfoHandler(t *testing.T) {
 		"/v0.4/services",
 		"/v0.5/traces",
 		"/v0.7/traces",
+		"/api/v2/spans",
+		"/api/traces",
 		"/profiling/v1/input",
 		"/telemetry/proxy/",
 		"/v0.6/stats",
@@ -183,6 +185,8 @@ func TestInfoHandler(t *testing.T) {
 		"/v0.4/services",
 		"/v0.5/traces",
 		"/v0.7/traces",
+		"/api/v2/spans",
+		"/api/traces",
 		"/profiling/v1/input",
 		"/telemetry/proxy/",
 		"/v0.6/stats",
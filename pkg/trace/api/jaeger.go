@@ -0,0 +1,525 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// The types of the Jaeger tag values.
+const (
+	jaegerTagString = 0
+	jaegerTagDouble = 1
+	jaegerTagBool   = 2
+	jaegerTagLong   = 3
+	jaegerTagBinary = 4
+)
+
+// jaegerRefChildOf is the type of the references to the parent span.
+const jaegerRefChildOf = 0
+
+// jaegerSpanKinds maps the values of the Jaeger "span.kind" tag to the OpenTelemetry span kinds.
+var jaegerSpanKinds = map[string]ptrace.SpanKind{
+	"client":   ptrace.SpanKindClient,
+	"server":   ptrace.SpanKindServer,
+	"producer": ptrace.SpanKindProducer,
+	"consumer": ptrace.SpanKindConsumer,
+}
+
+// jaegerBatch is the Batch structure of the Jaeger Thrift API, see
+// https://github.com/jaegertracing/jaeger-idl/blob/main/thrift/jaeger.thrift.
+// The times are in microseconds.
+type jaegerBatch struct {
+	serviceName string
+	processTags []jaegerTag
+	spans       []jaegerSpan
+}
+
+type jaegerSpan struct {
+	traceIDLow    int64
+	traceIDHigh   int64
+	spanID        int64
+	parentSpanID  int64
+	operationName string
+	references    []jaegerSpanRef
+	startTime     int64
+	duration      int64
+	tags          []jaegerTag
+	logs          []jaegerLog
+}
+
+type jaegerSpanRef struct {
+	refType int32
+	spanID  int64
+}
+
+type jaegerTag struct {
+	key     string
+	vType   int32
+	vStr    string
+	vDouble float64
+	vBool   bool
+	vLong   int64
+	vBinary []byte
+}
+
+type jaegerLog struct {
+	timestamp int64
+	fields    []jaegerTag
+}
+
+// String returns the value of the tag as a string.
+func (t *jaegerTag) String() string {
+	switch t.vType {
+	case jaegerTagDouble:
+		return strconv.FormatFloat(t.vDouble, 'f', -1, 64)
+	case jaegerTagBool:
+		return strconv.FormatBool(t.vBool)
+	case jaegerTagLong:
+		return strconv.FormatInt(t.vLong, 10)
+	case jaegerTagBinary:
+		return base64.StdEncoding.EncodeToString(t.vBinary)
+	default:
+		return t.vStr
+	}
+}
+
+// handleJaeger handles the Jaeger batches encoded with the Thrift binary protocol, as
+// sent by the Jaeger clients to the /api/traces endpoint of the Jaeger collector.
+func (r *HTTPReceiver) handleJaeger(v Version, w http.ResponseWriter, req *http.Request) {
+	r.handleSpans(v, w, req, decodeJaeger)
+}
+
+// decodeJaeger decodes the Jaeger batch in to Datadog spans.
+func decodeJaeger(body []byte) ([]*pb.Span, error) {
+	batch, err := decodeJaegerBatch(body)
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]*pb.Span, 0, len(batch.spans))
+	for i := range batch.spans {
+		spans = append(spans, convertJaegerSpan(batch, &batch.spans[i]))
+	}
+	return spans, nil
+}
+
+// convertJaegerSpan converts the Jaeger span in to a Datadog span, using the process
+// of the batch to further augment it.
+func convertJaegerSpan(batch *jaegerBatch, in *jaegerSpan) *pb.Span {
+	span := &pb.Span{
+		TraceID:  uint64(in.traceIDLow),
+		SpanID:   uint64(in.spanID),
+		ParentID: uint64(in.parentSpanID),
+		Start:    in.startTime * 1000,
+		Duration: in.duration * 1000,
+		Service:  batch.serviceName,
+		Resource: in.operationName,
+		Meta:     make(map[string]string, len(batch.processTags)+len(in.tags)),
+		Metrics:  map[string]float64{},
+	}
+	if span.ParentID == 0 {
+		for _, ref := range in.references {
+			if ref.refType == jaegerRefChildOf {
+				span.ParentID = uint64(ref.spanID)
+				break
+			}
+		}
+	}
+
+	var isError bool
+	for _, tags := range [][]jaegerTag{batch.processTags, in.tags} {
+		for i := range tags {
+			tag := &tags[i]
+			switch {
+			case tag.key == "error":
+				isError = tag.String() == "true"
+			case tag.vType == jaegerTagDouble:
+				span.Metrics[tag.key] = tag.vDouble
+			case tag.vType == jaegerTagLong:
+				span.Metrics[tag.key] = float64(tag.vLong)
+			default:
+				span.Meta[tag.key] = tag.String()
+			}
+		}
+	}
+	if _, ok := span.Meta["env"]; !ok {
+		if env := span.Meta["deployment.environment"]; env != "" {
+			span.Meta["env"] = env
+		}
+	}
+	traceutil.SetTraceIDHigh(span, uint64(in.traceIDHigh))
+	if len(in.logs) > 0 {
+		span.Meta["events"] = marshalJaegerLogs(in.logs)
+	}
+	if isError {
+		span.Error = 1
+		jaegerLogs2Error(in.logs, span)
+	}
+
+	kind, ok := jaegerSpanKinds[span.Meta["span.kind"]]
+	if !ok {
+		kind = ptrace.SpanKindInternal
+	}
+	span.Name = "jaeger." + spanKindName(kind)
+	if span.Resource == "" {
+		span.Resource = span.Name
+	}
+	if r := resourceFromTags(span.Meta); r != "" {
+		span.Resource = r
+	}
+	span.Type = spanKind2Type(kind, span)
+	return span
+}
+
+// jaegerLogs2Error applies the error details found in the "error" event of the logs to the span.
+func jaegerLogs2Error(logs []jaegerLog, span *pb.Span) {
+	for _, l := range logs {
+		fields := make(map[string]string, len(l.fields))
+		for i := range l.fields {
+			fields[l.fields[i].key] = l.fields[i].String()
+		}
+		if fields["event"] != "error" {
+			continue
+		}
+		if msg := fields["message"]; msg != "" {
+			span.Meta["error.msg"] = msg
+		}
+		if typ := fields["error.kind"]; typ != "" {
+			span.Meta["error.type"] = typ
+		}
+		if stack := fields["stack"]; stack != "" {
+			span.Meta["error.stack"] = stack
+		}
+	}
+}
+
+// marshalJaegerLogs marshals the logs into JSON, in the format used by the OpenTelemetry span events.
+func marshalJaegerLogs(logs []jaegerLog) string {
+	type event struct {
+		Time       uint64            `json:"time_unix_nano"`
+		Name       string            `json:"name,omitempty"`
+		Attributes map[string]string `json:"attributes,omitempty"`
+	}
+	events := make([]event, 0, len(logs))
+	for _, l := range logs {
+		e := event{Time: uint64(l.timestamp) * 1000}
+		for i := range l.fields {
+			f := &l.fields[i]
+			if f.key == "event" {
+				e.Name = f.String()
+				continue
+			}
+			if e.Attributes == nil {
+				e.Attributes = make(map[string]string, len(l.fields))
+			}
+			e.Attributes[f.key] = f.String()
+		}
+		events = append(events, e)
+	}
+	out, _ := json.Marshal(events)
+	return string(out)
+}
+
+// decodeJaegerBatch decodes a Batch encoded with the Thrift binary protocol.
+func decodeJaegerBatch(b []byte) (*jaegerBatch, error) {
+	r := &thriftReader{b: b}
+	batch := &jaegerBatch{}
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == thriftStruct:
+			r.readStruct(func(id int16, typ byte) {
+				switch {
+				case id == 1 && typ == thriftString:
+					batch.serviceName = string(r.readBinary())
+				case id == 2 && typ == thriftList:
+					batch.processTags = r.readJaegerTags()
+				default:
+					r.skip(typ)
+				}
+			})
+		case id == 2 && typ == thriftList:
+			r.readList(func(typ byte) {
+				if typ != thriftStruct {
+					r.skip(typ)
+					return
+				}
+				var span jaegerSpan
+				r.readJaegerSpan(&span)
+				batch.spans = append(batch.spans, span)
+			})
+		default:
+			r.skip(typ)
+		}
+	})
+	if r.err != nil {
+		return nil, r.err
+	}
+	return batch, nil
+}
+
+func (r *thriftReader) readJaegerSpan(span *jaegerSpan) {
+	r.readStruct(func(id int16, typ byte) {
+		switch {
+		case id == 1 && typ == thriftI64:
+			span.traceIDLow = r.readI64()
+		case id == 2 && typ == thriftI64:
+			span.traceIDHigh = r.readI64()
+		case id == 3 && typ == thriftI64:
+			span.spanID = r.readI64()
+		case id == 4 && typ == thriftI64:
+			span.parentSpanID = r.readI64()
+		case id == 5 && typ == thriftString:
+			span.operationName = string(r.readBinary())
+		case id == 6 && typ == thriftList:
+			r.readList(func(typ byte) {
+				if typ != thriftStruct {
+					r.skip(typ)
+					return
+				}
+				var ref jaegerSpanRef
+				r.readStruct(func(id int16, typ byte) {
+					switch {
+					case id == 1 && typ == thriftI32:
+						ref.refType = r.readI32()
+					case id == 4 && typ == thriftI64:
+						ref.spanID = r.readI64()
+					default:
+						r.skip(typ)
+					}
+				})
+				span.references = append(span.references, ref)
+			})
+		case id == 8 && typ == thriftI64:
+			span.startTime = r.readI64()
+		case id == 9 && typ == thriftI64:
+			span.duration = r.readI64()
+		case id == 10 && typ == thriftList:
+			span.tags = r.readJaegerTags()
+		case id == 11 && typ == thriftList:
+			r.readList(func(typ byte) {
+				if typ != thriftStruct {
+					r.skip(typ)
+					return
+				}
+				var l jaegerLog
+				r.readStruct(func(id int16, typ byte) {
+					switch {
+					case id == 1 && typ == thriftI64:
+						l.timestamp = r.readI64()
+					case id == 2 && typ == thriftList:
+						l.fields = r.readJaegerTags()
+					default:
+						r.skip(typ)
+					}
+				})
+				span.logs = append(span.logs, l)
+			})
+		default:
+			r.skip(typ)
+		}
+	})
+}
+
+func (r *thriftReader) readJaegerTags() []jaegerTag {
+	var tags []jaegerTag
+	r.readList(func(typ byte) {
+		if typ != thriftStruct {
+			r.skip(typ)
+			return
+		}
+		var tag jaegerTag
+		r.readStruct(func(id int16, typ byte) {
+			switch {
+			case id == 1 && typ == thriftString:
+				tag.key = string(r.readBinary())
+			case id == 2 && typ == thriftI32:
+				tag.vType = r.readI32()
+			case id == 3 && typ == thriftString:
+				tag.vStr = string(r.readBinary())
+			case id == 4 && typ == thriftDouble:
+				tag.vDouble = r.readDouble()
+			case id == 5 && typ == thriftBool:
+				tag.vBool = r.readByte() != 0
+			case id == 6 && typ == thriftI64:
+				tag.vLong = r.readI64()
+			case id == 7 && typ == thriftString:
+				tag.vBinary = r.readBinary()
+			default:
+				r.skip(typ)
+			}
+		})
+		tags = append(tags, tag)
+	})
+	return tags
+}
+
+// The Thrift types.
+const (
+	thriftStop   = 0
+	thriftBool   = 2
+	thriftByte   = 3
+	thriftDouble = 4
+	thriftI16    = 6
+	thriftI32    = 8
+	thriftI64    = 10
+	thriftString = 11
+	thriftStruct = 12
+	thriftMap    = 13
+	thriftSet    = 14
+	thriftList   = 15
+)
+
+// thriftMaxDepth limits the nesting of the skipped values.
+const thriftMaxDepth = 64
+
+var errThriftShortBuffer = errors.New("thrift: unexpected end of buffer")
+
+// thriftReader reads values encoded with the Thrift binary protocol. The first error
+// is kept in err and the next reads return zero values.
+type thriftReader struct {
+	b     []byte
+	err   error
+	depth int
+}
+
+func (r *thriftReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.b) {
+		r.err = errThriftShortBuffer
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) readByte() byte {
+	if v := r.next(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (r *thriftReader) readI16() int16 {
+	if v := r.next(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (r *thriftReader) readI32() int32 {
+	if v := r.next(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (r *thriftReader) readI64() int64 {
+	if v := r.next(8); v != nil {
+		return int64(binary.BigEndian.Uint64(v))
+	}
+	return 0
+}
+
+func (r *thriftReader) readDouble() float64 {
+	return math.Float64frombits(uint64(r.readI64()))
+}
+
+func (r *thriftReader) readBinary() []byte {
+	return r.next(int(r.readI32()))
+}
+
+// readStruct calls fn with the ID and the type of each field of a struct. fn must read
+// or skip the value of the field.
+func (r *thriftReader) readStruct(fn func(id int16, typ byte)) {
+	r.enter()
+	defer r.leave()
+	for r.err == nil {
+		typ := r.readByte()
+		if typ == thriftStop || r.err != nil {
+			return
+		}
+		fn(r.readI16(), typ)
+	}
+}
+
+// readList calls fn with the type of the elements for each element of a list or a set.
+// fn must read or skip the element.
+func (r *thriftReader) readList(fn func(typ byte)) {
+	typ := r.readByte()
+	size := int(r.readI32())
+	if r.err == nil && (size < 0 || size > len(r.b)) {
+		// each element is encoded on at least one byte
+		r.err = fmt.Errorf("thrift: invalid list size %d", size)
+	}
+	for i := 0; i < size && r.err == nil; i++ {
+		fn(typ)
+	}
+}
+
+// skip skips a value of the given type.
+func (r *thriftReader) skip(typ byte) {
+	switch typ {
+	case thriftBool, thriftByte:
+		r.next(1)
+	case thriftI16:
+		r.next(2)
+	case thriftI32:
+		r.next(4)
+	case thriftDouble, thriftI64:
+		r.next(8)
+	case thriftString:
+		r.readBinary()
+	case thriftStruct:
+		r.readStruct(func(_ int16, typ byte) { r.skip(typ) })
+	case thriftList, thriftSet:
+		r.enter()
+		defer r.leave()
+		r.readList(r.skip)
+	case thriftMap:
+		r.enter()
+		defer r.leave()
+		ktyp, vtyp := r.readByte(), r.readByte()
+		size := int(r.readI32())
+		if r.err == nil && (size < 0 || size > len(r.b)) {
+			r.err = fmt.Errorf("thrift: invalid map size %d", size)
+		}
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(ktyp)
+			r.skip(vtyp)
+		}
+	default:
+		if r.err == nil {
+			r.err = fmt.Errorf("thrift: unknown type %d", typ)
+		}
+	}
+}
+
+// enter increases the nesting depth, failing the reader when it exceeds thriftMaxDepth.
+func (r *thriftReader) enter() {
+	r.depth++
+	if r.depth > thriftMaxDepth && r.err == nil {
+		r.err = errors.New("thrift: maximum depth exceeded")
+	}
+}
+
+func (r *thriftReader) leave() {
+	r.depth--
+}
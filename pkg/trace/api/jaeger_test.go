@@ -0,0 +1,223 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// thriftWriter encodes values with the Thrift binary protocol.
+type thriftWriter struct {
+	bytes.Buffer
+}
+
+func (w *thriftWriter) field(typ byte, id int16) {
+	w.WriteByte(typ)
+	binary.Write(w, binary.BigEndian, id)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(thriftI32, id)
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(thriftI64, id)
+	binary.Write(w, binary.BigEndian, v)
+}
+
+func (w *thriftWriter) double(id int16, v float64) {
+	w.field(thriftDouble, id)
+	binary.Write(w, binary.BigEndian, math.Float64bits(v))
+}
+
+func (w *thriftWriter) bool(id int16, v bool) {
+	w.field(thriftBool, id)
+	if v {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.field(thriftString, id)
+	binary.Write(w, binary.BigEndian, int32(len(v)))
+	w.WriteString(v)
+}
+
+func (w *thriftWriter) structField(id int16, fn func()) {
+	w.field(thriftStruct, id)
+	fn()
+	w.WriteByte(thriftStop)
+}
+
+func (w *thriftWriter) structList(id int16, n int, fn func(i int)) {
+	w.field(thriftList, id)
+	w.WriteByte(thriftStruct)
+	binary.Write(w, binary.BigEndian, int32(n))
+	for i := 0; i < n; i++ {
+		fn(i)
+		w.WriteByte(thriftStop)
+	}
+}
+
+func (w *thriftWriter) tags(id int16, tags []jaegerTag) {
+	w.structList(id, len(tags), func(i int) {
+		t := tags[i]
+		w.string(1, t.key)
+		w.i32(2, t.vType)
+		switch t.vType {
+		case jaegerTagString:
+			w.string(3, t.vStr)
+		case jaegerTagDouble:
+			w.double(4, t.vDouble)
+		case jaegerTagBool:
+			w.bool(5, t.vBool)
+		case jaegerTagLong:
+			w.i64(6, t.vLong)
+		}
+	})
+}
+
+func testJaegerBatch() []byte {
+	var w thriftWriter
+	w.structField(1, func() {
+		w.string(1, "frontend")
+		w.tags(2, []jaegerTag{
+			{key: "hostname", vType: jaegerTagString, vStr: "host-1"},
+			{key: "deployment.environment", vType: jaegerTagString, vStr: "prod"},
+		})
+	})
+	w.structList(2, 2, func(i int) {
+		if i == 0 {
+			w.i64(1, 1)
+			w.i64(2, 0x5af7183fb1d4cf5f)
+			w.i64(3, 2)
+			w.i64(4, 3)
+			w.string(5, "GET /users")
+			w.i32(7, 1)
+			w.i64(8, 1500000000000000)
+			w.i64(9, 1500)
+			w.tags(10, []jaegerTag{
+				{key: "span.kind", vType: jaegerTagString, vStr: "server"},
+				{key: "http.status_code", vType: jaegerTagLong, vLong: 500},
+				{key: "sampler.param", vType: jaegerTagDouble, vDouble: 0.5},
+				{key: "error", vType: jaegerTagBool, vBool: true},
+			})
+			w.structList(11, 1, func(int) {
+				w.i64(1, 1500000000000100)
+				w.tags(2, []jaegerTag{
+					{key: "event", vType: jaegerTagString, vStr: "error"},
+					{key: "message", vType: jaegerTagString, vStr: "boom"},
+					{key: "error.kind", vType: jaegerTagString, vStr: "IOError"},
+				})
+			})
+			// unknown fields are skipped
+			w.structField(20, func() { w.i32(1, 1) })
+			return
+		}
+		w.i64(1, 4)
+		w.i64(3, 5)
+		w.i64(4, 0)
+		w.string(5, "query")
+		w.structList(6, 1, func(int) {
+			w.i32(1, jaegerRefChildOf)
+			w.i64(2, 4)
+			w.i64(4, 6)
+		})
+		w.tags(10, []jaegerTag{
+			{key: "span.kind", vType: jaegerTagString, vStr: "client"},
+			{key: "db.system", vType: jaegerTagString, vStr: "postgresql"},
+		})
+	})
+	w.WriteByte(thriftStop)
+	return w.Bytes()
+}
+
+func TestDecodeJaeger(t *testing.T) {
+	assert := assert.New(t)
+	spans, err := decodeJaeger(testJaegerBatch())
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+
+	assert.Equal(&pb.Span{
+		Service:  "frontend",
+		Name:     "jaeger.server",
+		Resource: "GET /users",
+		TraceID:  1,
+		SpanID:   2,
+		ParentID: 3,
+		Start:    1500000000000000000,
+		Duration: 1500000,
+		Error:    1,
+		Meta: map[string]string{
+			"hostname":               "host-1",
+			"_dd.p.tid":              "5af7183fb1d4cf5f",
+			"deployment.environment": "prod",
+			"env":                    "prod",
+			"span.kind":              "server",
+			"error.msg":              "boom",
+			"error.type":             "IOError",
+			"events":                 `[{"time_unix_nano":1500000000000100000,"name":"error","attributes":{"error.kind":"IOError","message":"boom"}}]`,
+		},
+		Metrics: map[string]float64{
+			"http.status_code": 500,
+			"sampler.param":    0.5,
+		},
+		Type: "web",
+	}, spans[0])
+
+	assert.Equal("jaeger.client", spans[1].Name)
+	assert.Equal("query", spans[1].Resource)
+	assert.Equal(uint64(4), spans[1].TraceID)
+	assert.Equal(uint64(6), spans[1].ParentID)
+	assert.Equal("db", spans[1].Type)
+}
+
+func TestDecodeJaegerInvalid(t *testing.T) {
+	batch := testJaegerBatch()
+	for name, payload := range map[string][]byte{
+		"truncated":    batch[:len(batch)-10],
+		"unknown type": {1, 0, 1},
+		"list size":    {thriftList, 0, 2, thriftStruct, 0x7f, 0xff, 0xff, 0xff},
+		"depth":        bytes.Repeat([]byte{thriftStruct, 0, 1}, thriftMaxDepth+1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeJaeger(payload)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHandleJaeger(t *testing.T) {
+	assert := assert.New(t)
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(r.handleWithVersion(jaegerThrift, r.handleJaeger))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/x-thrift", bytes.NewReader(testJaegerBatch()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(http.StatusAccepted, resp.StatusCode)
+
+	p := <-r.out
+	assert.Len(p.TracerPayload.Chunks, 2)
+	for _, c := range p.TracerPayload.Chunks {
+		assert.Equal(int32(sampler.PriorityAutoKeep), c.Priority)
+	}
+}
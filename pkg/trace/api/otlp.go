@@ -27,6 +27,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 
 	semconv "go.opentelemetry.io/collector/model/semconv/v1.6.1"
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -226,13 +227,13 @@ func (o *OTLPReceiver) processRequest(protocol string, header http.Header, in pt
 			},
 			Stats: info.NewStats(),
 		}
-		tracesByID := make(map[uint64]pb.Trace)
+		tracesByID := make(map[traceutil.TraceID]pb.Trace)
 		for i := 0; i < rspans.ScopeSpans().Len(); i++ {
 			libspans := rspans.ScopeSpans().At(i)
 			lib := libspans.Scope()
 			for i := 0; i < libspans.Spans().Len(); i++ {
 				span := libspans.Spans().At(i)
-				traceID := traceIDToUint64(span.TraceID().Bytes())
+				traceID := traceIDFromBytes(span.TraceID().Bytes())
 				if tracesByID[traceID] == nil {
 					tracesByID[traceID] = pb.Trace{}
 				}
@@ -339,13 +340,14 @@ func convertSpan(rattr map[string]string, lib pcommon.InstrumentationScope, in p
 		name = "opentelemetry." + name
 	}
 	traceID := in.TraceID().Bytes()
+	fullID := traceIDFromBytes(traceID)
 	meta := make(map[string]string, len(rattr))
 	for k, v := range rattr {
 		meta[k] = v
 	}
 	span := &pb.Span{
 		Name:     name,
-		TraceID:  traceIDToUint64(traceID),
+		TraceID:  fullID.Low,
 		SpanID:   spanIDToUint64(in.SpanID().Bytes()),
 		ParentID: spanIDToUint64(in.ParentSpanID().Bytes()),
 		Start:    int64(in.StartTimestamp()),
@@ -356,6 +358,7 @@ func convertSpan(rattr map[string]string, lib pcommon.InstrumentationScope, in p
 		Metrics:  map[string]float64{},
 	}
 	span.Meta["otel.trace_id"] = hex.EncodeToString(traceID[:])
+	traceutil.SetTraceIDHigh(span, fullID.High)
 	if _, ok := span.Meta["version"]; !ok {
 		if ver := rattr[string(semconv.AttributeServiceVersion)]; ver != "" {
 			span.Meta["version"] = ver
@@ -475,8 +478,11 @@ func spanKind2Type(kind ptrace.SpanKind, span *pb.Span) string {
 	return typ
 }
 
-func traceIDToUint64(b [16]byte) uint64 {
-	return binary.BigEndian.Uint64(b[len(b)-8:])
+func traceIDFromBytes(b [16]byte) traceutil.TraceID {
+	return traceutil.TraceID{
+		High: binary.BigEndian.Uint64(b[:8]),
+		Low:  binary.BigEndian.Uint64(b[8:]),
+	}
 }
 
 func spanIDToUint64(b [8]byte) uint64 {
@@ -18,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 
 	"github.com/stretchr/testify/assert"
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -174,7 +175,7 @@ var (
 
 func TestOTLPHelpers(t *testing.T) {
 	t.Run("byteArrayToUint64", func(t *testing.T) {
-		assert.Equal(t, uint64(0x240031ead750e5f3), traceIDToUint64(otlpTestTraceID.Bytes()))
+		assert.Equal(t, traceutil.TraceID{High: 0x72df520af2bde7a5, Low: 0x240031ead750e5f3}, traceIDFromBytes(otlpTestTraceID.Bytes()))
 		assert.Equal(t, uint64(0x240031ead750e5f3), spanIDToUint64(otlpTestSpanID.Bytes()))
 	})
 
@@ -412,6 +413,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 				Meta: map[string]string{
 					"name":                            "john",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 					"env":                             "staging",
 					"instrumentation_library.name":    "ddtracer",
 					"instrumentation_library.version": "v2",
@@ -493,6 +495,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"deployment.environment":          "prod",
 					"instrumentation_library.name":    "ddtracer",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 					"instrumentation_library.version": "v2",
 					"service.version":                 "v1.2.3",
 					"trace_state":                     "state",
@@ -578,6 +581,7 @@ func TestOTLPConvertSpan(t *testing.T) {
 					"trace_state":                     "state",
 					"version":                         "v1.2.3",
 					"otel.trace_id":                   "72df520af2bde7a5240031ead750e5f3",
+					"_dd.p.tid":                       "72df520af2bde7a5",
 					"events":                          "[{\"time_unix_nano\":123,\"name\":\"boom\",\"attributes\":{\"message\":\"Out of memory\",\"accuracy\":\"2.4\"},\"dropped_attributes_count\":2},{\"time_unix_nano\":456,\"name\":\"exception\",\"attributes\":{\"exception.message\":\"Out of memory\",\"exception.type\":\"mem\",\"exception.stacktrace\":\"1/2/3\"},\"dropped_attributes_count\":2}]",
 					"error.msg":                       "Out of memory",
 					"error.type":                      "mem",
@@ -81,4 +81,21 @@ const (
 	// Response: Service sampling rates.
 	//
 	V07 Version = "v0.7"
+
+	// Zipkin v2 API
+	//
+	// Content-Type: application/json or application/x-protobuf
+	// Payload: List of Zipkin spans (https://zipkin.io/zipkin-api/#/default/post_spans)
+	// Response: Status 202/Accepted.
+	//
+	zipkinV2 Version = "zipkin_v2"
+
+	// Jaeger Thrift API
+	//
+	// Content-Type: application/x-thrift
+	// Payload: Jaeger Batch encoded with the Thrift binary protocol
+	// (https://github.com/jaegertracing/jaeger-idl/blob/main/thrift/jaeger.thrift)
+	// Response: Status 202/Accepted.
+	//
+	jaegerThrift Version = "jaeger_thrift"
 )
@@ -0,0 +1,316 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// zipkinSpan is a span of the Zipkin v2 API, see https://zipkin.io/zipkin-api/#/default/post_spans.
+// The IDs are hex encoded and the times are in microseconds.
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ID             string             `json:"id"`
+	ParentID       string             `json:"parentId"`
+	Name           string             `json:"name"`
+	Kind           string             `json:"kind"`
+	Timestamp      uint64             `json:"timestamp"`
+	Duration       uint64             `json:"duration"`
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// zipkinSpanKinds maps the Zipkin span kinds to the OpenTelemetry ones. The spans
+// without kind are internal spans.
+var zipkinSpanKinds = map[string]ptrace.SpanKind{
+	"CLIENT":   ptrace.SpanKindClient,
+	"SERVER":   ptrace.SpanKindServer,
+	"PRODUCER": ptrace.SpanKindProducer,
+	"CONSUMER": ptrace.SpanKindConsumer,
+}
+
+// zipkinProtoSpanKinds holds the names of the Kind enum of the Zipkin proto3 API.
+var zipkinProtoSpanKinds = []string{"", "CLIENT", "SERVER", "PRODUCER", "CONSUMER"}
+
+// handleZipkin handles the Zipkin v2 spans, encoded in JSON or with proto3.
+func (r *HTTPReceiver) handleZipkin(v Version, w http.ResponseWriter, req *http.Request) {
+	r.handleSpans(v, w, req, func(body []byte) ([]*pb.Span, error) {
+		return decodeZipkin(getMediaType(req), body)
+	})
+}
+
+// decodeZipkin decodes the Zipkin v2 spans of the given media type in to Datadog spans.
+func decodeZipkin(mediaType string, body []byte) ([]*pb.Span, error) {
+	var spans []zipkinSpan
+	var err error
+	if mediaType == "application/x-protobuf" {
+		spans, err = decodeZipkinProto(body)
+	} else {
+		err = json.Unmarshal(body, &spans)
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*pb.Span, 0, len(spans))
+	for i := range spans {
+		span, err := convertZipkinSpan(&spans[i])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, span)
+	}
+	return out, nil
+}
+
+// convertZipkinSpan converts the Zipkin span in to a Datadog span.
+func convertZipkinSpan(in *zipkinSpan) (*pb.Span, error) {
+	traceIDHigh, traceID, err := parseZipkinID(in.TraceID, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID %q: %v", in.TraceID, err)
+	}
+	_, spanID, err := parseZipkinID(in.ID, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span ID %q: %v", in.ID, err)
+	}
+	var parentID uint64
+	if in.ParentID != "" {
+		if _, parentID, err = parseZipkinID(in.ParentID, 16); err != nil {
+			return nil, fmt.Errorf("invalid parent ID %q: %v", in.ParentID, err)
+		}
+	}
+
+	kind, ok := zipkinSpanKinds[in.Kind]
+	if !ok {
+		kind = ptrace.SpanKindInternal
+	}
+	span := &pb.Span{
+		Name:     "zipkin." + spanKindName(kind),
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Start:    int64(in.Timestamp) * 1000,
+		Duration: int64(in.Duration) * 1000,
+		Resource: in.Name,
+		Meta:     make(map[string]string, len(in.Tags)),
+		Metrics:  map[string]float64{},
+	}
+	if in.LocalEndpoint != nil {
+		span.Service = in.LocalEndpoint.ServiceName
+	}
+	for k, v := range in.Tags {
+		span.Meta[k] = v
+	}
+	traceutil.SetTraceIDHigh(span, traceIDHigh)
+	if _, ok := span.Meta["peer.service"]; !ok && in.RemoteEndpoint != nil && in.RemoteEndpoint.ServiceName != "" {
+		span.Meta["peer.service"] = in.RemoteEndpoint.ServiceName
+	}
+	if _, ok := span.Meta["env"]; !ok {
+		if env := span.Meta["deployment.environment"]; env != "" {
+			span.Meta["env"] = env
+		}
+	}
+	if len(in.Annotations) > 0 {
+		span.Meta["events"] = marshalZipkinAnnotations(in.Annotations)
+	}
+	if msg, ok := in.Tags["error"]; ok {
+		// Zipkin marks the errors with an "error" tag holding the message
+		span.Error = 1
+		delete(span.Meta, "error")
+		if msg != "" && msg != "true" {
+			span.Meta["error.msg"] = msg
+		}
+	}
+	if span.Resource == "" {
+		span.Resource = span.Name
+	}
+	if r := resourceFromTags(span.Meta); r != "" {
+		span.Resource = r
+	}
+	span.Type = spanKind2Type(kind, span)
+	return span, nil
+}
+
+// parseZipkinID parses the hex encoded ID of at most maxLen characters, returning the
+// high and the low 64 bits of the 128-bit IDs.
+func parseZipkinID(id string, maxLen int) (high, low uint64, err error) {
+	if id == "" || len(id) > maxLen {
+		return 0, 0, errors.New("invalid length")
+	}
+	if len(id) > 16 {
+		if high, err = strconv.ParseUint(id[:len(id)-16], 16, 64); err != nil {
+			return 0, 0, err
+		}
+		id = id[len(id)-16:]
+	}
+	low, err = strconv.ParseUint(id, 16, 64)
+	return high, low, err
+}
+
+// marshalZipkinAnnotations marshals the annotations into JSON, in the format used by
+// the OpenTelemetry span events.
+func marshalZipkinAnnotations(annotations []zipkinAnnotation) string {
+	type event struct {
+		Time uint64 `json:"time_unix_nano"`
+		Name string `json:"name"`
+	}
+	events := make([]event, 0, len(annotations))
+	for _, a := range annotations {
+		events = append(events, event{Time: a.Timestamp * 1000, Name: a.Value})
+	}
+	out, _ := json.Marshal(events)
+	return string(out)
+}
+
+// decodeZipkinProto decodes a ListOfSpans message of the Zipkin proto3 API, see
+// https://github.com/openzipkin/zipkin-api/blob/master/zipkin.proto.
+func decodeZipkinProto(b []byte) ([]zipkinSpan, error) {
+	var spans []zipkinSpan
+	err := decodeProtoFields(b, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		var span zipkinSpan
+		if err := decodeZipkinProtoSpan(v, &span); err != nil {
+			return err
+		}
+		spans = append(spans, span)
+		return nil
+	})
+	return spans, err
+}
+
+func decodeZipkinProtoSpan(b []byte, span *zipkinSpan) error {
+	return decodeProtoFields(b, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			span.TraceID = hex.EncodeToString(v)
+		case 2:
+			span.ParentID = hex.EncodeToString(v)
+		case 3:
+			span.ID = hex.EncodeToString(v)
+		case 4:
+			if n < uint64(len(zipkinProtoSpanKinds)) {
+				span.Kind = zipkinProtoSpanKinds[n]
+			}
+		case 5:
+			span.Name = string(v)
+		case 6:
+			span.Timestamp = n
+		case 7:
+			span.Duration = n
+		case 8, 9:
+			endpoint := &zipkinEndpoint{}
+			err := decodeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+				if num == 1 {
+					endpoint.ServiceName = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if num == 8 {
+				span.LocalEndpoint = endpoint
+			} else {
+				span.RemoteEndpoint = endpoint
+			}
+		case 10:
+			var a zipkinAnnotation
+			err := decodeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, n uint64) error {
+				switch num {
+				case 1:
+					a.Timestamp = n
+				case 2:
+					a.Value = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			span.Annotations = append(span.Annotations, a)
+		case 11:
+			var key, value string
+			err := decodeProtoFields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) error {
+				switch num {
+				case 1:
+					key = string(v)
+				case 2:
+					value = string(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if span.Tags == nil {
+				span.Tags = make(map[string]string)
+			}
+			span.Tags[key] = value
+		}
+		return nil
+	})
+}
+
+// decodeProtoFields calls fn for each field of the protobuf message b, with the value
+// of the length-delimited fields in v and the value of the numeric fields in n.
+func decodeProtoFields(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		var v []byte
+		var n uint64
+		switch typ {
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(b)
+			n = uint64(n32)
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return protowire.ParseError(l)
+		}
+		b = b[l:]
+
+		if err := fn(num, typ, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
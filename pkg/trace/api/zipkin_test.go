@@ -0,0 +1,182 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+const zipkinJSONPayload = `[{
+	"traceId": "5af7183fb1d4cf5f0000000000000001",
+	"id": "0000000000000002",
+	"parentId": "0000000000000003",
+	"name": "get /users",
+	"kind": "SERVER",
+	"timestamp": 1500000000000000,
+	"duration": 1500,
+	"localEndpoint": {"serviceName": "frontend"},
+	"remoteEndpoint": {"serviceName": "backend"},
+	"annotations": [{"timestamp": 1500000000000100, "value": "ws"}],
+	"tags": {"http.method": "GET", "http.route": "/users", "error": "boom", "deployment.environment": "prod"}
+}, {
+	"traceId": "0000000000000004",
+	"id": "0000000000000005",
+	"name": "query",
+	"kind": "CLIENT",
+	"timestamp": 1500000000000000,
+	"duration": 10,
+	"localEndpoint": {"serviceName": "backend"},
+	"tags": {"db.system": "postgresql"}
+}]`
+
+func TestConvertZipkinSpan(t *testing.T) {
+	assert := assert.New(t)
+	spans, err := decodeZipkin("application/json", []byte(zipkinJSONPayload))
+	require.NoError(t, err)
+	require.Len(t, spans, 2)
+
+	assert.Equal(&pb.Span{
+		Service:  "frontend",
+		Name:     "zipkin.server",
+		Resource: "GET /users",
+		TraceID:  1,
+		SpanID:   2,
+		ParentID: 3,
+		Start:    1500000000000000000,
+		Duration: 1500000,
+		Error:    1,
+		Meta: map[string]string{
+			"http.method":            "GET",
+			"http.route":             "/users",
+			"_dd.p.tid":              "5af7183fb1d4cf5f",
+			"deployment.environment": "prod",
+			"env":                    "prod",
+			"peer.service":           "backend",
+			"error.msg":              "boom",
+			"events":                 `[{"time_unix_nano":1500000000000100000,"name":"ws"}]`,
+		},
+		Metrics: map[string]float64{},
+		Type:    "web",
+	}, spans[0])
+
+	assert.Equal("zipkin.client", spans[1].Name)
+	assert.Equal("query", spans[1].Resource)
+	assert.Equal(uint64(4), spans[1].TraceID)
+	assert.Equal(uint64(0), spans[1].ParentID)
+	assert.Equal("db", spans[1].Type)
+}
+
+func TestConvertZipkinSpanInvalidID(t *testing.T) {
+	for _, payload := range []string{
+		`[{"traceId": "", "id": "1"}]`,
+		`[{"traceId": "1", "id": "00000000000000001"}]`,
+		`[{"traceId": "xyz", "id": "1"}]`,
+		`[{"traceId": "1", "id": "1", "parentId": "x"}]`,
+	} {
+		_, err := decodeZipkin("application/json", []byte(payload))
+		assert.Error(t, err, payload)
+	}
+}
+
+func TestDecodeZipkinProto(t *testing.T) {
+	var span []byte
+	span = protowire.AppendTag(span, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0x5a, 0xf7, 0x18, 0x3f, 0xb1, 0xd4, 0xcf, 0x5f, 0, 0, 0, 0, 0, 0, 0, 1})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 2})
+	span = protowire.AppendTag(span, 4, protowire.VarintType)
+	span = protowire.AppendVarint(span, 4) // CONSUMER
+	span = protowire.AppendTag(span, 5, protowire.BytesType)
+	span = protowire.AppendString(span, "process")
+	span = protowire.AppendTag(span, 6, protowire.VarintType)
+	span = protowire.AppendVarint(span, 1500000000000000)
+	span = protowire.AppendTag(span, 7, protowire.VarintType)
+	span = protowire.AppendVarint(span, 20)
+	var endpoint []byte
+	endpoint = protowire.AppendTag(endpoint, 1, protowire.BytesType)
+	endpoint = protowire.AppendString(endpoint, "worker")
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, endpoint)
+	var tag []byte
+	tag = protowire.AppendTag(tag, 1, protowire.BytesType)
+	tag = protowire.AppendString(tag, "messaging.system")
+	tag = protowire.AppendTag(tag, 2, protowire.BytesType)
+	tag = protowire.AppendString(tag, "kafka")
+	span = protowire.AppendTag(span, 11, protowire.BytesType)
+	span = protowire.AppendBytes(span, tag)
+	// unknown fields are skipped
+	span = protowire.AppendTag(span, 12, protowire.VarintType)
+	span = protowire.AppendVarint(span, 1)
+
+	var list []byte
+	list = protowire.AppendTag(list, 1, protowire.BytesType)
+	list = protowire.AppendBytes(list, span)
+
+	spans, err := decodeZipkin("application/x-protobuf", list)
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+	assert.Equal(t, &pb.Span{
+		Service:  "worker",
+		Name:     "zipkin.consumer",
+		Resource: "process",
+		TraceID:  1,
+		SpanID:   2,
+		Start:    1500000000000000000,
+		Duration: 20000,
+		Meta:     map[string]string{"messaging.system": "kafka", "_dd.p.tid": "5af7183fb1d4cf5f"},
+		Metrics:  map[string]float64{},
+		Type:     "custom",
+	}, spans[0])
+
+	_, err = decodeZipkin("application/x-protobuf", list[:len(list)-3])
+	assert.Error(t, err)
+}
+
+func TestHandleZipkin(t *testing.T) {
+	assert := assert.New(t)
+	r := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(r.handleWithVersion(zipkinV2, r.handleZipkin))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	gzipw := gzip.NewWriter(&buf)
+	gzipw.Write([]byte(zipkinJSONPayload))
+	gzipw.Close()
+	req, err := http.NewRequest("POST", server.URL, &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(http.StatusAccepted, resp.StatusCode)
+
+	p := <-r.out
+	assert.Len(p.TracerPayload.Chunks, 2)
+	for _, c := range p.TracerPayload.Chunks {
+		assert.Equal(int32(sampler.PriorityAutoKeep), c.Priority)
+		assert.Len(c.Spans, 1)
+	}
+	assert.EqualValues(2, p.Source.TracesReceived)
+	assert.EqualValues(1, p.Source.PayloadAccepted)
+
+	resp, err = http.Post(server.URL, "application/json", bytes.NewReader([]byte("{")))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+	assert.Empty(r.out)
+}
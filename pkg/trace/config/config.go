@@ -280,6 +280,8 @@ type AgentConfig struct {
 	// Concentrator
 	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
 	ExtraAggregators []string
+	// StatsDimensions specifies the span tags used as additional stats aggregation dimensions.
+	StatsDimensions []*StatsDimension
 
 	// Sampler configuration
 	ExtraSampleRate    float64
@@ -288,6 +290,7 @@ type AgentConfig struct {
 	DisableRareSampler bool
 	MaxEPS             float64
 	MaxRemoteTPS       float64
+	TailSampling       *TailSamplingConfig
 
 	// Receiver
 	ReceiverHost    string
@@ -336,6 +339,9 @@ type AgentConfig struct {
 	// It maps tag keys to a set of replacements. Only supported in A6.
 	ReplaceTags []*ReplaceRule
 
+	// SpanRules specifies the rules dropping and rewriting the spans, applied in order.
+	SpanRules []*SpanRule
+
 	// GlobalTags list metadata that will be added to all spans
 	GlobalTags map[string]string
 
@@ -385,6 +391,85 @@ type AgentConfig struct {
 	ContainerTags func(cid string) ([]string, error) `json:"-"`
 }
 
+// TailSamplingConfig holds the configuration of the tail sampling, which buffers the
+// chunks dropped by the other samplers to keep the traces matching a policy once complete.
+type TailSamplingConfig struct {
+	// Enabled reports whether the tail sampling is enabled.
+	Enabled bool
+	// DecisionWait specifies how long the chunks of a trace are buffered before a decision is made.
+	DecisionWait time.Duration
+	// MaxTraces specifies the maximum number of traces buffered. The oldest traces are
+	// decided early when it is reached.
+	MaxTraces int
+	// MaxSpans specifies the maximum number of spans buffered. The oldest traces are
+	// decided early when it is reached.
+	MaxSpans int
+	// Policies specifies the traces to keep. A trace is kept if it matches any policy.
+	Policies []*TailSamplingPolicy
+}
+
+// TailSamplingPolicy specifies traces kept by the tail sampling. A trace matches the
+// policy when it matches all of its conditions.
+type TailSamplingPolicy struct {
+	// Name identifies the policy in the telemetry.
+	Name string `mapstructure:"name"`
+
+	// LatencyThresholdMs matches the traces lasting at least this duration, in milliseconds.
+	LatencyThresholdMs float64 `mapstructure:"latency_threshold_ms"`
+
+	// The following conditions must all be matched by the same span of the trace.
+
+	// Error matches the spans with an error.
+	Error bool `mapstructure:"error"`
+	// Service matches the spans of this service.
+	Service string `mapstructure:"service"`
+	// Resource is a regexp matching the resource of the spans.
+	Resource string `mapstructure:"resource"`
+	// Attributes matches the spans with these tags. An empty value matches any value.
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
+// StatsDimension specifies a span tag used as an additional stats aggregation dimension.
+type StatsDimension struct {
+	// Tag is the name of the span tag.
+	Tag string `mapstructure:"tag"`
+	// MaxCardinality is the maximum number of distinct values of the tag aggregated
+	// separately in a stats bucket. The other values are aggregated together. When 0,
+	// a default limit is used.
+	MaxCardinality int `mapstructure:"max_cardinality"`
+}
+
+// SpanRule specifies a rule dropping or rewriting the spans matching all of its
+// conditions. A rule without conditions matches all the spans.
+type SpanRule struct {
+	// Name identifies the rule in the logs.
+	Name string `mapstructure:"name"`
+
+	// Service is a regexp matching the service of the spans.
+	Service string `mapstructure:"service"`
+	// SpanName is a regexp matching the name of the spans.
+	SpanName string `mapstructure:"span_name"`
+	// Resource is a regexp matching the resource of the spans.
+	Resource string `mapstructure:"resource"`
+	// Meta maps tags to regexps matching their value. An empty regexp matches any value.
+	Meta map[string]string `mapstructure:"meta"`
+	// Metrics maps metrics to comparisons with their value, such as "> 500" or "== 1".
+	// An empty comparison matches any value.
+	Metrics map[string]string `mapstructure:"metrics"`
+
+	// Drop drops the matching spans when set to "span", or their whole trace when set
+	// to "trace". The following actions are ignored when set.
+	Drop string `mapstructure:"drop"`
+	// DeleteTags lists the tags removed from the matching spans.
+	DeleteTags []string `mapstructure:"delete_tags"`
+	// HashTags lists the tags whose value is replaced with its SHA-256 hash.
+	HashTags []string `mapstructure:"hash_tags"`
+	// RenameService specifies the new service of the matching spans.
+	RenameService string `mapstructure:"rename_service"`
+	// AddTags lists the tags added to the matching spans.
+	AddTags map[string]string `mapstructure:"add_tags"`
+}
+
 // RemoteClient client is used to APM Sampling Updates from a remote source. Within the Datadog Agent
 // the implementation is (cmd/trace-agent.remoteClient).
 type RemoteClient interface {
@@ -419,6 +504,11 @@ func New() *AgentConfig {
 		ErrorTPS:        10,
 		MaxEPS:          200,
 		MaxRemoteTPS:    100,
+		TailSampling: &TailSamplingConfig{
+			DecisionWait: 10 * time.Second,
+			MaxTraces:    50000,
+			MaxSpans:     1_000_000,
+		},
 
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
@@ -8,6 +8,7 @@ package event
 import (
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 )
 
 // Processor is responsible for all the logic surrounding extraction and sampling of APM events from processed traces.
@@ -55,6 +56,7 @@ func (p *Processor) Process(root *pb.Span, t *pb.TraceChunk) (numEvents, numExtr
 	preSampleRate := sampler.GetPreSampleRate(root)
 	priority := sampler.SamplingPriority(t.Priority)
 	events := []*pb.Span{}
+	traceIDHigh := traceutil.GetTraceID(t.Spans).High
 
 	for _, span := range t.Spans {
 		extractionRate, ok := p.extract(span, priority)
@@ -66,6 +68,8 @@ func (p *Processor) Process(root *pb.Span, t *pb.TraceChunk) (numEvents, numExtr
 		}
 
 		numExtracted++
+		// events are sent on their own, so they hold the full trace ID
+		traceutil.SetTraceIDHigh(span, traceIDHigh)
 
 		sampled, epsRate := p.maxEPSSample(span, priority)
 		if !sampled {
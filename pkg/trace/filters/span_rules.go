@@ -0,0 +1,283 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// dropSpan drops the matching spans.
+	dropSpan = "span"
+	// dropTrace drops the traces of the matching spans.
+	dropTrace = "trace"
+)
+
+// SpanRules is a filter which drops and rewrites spans based on its rules.
+type SpanRules struct {
+	rules []*spanRule
+}
+
+// spanRule is the compiled form of a config.SpanRule.
+type spanRule struct {
+	name     string
+	service  *regexp.Regexp
+	spanName *regexp.Regexp
+	resource *regexp.Regexp
+	meta     map[string]*regexp.Regexp // nil regexps match any value
+	metrics  map[string]metricCondition
+
+	drop          string
+	deleteTags    []string
+	hashTags      []string
+	renameService string
+	addTags       map[string]string
+}
+
+// metricCondition compares the value of a metric with a constant. An empty op matches any value.
+type metricCondition struct {
+	op    string
+	value float64
+}
+
+// metricOps lists the supported comparison operators, the longest first.
+var metricOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// NewSpanRules returns a new SpanRules which will use the given set of rules. The
+// invalid rules are skipped.
+func NewSpanRules(conf []*config.SpanRule) *SpanRules {
+	var rules []*spanRule
+	for i, c := range conf {
+		if c == nil {
+			log.Errorf("Invalid span rule %q (skipping): empty rule", "rule_"+strconv.Itoa(i))
+			continue
+		}
+		r, err := newSpanRule(c)
+		if r.name == "" {
+			r.name = "rule_" + strconv.Itoa(i)
+		}
+		if err != nil {
+			log.Errorf("Invalid span rule %q (skipping): %v", r.name, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+	return &SpanRules{rules: rules}
+}
+
+func newSpanRule(c *config.SpanRule) (*spanRule, error) {
+	r := &spanRule{
+		name:       c.Name,
+		drop:       c.Drop,
+		deleteTags: c.DeleteTags,
+		hashTags:   c.HashTags,
+		addTags:    c.AddTags,
+	}
+	var err error
+	if r.service, err = compileOptional(c.Service); err != nil {
+		return r, fmt.Errorf("service: %v", err)
+	}
+	if r.spanName, err = compileOptional(c.SpanName); err != nil {
+		return r, fmt.Errorf("span_name: %v", err)
+	}
+	if r.resource, err = compileOptional(c.Resource); err != nil {
+		return r, fmt.Errorf("resource: %v", err)
+	}
+	if len(c.Meta) > 0 {
+		r.meta = make(map[string]*regexp.Regexp, len(c.Meta))
+		for k, expr := range c.Meta {
+			if r.meta[k], err = compileOptional(expr); err != nil {
+				return r, fmt.Errorf("meta %q: %v", k, err)
+			}
+		}
+	}
+	if len(c.Metrics) > 0 {
+		r.metrics = make(map[string]metricCondition, len(c.Metrics))
+		for k, expr := range c.Metrics {
+			if r.metrics[k], err = parseMetricCondition(expr); err != nil {
+				return r, fmt.Errorf("metric %q: %v", k, err)
+			}
+		}
+	}
+	switch r.drop {
+	case "", dropSpan, dropTrace:
+	default:
+		return r, fmt.Errorf("drop must be %q or %q, got %q", dropSpan, dropTrace, r.drop)
+	}
+	if c.RenameService != "" {
+		if r.renameService, err = traceutil.NormalizeService(c.RenameService, ""); err != nil {
+			return r, fmt.Errorf("rename_service: %v", err)
+		}
+	}
+	if r.drop == "" && len(r.deleteTags) == 0 && len(r.hashTags) == 0 && r.renameService == "" && len(r.addTags) == 0 {
+		return r, fmt.Errorf("no action")
+	}
+	return r, nil
+}
+
+// compileOptional compiles expr, returning nil when it is empty.
+func compileOptional(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+// parseMetricCondition parses a comparison such as "> 500".
+func parseMetricCondition(expr string) (metricCondition, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return metricCondition{}, nil
+	}
+	for _, op := range metricOps {
+		if strings.HasPrefix(expr, op) {
+			v, err := strconv.ParseFloat(strings.TrimSpace(expr[len(op):]), 64)
+			if err != nil {
+				return metricCondition{}, err
+			}
+			return metricCondition{op: op, value: v}, nil
+		}
+	}
+	return metricCondition{}, fmt.Errorf("unknown operator in %q", expr)
+}
+
+// Apply applies the rules to the spans of the trace. The rules are applied in order
+// to each span, the actions of a rule being visible to the next ones, until a rule
+// drops the span. Apply returns the spans kept, and false if the whole trace must be
+// dropped. A nil SpanRules keeps all the spans.
+func (f *SpanRules) Apply(trace pb.Trace) (pb.Trace, bool) {
+	if f == nil || len(f.rules) == 0 {
+		return trace, true
+	}
+	kept := trace[:0]
+	// dropped maps the IDs of the dropped spans to their parent ID
+	var dropped map[uint64]uint64
+	for _, s := range trace {
+		switch f.applySpan(s) {
+		case dropTrace:
+			return nil, false
+		case dropSpan:
+			if dropped == nil {
+				dropped = make(map[uint64]uint64)
+			}
+			dropped[s.SpanID] = s.ParentID
+			continue
+		}
+		kept = append(kept, s)
+	}
+	// clear the dropped spans at the end of the backing array
+	for i := len(kept); i < len(trace); i++ {
+		trace[i] = nil
+	}
+	if len(dropped) > 0 {
+		reparent(kept, dropped)
+	}
+	return kept, true
+}
+
+// reparent attaches the kept spans whose parent was dropped to the closest kept ancestor,
+// so that the dropped spans don't leave orphans mistaken for top-level spans or roots.
+func reparent(kept pb.Trace, dropped map[uint64]uint64) {
+	for _, s := range kept {
+		// the number of steps is bounded in case of a parenting cycle
+		for i := 0; i < len(dropped); i++ {
+			parentID, ok := dropped[s.ParentID]
+			if !ok {
+				break
+			}
+			s.ParentID = parentID
+		}
+	}
+}
+
+// applySpan applies the rules to s. It returns dropSpan or dropTrace when a rule drops
+// the span or its trace, and an empty string otherwise.
+func (f *SpanRules) applySpan(s *pb.Span) string {
+	for _, r := range f.rules {
+		if !r.matches(s) {
+			continue
+		}
+		if r.drop != "" {
+			log.Debugf("Span rule %q dropping %s. span: %v", r.name, r.drop, s)
+			return r.drop
+		}
+		r.rewrite(s)
+	}
+	return ""
+}
+
+func (r *spanRule) matches(s *pb.Span) bool {
+	if r.service != nil && !r.service.MatchString(s.Service) {
+		return false
+	}
+	if r.spanName != nil && !r.spanName.MatchString(s.Name) {
+		return false
+	}
+	if r.resource != nil && !r.resource.MatchString(s.Resource) {
+		return false
+	}
+	for k, re := range r.meta {
+		v, ok := s.Meta[k]
+		if !ok || (re != nil && !re.MatchString(v)) {
+			return false
+		}
+	}
+	for k, cond := range r.metrics {
+		v, ok := s.Metrics[k]
+		if !ok || !cond.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c metricCondition) matches(v float64) bool {
+	switch c.op {
+	case "==":
+		return v == c.value
+	case "!=":
+		return v != c.value
+	case "<":
+		return v < c.value
+	case "<=":
+		return v <= c.value
+	case ">":
+		return v > c.value
+	case ">=":
+		return v >= c.value
+	default:
+		return true
+	}
+}
+
+// rewrite applies the rewriting actions of the rule to s.
+func (r *spanRule) rewrite(s *pb.Span) {
+	for _, k := range r.deleteTags {
+		delete(s.Meta, k)
+	}
+	for _, k := range r.hashTags {
+		if v, ok := s.Meta[k]; ok {
+			sum := sha256.Sum256([]byte(v))
+			s.Meta[k] = hex.EncodeToString(sum[:])
+		}
+	}
+	if r.renameService != "" {
+		s.Service = r.renameService
+	}
+	for k, v := range r.addTags {
+		traceutil.SetMeta(s, k, v)
+	}
+}
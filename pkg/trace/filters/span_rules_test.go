@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpanRulesMatch(t *testing.T) {
+	span := pb.Span{
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "GET /users",
+		Meta:     map[string]string{"http.method": "GET", "team": "payments"},
+		Metrics:  map[string]float64{"http.status_code": 500},
+	}
+	for _, tt := range []struct {
+		rule  config.SpanRule
+		match bool
+	}{
+		{rule: config.SpanRule{}, match: true},
+		{rule: config.SpanRule{Service: "^web$", SpanName: "^http\\.", Resource: "/users"}, match: true},
+		{rule: config.SpanRule{Service: "^db$"}, match: false},
+		{rule: config.SpanRule{SpanName: "^grpc\\."}, match: false},
+		{rule: config.SpanRule{Resource: "^POST"}, match: false},
+		{rule: config.SpanRule{Meta: map[string]string{"http.method": "^GET$", "team": ""}}, match: true},
+		{rule: config.SpanRule{Meta: map[string]string{"http.method": "^POST$"}}, match: false},
+		{rule: config.SpanRule{Meta: map[string]string{"missing": ""}}, match: false},
+		{rule: config.SpanRule{Metrics: map[string]string{"http.status_code": ">= 500"}}, match: true},
+		{rule: config.SpanRule{Metrics: map[string]string{"http.status_code": "==500"}}, match: true},
+		{rule: config.SpanRule{Metrics: map[string]string{"http.status_code": "< 500"}}, match: false},
+		{rule: config.SpanRule{Metrics: map[string]string{"http.status_code": ""}}, match: true},
+		{rule: config.SpanRule{Metrics: map[string]string{"missing": ""}}, match: false},
+	} {
+		tt.rule.Drop = "span"
+		r, err := newSpanRule(&tt.rule)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.match, r.matches(&span), "%+v", tt.rule)
+	}
+}
+
+func TestSpanRulesInvalid(t *testing.T) {
+	f := NewSpanRules([]*config.SpanRule{
+		{Name: "no action"},
+		{Name: "invalid service", Service: "(", Drop: "span"},
+		{Name: "invalid meta", Meta: map[string]string{"a": "("}, Drop: "span"},
+		{Name: "invalid metric", Metrics: map[string]string{"a": "~ 1"}, Drop: "span"},
+		{Name: "invalid metric value", Metrics: map[string]string{"a": "> x"}, Drop: "span"},
+		{Name: "invalid drop", Drop: "all"},
+		{Name: "invalid service name", RenameService: "!!!"},
+		nil,
+		{Drop: "span"},
+	})
+	assert.Len(t, f.rules, 1)
+	assert.Equal(t, "rule_8", f.rules[0].name)
+}
+
+func TestSpanRulesApply(t *testing.T) {
+	assert := assert.New(t)
+	f := NewSpanRules([]*config.SpanRule{
+		{Name: "health_checks", Resource: "^GET /health", Drop: "trace"},
+		{Name: "cache", SpanName: "^redis\\.", Drop: "span"},
+		{
+			Name:          "pii",
+			Meta:          map[string]string{"user.email": ""},
+			DeleteTags:    []string{"user.email"},
+			HashTags:      []string{"user.id", "missing"},
+			RenameService: "Web Store",
+			AddTags:       map[string]string{"pii": "redacted"},
+		},
+		// sees the actions of the previous rules
+		{Name: "store", Service: "^web_store$", AddTags: map[string]string{"store": "true"}},
+	})
+
+	t.Run("rewrite", func(t *testing.T) {
+		root := &pb.Span{
+			SpanID:   1,
+			Service:  "web",
+			Name:     "http.request",
+			Resource: "GET /users",
+			Meta:     map[string]string{"user.email": "jane@example.com", "user.id": "42"},
+		}
+		cache := &pb.Span{SpanID: 2, ParentID: 1, Name: "redis.command"}
+		db := &pb.Span{SpanID: 3, ParentID: 1, Name: "db.query"}
+
+		trace, keep := f.Apply(pb.Trace{root, cache, db})
+		assert.True(keep)
+		assert.Equal(pb.Trace{root, db}, trace)
+		assert.Equal("web_store", root.Service)
+		assert.Equal(map[string]string{
+			"user.id": "73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049",
+			"pii":     "redacted",
+			"store":   "true",
+		}, root.Meta)
+		assert.Nil(db.Meta)
+	})
+
+	t.Run("drop-middle-span", func(t *testing.T) {
+		root := &pb.Span{SpanID: 1, Name: "http.request"}
+		cache := &pb.Span{SpanID: 2, ParentID: 1, Name: "redis.command"}
+		nested := &pb.Span{SpanID: 3, ParentID: 2, Name: "redis.pipeline"}
+		db := &pb.Span{SpanID: 4, ParentID: 3, Name: "db.query"}
+		other := &pb.Span{SpanID: 5, ParentID: 1, Name: "db.query"}
+
+		trace, keep := f.Apply(pb.Trace{root, cache, nested, db, other})
+		assert.True(keep)
+		assert.Equal(pb.Trace{root, db, other}, trace)
+		// the children of the dropped spans are attached to their closest kept ancestor
+		assert.EqualValues(1, db.ParentID)
+		assert.EqualValues(1, other.ParentID)
+		assert.EqualValues(0, root.ParentID)
+	})
+
+	t.Run("drop-trace", func(t *testing.T) {
+		trace, keep := f.Apply(pb.Trace{
+			{SpanID: 1, Name: "http.request", Resource: "GET /health"},
+			{SpanID: 2, ParentID: 1, Name: "db.query"},
+		})
+		assert.False(keep)
+		assert.Nil(trace)
+	})
+
+	t.Run("no-rules", func(t *testing.T) {
+		in := pb.Trace{{SpanID: 1}}
+		trace, keep := NewSpanRules(nil).Apply(in)
+		assert.True(keep)
+		assert.Equal(in, trace)
+	})
+}
@@ -65,7 +65,9 @@ const (
 )
 
 // SampleByRate returns whether to keep a trace, based on its ID and a sampling rate.
-// This assumes that trace IDs are nearly uniformly distributed.
+// This assumes that trace IDs are nearly uniformly distributed. Only the low 64 bits of
+// 128-bit trace IDs are used, so that the decision is the same for all the chunks of a
+// trace, whether their tracer propagates the high bits or not.
 func SampleByRate(traceID uint64, rate float64) bool {
 	if rate < 1 {
 		return traceID*samplerHasher < uint64(rate*maxTraceIDFloat)
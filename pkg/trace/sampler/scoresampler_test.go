@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/atomic"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -161,3 +162,16 @@ func BenchmarkSampler(b *testing.B) {
 		s.Sample(ts, trace, trace[0], defaultEnv)
 	}
 }
+
+func TestSampleByRateIgnoresTraceIDHigh(t *testing.T) {
+	assert := assert.New(t)
+	s := getTestErrorsSampler(10)
+	for i := 0; i < 1000; i++ {
+		// the chunks of a trace sent by a 128-bit aware tracer and by a 64-bit one
+		tID := randomTraceID()
+		withHigh := &pb.Span{TraceID: tID, SpanID: 1}
+		traceutil.SetTraceIDHigh(withHigh, rand.Uint64()|1)
+		withoutHigh := &pb.Span{TraceID: tID, SpanID: 2}
+		assert.Equal(s.applySampleRate(withoutHigh, 0.5), s.applySampleRate(withHigh, 0.5))
+	}
+}
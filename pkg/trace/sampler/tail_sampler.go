@@ -0,0 +1,347 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"container/list"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// tailFlushPeriod specifies the frequency at which the decisions are made for the expired traces.
+	tailFlushPeriod = time.Second
+	// tailReportPeriod specifies the frequency at which the telemetry is reported.
+	tailReportPeriod = 10 * time.Second
+)
+
+// TailChunk is a chunk buffered by the TailSampler.
+type TailChunk struct {
+	// TracerPayload holds the metadata of the payload in which the chunk was received.
+	// Its chunks are ignored.
+	TracerPayload *pb.TracerPayload
+	// Chunk is the complete chunk, sent when the trace is kept.
+	Chunk *pb.TraceChunk
+	// DroppedChunk holds the analyzed spans of the chunk, sent when the trace is dropped.
+	// It is nil if there is none.
+	DroppedChunk *pb.TraceChunk
+	// NumEvents specifies the number of analyzed spans of the chunk.
+	NumEvents int64
+}
+
+// TailSampler buffers by trace ID the chunks dropped by the other samplers for a wait
+// window, and then keeps the traces matching a policy. As the decision is made on all
+// the chunks received during the window, a slow or erroring span arriving in a later
+// chunk or payload can keep the whole trace.
+//
+// When a chunk of a trace is sampled by the other samplers, the chunks of this trace
+// buffered or received during the window are kept too.
+type TailSampler struct {
+	policies  []*tailPolicy
+	wait      time.Duration
+	maxTraces int
+	maxSpans  int
+	// out receives the chunks to send, either complete when their trace is kept or
+	// only their analyzed spans when it is dropped.
+	out func([]*TailChunk)
+
+	mu     sync.Mutex
+	traces map[traceutil.TraceID]*list.Element
+	queue  *list.List // *tailTrace ordered by deadline
+	spans  int
+
+	// telemetry, reset on each report
+	keptByPolicy map[string]int64
+	dropped      int64
+	evicted      int64
+
+	exit    chan struct{}
+	stopped chan struct{}
+}
+
+// tailTrace holds the buffered chunks of a trace.
+type tailTrace struct {
+	traceID  traceutil.TraceID
+	deadline time.Time
+	chunks   []*TailChunk
+	spans    int
+	// sampled reports whether a chunk of the trace was sampled by the other samplers.
+	sampled bool
+}
+
+// NewTailSampler returns a TailSampler sending the chunks to out.
+func NewTailSampler(conf *config.TailSamplingConfig, out func([]*TailChunk)) *TailSampler {
+	return &TailSampler{
+		policies:     newTailPolicies(conf.Policies),
+		wait:         conf.DecisionWait,
+		maxTraces:    conf.MaxTraces,
+		maxSpans:     conf.MaxSpans,
+		out:          out,
+		traces:       make(map[traceutil.TraceID]*list.Element),
+		queue:        list.New(),
+		keptByPolicy: make(map[string]int64),
+		exit:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// Start starts making the decisions for the expired traces.
+func (s *TailSampler) Start() {
+	go func() {
+		flush := time.NewTicker(tailFlushPeriod)
+		defer flush.Stop()
+		report := time.NewTicker(tailReportPeriod)
+		defer report.Stop()
+		defer close(s.stopped)
+		for {
+			select {
+			case now := <-flush.C:
+				s.flush(now)
+			case <-report.C:
+				s.report()
+			case <-s.exit:
+				// make the decisions for all the buffered traces
+				s.flush(time.Time{})
+				s.report()
+				return
+			}
+		}
+	}()
+}
+
+// Stop makes the decisions for all the buffered traces and stops the sampler.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	<-s.stopped
+}
+
+// Add adds a non-empty chunk to the sampler. sampled reports whether the chunk was
+// sampled by the other samplers, in which case it is not buffered but the whole trace
+// is kept. Add returns true if the chunk must be sent right away, false if it is
+// buffered.
+func (s *TailSampler) Add(now time.Time, c *TailChunk, sampled bool) bool {
+	traceID := traceutil.GetTraceID(c.Chunk.Spans)
+
+	s.mu.Lock()
+	var t *tailTrace
+	if e, ok := s.traces[traceID]; ok {
+		t = e.Value.(*tailTrace)
+	} else {
+		t = &tailTrace{traceID: traceID, deadline: now.Add(s.wait)}
+		s.traces[traceID] = s.queue.PushBack(t)
+	}
+	if t.sampled {
+		s.mu.Unlock()
+		return true
+	}
+
+	var send []*TailChunk
+	if sampled {
+		t.sampled = true
+		send = t.chunks
+		s.spans -= t.spans
+		t.chunks, t.spans = nil, 0
+	} else {
+		t.chunks = append(t.chunks, c)
+		t.spans += len(c.Chunk.Spans)
+		s.spans += len(c.Chunk.Spans)
+		send = s.evict()
+	}
+	s.mu.Unlock()
+
+	if len(send) > 0 {
+		s.out(send)
+	}
+	return sampled
+}
+
+// evict makes the decisions for the oldest traces until the memory limits are
+// respected, and returns the chunks to send. It must be called with the lock held.
+func (s *TailSampler) evict() []*TailChunk {
+	var send []*TailChunk
+	for s.queue.Len() > 0 && (len(s.traces) > s.maxTraces || s.spans > s.maxSpans) {
+		t := s.remove(s.queue.Front())
+		if len(t.chunks) > 0 {
+			s.evicted++
+		}
+		send = append(send, s.decide(t)...)
+	}
+	return send
+}
+
+// flush makes the decisions for the traces expired at now, or for all the traces if
+// now is zero, and sends their chunks.
+func (s *TailSampler) flush(now time.Time) {
+	var send []*TailChunk
+	s.mu.Lock()
+	for s.queue.Len() > 0 {
+		e := s.queue.Front()
+		if !now.IsZero() && e.Value.(*tailTrace).deadline.After(now) {
+			break
+		}
+		send = append(send, s.decide(s.remove(e))...)
+	}
+	s.mu.Unlock()
+
+	if len(send) > 0 {
+		s.out(send)
+	}
+}
+
+// remove removes a trace from the buffer. It must be called with the lock held.
+func (s *TailSampler) remove(e *list.Element) *tailTrace {
+	t := s.queue.Remove(e).(*tailTrace)
+	delete(s.traces, t.traceID)
+	s.spans -= t.spans
+	return t
+}
+
+// decide returns the chunks of t to send. It must be called with the lock held.
+func (s *TailSampler) decide(t *tailTrace) []*TailChunk {
+	if len(t.chunks) == 0 {
+		return nil
+	}
+	for _, p := range s.policies {
+		if p.matches(t) {
+			s.keptByPolicy[p.name]++
+			return t.chunks
+		}
+	}
+
+	s.dropped++
+	var send []*TailChunk
+	for _, c := range t.chunks {
+		if c.DroppedChunk != nil {
+			send = append(send, &TailChunk{TracerPayload: c.TracerPayload, Chunk: c.DroppedChunk, NumEvents: c.NumEvents})
+		}
+	}
+	return send
+}
+
+func (s *TailSampler) report() {
+	s.mu.Lock()
+	traces, spans := len(s.traces), s.spans
+	keptByPolicy, dropped, evicted := s.keptByPolicy, s.dropped, s.evicted
+	s.keptByPolicy = make(map[string]int64, len(keptByPolicy))
+	s.dropped, s.evicted = 0, 0
+	s.mu.Unlock()
+
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_traces", float64(traces), nil, 1)
+	metrics.Gauge("datadog.trace_agent.sampler.tail.buffered_spans", float64(spans), nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.tail.evicted_traces", evicted, nil, 1)
+	metrics.Count("datadog.trace_agent.sampler.tail.dropped_traces", dropped, nil, 1)
+	for name, kept := range keptByPolicy {
+		metrics.Count("datadog.trace_agent.sampler.tail.kept_traces", kept, []string{"policy:" + name}, 1)
+	}
+}
+
+// tailPolicy is the compiled form of a config.TailSamplingPolicy.
+type tailPolicy struct {
+	name       string
+	latency    int64 // in nanoseconds, 0 if not set
+	error      bool
+	service    string
+	resource   *regexp.Regexp
+	attributes map[string]string
+}
+
+// newTailPolicies compiles the policies. The invalid ones are skipped.
+func newTailPolicies(conf []*config.TailSamplingPolicy) []*tailPolicy {
+	var policies []*tailPolicy
+	for i, c := range conf {
+		p := &tailPolicy{
+			name:       c.Name,
+			latency:    int64(c.LatencyThresholdMs * float64(time.Millisecond)),
+			error:      c.Error,
+			service:    c.Service,
+			attributes: c.Attributes,
+		}
+		if p.name == "" {
+			p.name = "policy_" + strconv.Itoa(i)
+		}
+		if c.Resource != "" {
+			re, err := regexp.Compile(c.Resource)
+			if err != nil {
+				log.Errorf("Invalid resource of the tail sampling policy %q (skipping): %v", p.name, err)
+				continue
+			}
+			p.resource = re
+		}
+		if p.latency <= 0 && !p.hasSpanConditions() {
+			log.Errorf("The tail sampling policy %q has no condition (skipping)", p.name)
+			continue
+		}
+		policies = append(policies, p)
+	}
+	return policies
+}
+
+func (p *tailPolicy) hasSpanConditions() bool {
+	return p.error || p.service != "" || p.resource != nil || len(p.attributes) > 0
+}
+
+// matches reports whether the buffered chunks of t match the policy.
+func (p *tailPolicy) matches(t *tailTrace) bool {
+	if p.latency > 0 && traceDuration(t) < p.latency {
+		return false
+	}
+	if !p.hasSpanConditions() {
+		return true
+	}
+	for _, c := range t.chunks {
+		for _, span := range c.Chunk.Spans {
+			if p.matchesSpan(span) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *tailPolicy) matchesSpan(span *pb.Span) bool {
+	if p.error && span.Error == 0 {
+		return false
+	}
+	if p.service != "" && span.Service != p.service {
+		return false
+	}
+	if p.resource != nil && !p.resource.MatchString(span.Resource) {
+		return false
+	}
+	for k, v := range p.attributes {
+		if actual, ok := span.Meta[k]; !ok || (v != "" && actual != v) {
+			return false
+		}
+	}
+	return true
+}
+
+// traceDuration returns the duration between the start of the first span and the
+// end of the last span of the buffered chunks.
+func traceDuration(t *tailTrace) int64 {
+	var start, end int64
+	first := true
+	for _, c := range t.chunks {
+		for _, span := range c.Chunk.Spans {
+			if first || span.Start < start {
+				start = span.Start
+			}
+			if first || span.Start+span.Duration > end {
+				end = span.Start + span.Duration
+			}
+			first = false
+		}
+	}
+	return end - start
+}
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+type tailSamplerOut struct {
+	chunks []*TailChunk
+}
+
+func (o *tailSamplerOut) send(chunks []*TailChunk) {
+	o.chunks = append(o.chunks, chunks...)
+}
+
+func newTestTailSampler(policies ...*config.TailSamplingPolicy) (*TailSampler, *tailSamplerOut) {
+	out := &tailSamplerOut{}
+	s := NewTailSampler(&config.TailSamplingConfig{
+		DecisionWait: 10 * time.Second,
+		MaxTraces:    100,
+		MaxSpans:     1000,
+		Policies:     policies,
+	}, out.send)
+	return s, out
+}
+
+func newTailChunk(spans ...*pb.Span) *TailChunk {
+	return &TailChunk{TracerPayload: &pb.TracerPayload{}, Chunk: &pb.TraceChunk{Spans: spans}}
+}
+
+func TestTailSamplerLatencyAcrossChunks(t *testing.T) {
+	assert := assert.New(t)
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Name: "slow", LatencyThresholdMs: 500})
+	now := time.Now()
+
+	c1 := newTailChunk(&pb.Span{TraceID: 1, SpanID: 1, Start: 0, Duration: int64(100 * time.Millisecond)})
+	c2 := newTailChunk(&pb.Span{TraceID: 1, SpanID: 2, Start: int64(400 * time.Millisecond), Duration: int64(200 * time.Millisecond)})
+	fast := newTailChunk(&pb.Span{TraceID: 2, SpanID: 3, Start: 0, Duration: int64(100 * time.Millisecond)})
+	assert.False(s.Add(now, c1, false))
+	assert.False(s.Add(now.Add(time.Second), c2, false))
+	assert.False(s.Add(now.Add(time.Second), fast, false))
+
+	// nothing is decided before the end of the wait window
+	s.flush(now.Add(9 * time.Second))
+	assert.Empty(out.chunks)
+	assert.Len(s.traces, 2)
+
+	// the window starts with the first chunk of the trace
+	s.flush(now.Add(10 * time.Second))
+	assert.Equal([]*TailChunk{c1, c2}, out.chunks)
+	assert.Len(s.traces, 1)
+
+	s.flush(now.Add(11 * time.Second))
+	assert.Len(out.chunks, 2)
+	assert.Empty(s.traces)
+	assert.Equal(0, s.spans)
+	assert.Equal(int64(1), s.keptByPolicy["slow"])
+	assert.Equal(int64(1), s.dropped)
+}
+
+func TestTailSamplerSpanPolicy(t *testing.T) {
+	s, _ := newTestTailSampler(&config.TailSamplingPolicy{
+		Name:       "checkout_errors",
+		Error:      true,
+		Service:    "web",
+		Resource:   "^POST /checkout",
+		Attributes: map[string]string{"http.status_code": "500", "team": ""},
+	})
+	assert.Len(t, s.policies, 1)
+	p := s.policies[0]
+
+	match := pb.Span{
+		Service:  "web",
+		Resource: "POST /checkout/confirm",
+		Error:    1,
+		Meta:     map[string]string{"http.status_code": "500", "team": "payments"},
+	}
+	assert.True(t, p.matchesSpan(&match))
+
+	for name, update := range map[string]func(*pb.Span){
+		"no error":          func(s *pb.Span) { s.Error = 0 },
+		"other service":     func(s *pb.Span) { s.Service = "db" },
+		"other resource":    func(s *pb.Span) { s.Resource = "GET /checkout" },
+		"other status code": func(s *pb.Span) { s.Meta = map[string]string{"http.status_code": "404", "team": "payments"} },
+		"missing attribute": func(s *pb.Span) { s.Meta = map[string]string{"http.status_code": "500"} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			span := match
+			update(&span)
+			assert.False(t, p.matchesSpan(&span))
+		})
+	}
+}
+
+func TestTailSamplerInvalidPolicies(t *testing.T) {
+	s, _ := newTestTailSampler(
+		&config.TailSamplingPolicy{Name: "no condition"},
+		&config.TailSamplingPolicy{Name: "invalid resource", Resource: "("},
+		&config.TailSamplingPolicy{Service: "web"},
+	)
+	assert.Len(t, s.policies, 1)
+	assert.Equal(t, "policy_2", s.policies[0].name)
+}
+
+func TestTailSamplerDroppedEvents(t *testing.T) {
+	assert := assert.New(t)
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Error: true})
+	now := time.Now()
+
+	event := &pb.Span{TraceID: 1, SpanID: 2}
+	c := newTailChunk(&pb.Span{TraceID: 1, SpanID: 1}, event)
+	c.DroppedChunk = &pb.TraceChunk{Spans: []*pb.Span{event}, DroppedTrace: true}
+	c.NumEvents = 1
+	s.Add(now, c, false)
+	s.Add(now, newTailChunk(&pb.Span{TraceID: 2, SpanID: 3}), false)
+
+	s.flush(now.Add(time.Minute))
+	assert.Len(out.chunks, 1)
+	assert.Equal(c.DroppedChunk, out.chunks[0].Chunk)
+	assert.Equal(int64(1), out.chunks[0].NumEvents)
+	assert.Equal(int64(2), s.dropped)
+}
+
+func TestTailSamplerSampledTrace(t *testing.T) {
+	assert := assert.New(t)
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Error: true})
+	now := time.Now()
+
+	buffered := newTailChunk(&pb.Span{TraceID: 1, SpanID: 1})
+	assert.False(s.Add(now, buffered, false))
+
+	// a chunk sampled by the other samplers releases the buffered chunks of its trace
+	assert.True(s.Add(now, newTailChunk(&pb.Span{TraceID: 1, SpanID: 2}), true))
+	assert.Equal([]*TailChunk{buffered}, out.chunks)
+	assert.Equal(0, s.spans)
+
+	// and keeps the next chunks of the trace
+	assert.True(s.Add(now, newTailChunk(&pb.Span{TraceID: 1, SpanID: 3}), false))
+
+	s.flush(now.Add(time.Minute))
+	assert.Len(out.chunks, 1)
+	assert.Empty(s.traces)
+}
+
+func TestTailSamplerEviction(t *testing.T) {
+	assert := assert.New(t)
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Error: true})
+	s.maxTraces = 2
+	s.maxSpans = 3
+	now := time.Now()
+
+	errorChunk := newTailChunk(&pb.Span{TraceID: 1, SpanID: 1, Error: 1})
+	s.Add(now, errorChunk, false)
+	s.Add(now, newTailChunk(&pb.Span{TraceID: 2, SpanID: 2}), false)
+
+	// the oldest trace is decided early when the maximum number of traces is reached
+	s.Add(now, newTailChunk(&pb.Span{TraceID: 3, SpanID: 3}), false)
+	assert.Equal([]*TailChunk{errorChunk}, out.chunks)
+	assert.Len(s.traces, 2)
+	assert.Equal(int64(1), s.evicted)
+
+	// and when the maximum number of spans is reached
+	s.Add(now, newTailChunk(&pb.Span{TraceID: 3, SpanID: 4}, &pb.Span{TraceID: 3, SpanID: 5}), false)
+	assert.Len(s.traces, 1)
+	assert.Equal(3, s.spans)
+	assert.Equal(int64(2), s.evicted)
+}
+
+func TestTailSampler128BitTraceIDs(t *testing.T) {
+	assert := assert.New(t)
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Error: true})
+	now := time.Now()
+
+	high := map[string]string{"_dd.p.tid": "5af7183fb1d4cf5f"}
+	errorChunk := newTailChunk(&pb.Span{TraceID: 1, SpanID: 1, Error: 1, Meta: high})
+	s.Add(now, errorChunk, false)
+	// same low bits, other trace
+	s.Add(now, newTailChunk(&pb.Span{TraceID: 1, SpanID: 2}), false)
+	assert.Len(s.traces, 2)
+
+	s.flush(now.Add(time.Minute))
+	assert.Equal([]*TailChunk{errorChunk}, out.chunks)
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	s, out := newTestTailSampler(&config.TailSamplingPolicy{Error: true})
+	s.Start()
+	c := newTailChunk(&pb.Span{TraceID: 1, SpanID: 1, Error: 1})
+	s.Add(time.Now(), c, false)
+
+	// the buffered traces are decided when stopping
+	s.Stop()
+	assert.Equal(t, []*TailChunk{c}, out.chunks)
+}
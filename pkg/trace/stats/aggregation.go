@@ -33,6 +33,9 @@ type BucketsAggregationKey struct {
 	Type       string
 	StatusCode uint32
 	Synthetics bool
+	// Tags holds the comma-separated "tag:value" values of the additional aggregation
+	// dimensions, in the order of their configuration.
+	Tags string
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -70,6 +70,10 @@ func getTestStatsWithStart(start time.Time) pb.ClientStatsPayload {
 	b := pb.ClientStatsBucket{}
 	fuzzer.Fuzz(&b)
 	b.Start = uint64(start.UnixNano())
+	// the tags of the grouped stats are cleared by the agent before the aggregation
+	for i := range b.Stats {
+		b.Stats[i].Tags = nil
+	}
 	p := pb.ClientStatsPayload{}
 	fuzzer.Fuzz(&p)
 	p.Tags = nil
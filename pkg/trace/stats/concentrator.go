@@ -46,6 +46,9 @@ type Concentrator struct {
 	mu            sync.Mutex
 	agentEnv      string
 	agentHostname string
+	// dimensions computes the additional aggregation dimensions of the spans. Its
+	// cardinality limits are reset on each flush.
+	dimensions *dimensions
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
@@ -64,6 +67,7 @@ func NewConcentrator(conf *config.AgentConfig, out chan pb.StatsPayload, now tim
 		exit:          make(chan struct{}),
 		agentEnv:      conf.DefaultEnv,
 		agentHostname: conf.Hostname,
+		dimensions:    newDimensions(conf.StatsDimensions),
 	}
 	return &c
 }
@@ -179,7 +183,7 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
 			c.buckets[btime] = b
 		}
-		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey)
+		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey, c.dimensions.fromSpan(s))
 	}
 }
 
@@ -212,6 +216,7 @@ func (c *Concentrator) flushNow(now int64) pb.StatsPayload {
 		log.Debugf("update oldestTs to %d", newOldestTs)
 		c.oldestTs = newOldestTs
 	}
+	c.dimensions.reset()
 	c.mu.Unlock()
 	sb := make([]pb.ClientStatsPayload, 0, len(m))
 	for k, s := range m {
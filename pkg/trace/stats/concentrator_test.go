@@ -8,6 +8,7 @@ package stats
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -528,3 +529,44 @@ func TestIgnoresPartialSpans(t *testing.T) {
 	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
 	assert.Empty(stats.GetStats())
 }
+
+// TestConcentratorStatsDimensions tests that the spans are aggregated by the configured stats dimensions.
+func TestConcentratorStatsDimensions(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+
+	c := NewTestConcentrator(now)
+	c.dimensions = newDimensions([]*config.StatsDimension{
+		{Tag: "region", MaxCardinality: 2},
+		{Tag: "tenant_tier"},
+	})
+	var spans []*pb.Span
+	for i, region := range []string{"us", "eu", "us", "ap", "jp", ""} {
+		s := testSpan(uint64(i+1), 0, 50, 5, "A1", "resource1", 0)
+		s.Meta = map[string]string{"tenant_tier": "gold"}
+		if region != "" {
+			s.Meta["region"] = region
+		}
+		spans = append(spans, s)
+	}
+	traceutil.ComputeTopLevel(spans)
+	c.addNow(toProcessedTrace(spans, "none", ""), "")
+
+	stats := c.flushNow(now.UnixNano() + int64(c.bufferLen)*testBucketInterval)
+	assert.Len(stats.Stats, 1)
+	assert.Len(stats.Stats[0].Stats, 1)
+	hits := make(map[string]uint64)
+	for _, g := range stats.Stats[0].Stats[0].Stats {
+		hits[strings.Join(g.Tags, " ")] += g.Hits
+	}
+	assert.Equal(map[string]uint64{
+		"region:us tenant_tier:gold":     2,
+		"region:eu tenant_tier:gold":     1,
+		"region:_other tenant_tier:gold": 2,
+		"tenant_tier:gold":               1,
+	}, hits)
+	// the cardinality limits are reset on flush
+	for _, dim := range c.dimensions.dims {
+		assert.Empty(dim.values)
+	}
+}
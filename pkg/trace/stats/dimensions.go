@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// defaultMaxCardinality is the maximum number of distinct values of a dimension
+	// when its configuration does not specify one.
+	defaultMaxCardinality = 100
+	// overflowValue replaces the values of a dimension past its cardinality limit.
+	overflowValue = "_other"
+	// tagsSeparator separates the tags in BucketsAggregationKey.Tags. Normalized tags
+	// never contain it.
+	tagsSeparator = ","
+)
+
+// dimensions computes the additional aggregation dimensions of the stats, which are
+// the values of the span tags configured in apm_config.stats_dimensions. It is not
+// safe for concurrent use.
+type dimensions struct {
+	dims []*dimension
+}
+
+// dimension is a single aggregation dimension.
+type dimension struct {
+	tag            string
+	maxCardinality int
+	// values holds the distinct values aggregated separately since the last reset.
+	values map[string]struct{}
+}
+
+// newDimensions returns the dimensions specified by conf. The invalid ones are skipped.
+func newDimensions(conf []*config.StatsDimension) *dimensions {
+	d := &dimensions{}
+	seen := make(map[string]bool, len(conf))
+	for _, c := range conf {
+		switch {
+		case c.Tag == "":
+			log.Errorf("Invalid stats dimension (skipping): empty tag")
+			continue
+		case seen[c.Tag]:
+			log.Errorf("Invalid stats dimension %q (skipping): duplicate tag", c.Tag)
+			continue
+		}
+		seen[c.Tag] = true
+		max := c.MaxCardinality
+		if max <= 0 {
+			max = defaultMaxCardinality
+		}
+		d.dims = append(d.dims, &dimension{
+			tag:            c.Tag,
+			maxCardinality: max,
+			values:         make(map[string]struct{}),
+		})
+	}
+	return d
+}
+
+// enabled reports whether any dimension is configured.
+func (d *dimensions) enabled() bool {
+	return d != nil && len(d.dims) > 0
+}
+
+// fromSpan returns the aggregation tags of s, in the format of BucketsAggregationKey.Tags.
+func (d *dimensions) fromSpan(s *pb.Span) string {
+	if !d.enabled() {
+		return ""
+	}
+	var tags []string
+	for _, dim := range d.dims {
+		if v := s.Meta[dim.tag]; v != "" {
+			tags = append(tags, dim.format(v))
+		}
+	}
+	return strings.Join(tags, tagsSeparator)
+}
+
+// reset forgets the values seen so far, starting a new cardinality limit period.
+func (d *dimensions) reset() {
+	if d == nil {
+		return
+	}
+	for _, dim := range d.dims {
+		dim.values = make(map[string]struct{})
+	}
+}
+
+// format returns the normalized "tag:value" tag of the dimension for value v, replacing
+// v with overflowValue once the cardinality limit is reached.
+func (dim *dimension) format(v string) string {
+	tag := traceutil.NormalizeTag(dim.tag + ":" + v)
+	if _, ok := dim.values[tag]; ok {
+		return tag
+	}
+	if len(dim.values) >= dim.maxCardinality {
+		return traceutil.NormalizeTag(dim.tag + ":" + overflowValue)
+	}
+	dim.values[tag] = struct{}{}
+	return tag
+}
+
+// splitTags returns the list of tags of the given BucketsAggregationKey.Tags.
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, tagsSeparator)
+}
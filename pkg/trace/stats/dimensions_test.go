@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDimensions(t *testing.T) {
+	d := newDimensions([]*config.StatsDimension{
+		{Tag: "region", MaxCardinality: 3},
+		{Tag: ""},
+		{Tag: "region"},
+		{Tag: "peer.service"},
+	})
+	assert.Len(t, d.dims, 2)
+	assert.Equal(t, "region", d.dims[0].tag)
+	assert.Equal(t, 3, d.dims[0].maxCardinality)
+	assert.Equal(t, "peer.service", d.dims[1].tag)
+	assert.Equal(t, defaultMaxCardinality, d.dims[1].maxCardinality)
+
+	assert.False(t, newDimensions(nil).enabled())
+	var nilDims *dimensions
+	assert.False(t, nilDims.enabled())
+	assert.Equal(t, "", nilDims.fromSpan(&pb.Span{Meta: map[string]string{"region": "us"}}))
+}
+
+func TestDimensionsFromSpan(t *testing.T) {
+	assert := assert.New(t)
+	d := newDimensions([]*config.StatsDimension{
+		{Tag: "region", MaxCardinality: 2},
+		{Tag: "tenant_tier"},
+	})
+	span := func(meta map[string]string) *pb.Span { return &pb.Span{Meta: meta} }
+
+	assert.Equal("region:us-east-1,tenant_tier:gold", d.fromSpan(span(map[string]string{
+		"tenant_tier": "gold",
+		"region":      "us-east-1",
+		"other":       "ignored",
+	})))
+	assert.Equal("tenant_tier:silver", d.fromSpan(span(map[string]string{"tenant_tier": "Silver"})))
+	assert.Equal("", d.fromSpan(span(nil)))
+	// values are normalized before being counted
+	assert.Equal("region:eu_west", d.fromSpan(span(map[string]string{"region": "EU,West"})))
+	assert.Equal("region:eu_west", d.fromSpan(span(map[string]string{"region": "eu_west"})))
+	// the cardinality limit of region is reached
+	assert.Equal("region:_other", d.fromSpan(span(map[string]string{"region": "ap-south-1"})))
+	assert.Equal("region:us-east-1", d.fromSpan(span(map[string]string{"region": "us-east-1"})))
+
+	d.reset()
+	assert.Equal("region:ap-south-1", d.fromSpan(span(map[string]string{"region": "ap-south-1"})))
+}
@@ -72,6 +72,7 @@ func (s *groupedStats) export(a Aggregation) (pb.ClientGroupedStats, error) {
 		OkSummary:      okSummary,
 		ErrorSummary:   errSummary,
 		Synthetics:     a.Synthetics,
+		Tags:           splitTags(a.Tags),
 	}, nil
 }
 
@@ -143,12 +144,14 @@ func (sb *RawBucket) Export() map[PayloadAggregationKey]pb.ClientStatsBucket {
 	return m
 }
 
-// HandleSpan adds the span to this bucket stats, aggregated with the finest grain matching given aggregators
-func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin string, aggKey PayloadAggregationKey) {
+// HandleSpan adds the span to this bucket stats, aggregated with the finest grain matching given aggregators.
+// tags holds the values of the additional aggregation dimensions of the span, as in BucketsAggregationKey.Tags.
+func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin string, aggKey PayloadAggregationKey, tags string) {
 	if aggKey.Env == "" {
 		panic("env should never be empty")
 	}
 	aggr := NewAggregationFromSpan(s, origin, aggKey)
+	aggr.Tags = tags
 	sb.add(s, weight, isTop, aggr)
 }
 
@@ -7,6 +7,8 @@ package traceutil
 
 import (
 	"bytes"
+	"fmt"
+	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 
@@ -23,6 +25,11 @@ const (
 	tracerTopLevelKey = "_dd.top_level"
 	// partialVersionKey is a metric carrying the snapshot seq number in the case the span is a partial snapshot
 	partialVersionKey = "_dd.partial_version"
+
+	// TraceIDHighKey is the meta tag holding the hex encoded high 64 bits of 128-bit
+	// trace IDs, the low 64 bits being the TraceID of the span. Tracers may set it only
+	// on the first span of a chunk.
+	TraceIDHighKey = "_dd.p.tid"
 )
 
 // HasTopLevel returns true if span is top-level.
@@ -101,6 +108,29 @@ func GetMetaDefault(s *pb.Span, key, fallback string) string {
 	return fallback
 }
 
+// GetTraceIDHigh returns the high 64 bits of the trace ID of the span s, or 0 if they
+// are not set or invalid.
+func GetTraceIDHigh(s *pb.Span) uint64 {
+	v, ok := GetMeta(s, TraceIDHighKey)
+	if !ok {
+		return 0
+	}
+	high, err := strconv.ParseUint(v, 16, 64)
+	if err != nil {
+		return 0
+	}
+	return high
+}
+
+// SetTraceIDHigh sets the high 64 bits of the trace ID of the span s. Nothing is set
+// when high is 0, as for 64-bit trace IDs.
+func SetTraceIDHigh(s *pb.Span, high uint64) {
+	if high == 0 {
+		return
+	}
+	SetMeta(s, TraceIDHighKey, fmt.Sprintf("%016x", high))
+}
+
 // SetMetaStruct sets the structured metadata at key to the val on the span s.
 func SetMetaStruct(s *pb.Span, key string, val interface{}) error {
 	var b bytes.Buffer
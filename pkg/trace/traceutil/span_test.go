@@ -215,3 +215,18 @@ func TestIsPartialSnapshot(t *testing.T) {
 	span.Metrics = map[string]float64{"_dd.partial_version": float64(rand.Uint32())}
 	assert.True(IsPartialSnapshot(span), "Any value in partialVersion key will mark the span as incomplete")
 }
+
+func TestGetSetTraceIDHigh(t *testing.T) {
+	s := &pb.Span{}
+	assert.Equal(t, uint64(0), GetTraceIDHigh(s))
+
+	SetTraceIDHigh(s, 0)
+	assert.Nil(t, s.Meta)
+
+	SetTraceIDHigh(s, 0x5af7183f)
+	assert.Equal(t, "000000005af7183f", s.Meta[TraceIDHighKey])
+	assert.Equal(t, uint64(0x5af7183f), GetTraceIDHigh(s))
+
+	s.Meta[TraceIDHighKey] = "invalid"
+	assert.Equal(t, uint64(0), GetTraceIDHigh(s))
+}
@@ -49,6 +49,37 @@ func GetAppVersion(root *pb.Span, t *pb.TraceChunk) string {
 	return ""
 }
 
+// TraceID is a 128-bit trace ID. High is 0 for 64-bit trace IDs.
+type TraceID struct {
+	High uint64
+	Low  uint64
+}
+
+// GetTraceID returns the 128-bit trace ID of the trace t, whose spans share the same
+// TraceID. The high bits are taken from the first span holding them, as tracers may
+// set them only on the first span of a chunk.
+func GetTraceID(t pb.Trace) TraceID {
+	if len(t) == 0 {
+		return TraceID{}
+	}
+	for _, s := range t {
+		if high := GetTraceIDHigh(s); high != 0 {
+			return TraceID{High: high, Low: t[0].TraceID}
+		}
+	}
+	return TraceID{Low: t[0].TraceID}
+}
+
+// GroupByTraceID groups the spans by 128-bit trace ID.
+func GroupByTraceID(spans []*pb.Span) map[TraceID]pb.Trace {
+	traces := make(map[TraceID]pb.Trace)
+	for _, s := range spans {
+		id := TraceID{High: GetTraceIDHigh(s), Low: s.TraceID}
+		traces[id] = append(traces[id], s)
+	}
+	return traces
+}
+
 // GetRoot extracts the root span from a trace
 func GetRoot(t pb.Trace) *pb.Span {
 	// That should be caught beforehand
@@ -137,3 +137,31 @@ func TestGetAppVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestGetTraceID(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(TraceID{}, GetTraceID(nil))
+	assert.Equal(TraceID{Low: 1}, GetTraceID(pb.Trace{{TraceID: 1}}))
+
+	// tracers may set the high bits only on the first span of a chunk
+	trace := pb.Trace{
+		{TraceID: 1, SpanID: 2, ParentID: 1},
+		{TraceID: 1, SpanID: 1, Meta: map[string]string{TraceIDHighKey: "5af7183fb1d4cf5f"}},
+	}
+	assert.Equal(TraceID{High: 0x5af7183fb1d4cf5f, Low: 1}, GetTraceID(trace))
+}
+
+func TestGroupByTraceID(t *testing.T) {
+	high := map[string]string{TraceIDHighKey: "5af7183fb1d4cf5f"}
+	spans := []*pb.Span{
+		{TraceID: 1, SpanID: 1},
+		{TraceID: 1, SpanID: 2, Meta: high},
+		{TraceID: 2, SpanID: 3},
+		{TraceID: 1, SpanID: 4, Meta: high},
+	}
+	assert.Equal(t, map[TraceID]pb.Trace{
+		{Low: 1}:                           {spans[0]},
+		{High: 0x5af7183fb1d4cf5f, Low: 1}: {spans[1], spans[3]},
+		{Low: 2}:                           {spans[2]},
+	}, GroupByTraceID(spans))
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/gogo/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 )
@@ -53,6 +54,32 @@ func TestTraceWriter(t *testing.T) {
 		assert.Equal(t, 2, srv.Accepted())
 		payloadsContain(t, srv.Payloads(), testSpans)
 	})
+
+}
+
+func TestTraceWriter128BitTraceIDs(t *testing.T) {
+	srv := newTestServer()
+	cfg := &config.AgentConfig{
+		Hostname:   testHostname,
+		DefaultEnv: testEnv,
+		Endpoints: []*config.Endpoint{{
+			APIKey: "123",
+			Host:   srv.URL,
+		}},
+		TraceWriter: &config.WriterConfig{ConnectionLimit: 200, QueueSize: 40},
+	}
+	testSpans := randomSampledSpans(10, 0)
+	for _, s := range testSpans.TracerPayload.Chunks[0].Spans {
+		traceutil.SetTraceIDHigh(s, 0x5af7183fb1d4cf5f)
+	}
+	tw := NewTraceWriter(cfg)
+	tw.In = make(chan *SampledChunks)
+	go tw.Run()
+	tw.In <- testSpans
+	tw.Stop()
+	// the high bits of the trace IDs are preserved in the payloads
+	assert.Equal(t, 1, srv.Accepted())
+	payloadsContain(t, srv.Payloads(), []*SampledChunks{testSpans})
 }
 
 func TestTraceWriterMultipleEndpointsConcurrent(t *testing.T) {